@@ -8,9 +8,13 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"./grpctransport"
 	"./tangram"
 	"./webserver"
 	"github.com/gorilla/websocket"
@@ -26,6 +30,8 @@ func main() {
 	rpcPort := flag.Int("p", 9000, "address to expose")
 	identifier := flag.Int("i", 0, "identifier for this client")
 	local := flag.Bool("l", false, "prevent public IP lookup")
+	useGRPC := flag.Bool("g", false, "use gRPC to talk to peers")
+	capacity := flag.Int64("b", 0, "spare bandwidth in kbit/s advertised in host elections")
 
 	flag.Parse()
 
@@ -55,16 +61,48 @@ func main() {
 
 	rpcAddr := fmt.Sprintf("%v:%v", ip, *rpcPort)
 
+	transport := tangram.TCPTransport
+	if *useGRPC {
+		transport = grpctransport.New()
+	}
+
+	// A player with a fixed identifier keeps its session token, so it can rejoin after a crash
+	sessionFile := fmt.Sprintf(".session-%d", *identifier)
+	token, _ := ioutil.ReadFile(sessionFile)
+
 	var game *tangram.Game
 	if *remoteAddr == "" {
-		game, err = tangram.NewGame(config, rpcAddr, *identifier)
+		game, err = tangram.NewGameWithTransport(transport, config, rpcAddr, *identifier)
+	} else if *identifier != 0 && len(token) > 0 {
+		game, err = tangram.RejoinGameWithTransport(transport, *remoteAddr, rpcAddr, *identifier, strings.TrimSpace(string(token)))
 	} else {
-		game, err = tangram.ConnectToGame(*remoteAddr, rpcAddr, *identifier)
+		game, err = tangram.ConnectToGameWithTransport(transport, *remoteAddr, rpcAddr, *identifier)
 	}
 
 	if err != nil {
 		log.Fatalln(err)
 	}
+	game.SetCapacity(*capacity)
+
+	if *identifier != 0 {
+		err = ioutil.WriteFile(sessionFile, []byte(game.GetSession()), 0600)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	// Leave the game properly on Ctrl-C, so peers do not have to wait to notice
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Println("Leaving the game")
+		err := game.Leave()
+		if err != nil {
+			log.Println(err)
+		}
+		os.Exit(0)
+	}()
 
 	http.HandleFunc("/ws", getWebSocketHandler(game))
 	http.Handle("/", http.FileServer(http.Dir("web")))
@@ -77,7 +115,7 @@ func main() {
 		addr = ":8080"
 		fmt.Println("[Default] Listening to requests at addr", addr)
 	} else {
-		fmt.Println("usage: go run client.go [-i identifier] [-l] [-c remote-address] [-p rpc-port] [address]")
+		fmt.Println("usage: go run client.go [-i identifier] [-l] [-g] [-c remote-address] [-p rpc-port] [address]")
 		return
 	}
 
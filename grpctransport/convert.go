@@ -0,0 +1,459 @@
+package grpctransport
+
+import (
+	"time"
+
+	"../lamport"
+	"../tangram"
+	pb "../tangrampb"
+)
+
+// Conversions between the types of the tangram package and their protobuf messages
+
+func stampToPB(stamp lamport.Timestamp) *pb.Timestamp {
+	return &pb.Timestamp{Wall: stamp.Wall, Logical: stamp.Logical}
+}
+
+func stampFromPB(stamp *pb.Timestamp) lamport.Timestamp {
+	return lamport.Timestamp{Wall: stamp.GetWall(), Logical: stamp.GetLogical()}
+}
+
+// timeToPB encodes a time as nanoseconds since the unix epoch, the zero time is encoded as 0
+func timeToPB(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func timeFromPB(t int64) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, t)
+}
+
+func vectorToPB(vector lamport.VectorClock) map[int64]uint64 {
+	if vector == nil {
+		return nil
+	}
+	result := make(map[int64]uint64, len(vector))
+	for id, t := range vector {
+		result[int64(id)] = t
+	}
+	return result
+}
+
+func vectorFromPB(vector map[int64]uint64) lamport.VectorClock {
+	if len(vector) == 0 {
+		return nil
+	}
+	result := make(lamport.VectorClock, len(vector))
+	for id, t := range vector {
+		result[lamport.NodeID(id)] = t
+	}
+	return result
+}
+
+func lockToPB(tan tangram.TanID, player tangram.PlayerID, t lamport.Time, vector lamport.VectorClock, stamp lamport.Timestamp) *pb.LockTanRequest {
+	return &pb.LockTanRequest{
+		Tan:    tan,
+		Player: int64(player),
+		Time:   t,
+		Vector: vectorToPB(vector),
+		Stamp:  stampToPB(stamp),
+	}
+}
+
+func moveToPB(move tangram.MoveTanRequest) *pb.MoveTanRequest {
+	return &pb.MoveTanRequest{
+		Tan:      move.Tan,
+		Player:   int64(move.Player),
+		From:     int64(move.From),
+		Location: pointToPB(move.Location),
+		Rotation: move.Rotation,
+		Time:     move.Time,
+		Vector:   vectorToPB(move.Vector),
+		Expiry:   timeToPB(move.Expiry),
+		Stamp:    stampToPB(move.Stamp),
+	}
+}
+
+func moveFromPB(move *pb.MoveTanRequest) tangram.MoveTanRequest {
+	return tangram.MoveTanRequest{
+		Tan:      move.Tan,
+		Player:   tangram.PlayerID(move.Player),
+		From:     tangram.PlayerID(move.From),
+		Location: pointFromPB(move.Location),
+		Rotation: move.Rotation,
+		Time:     move.Time,
+		Vector:   vectorFromPB(move.Vector),
+		Expiry:   timeFromPB(move.Expiry),
+		Stamp:    stampFromPB(move.Stamp),
+	}
+}
+
+func pointToPB(point tangram.Point) *pb.Point {
+	return &pb.Point{X: point.X, Y: point.Y}
+}
+
+func pointFromPB(point *pb.Point) tangram.Point {
+	return tangram.Point{X: point.GetX(), Y: point.GetY()}
+}
+
+func shapeToPB(shape *tangram.Shape) *pb.Shape {
+	if shape == nil {
+		return nil
+	}
+	result := &pb.Shape{Fill: shape.Fill, Stroke: shape.Stroke}
+	for _, point := range shape.Points {
+		result.Points = append(result.Points, pointToPB(point))
+	}
+	return result
+}
+
+func shapeFromPB(shape *pb.Shape) *tangram.Shape {
+	if shape == nil {
+		return nil
+	}
+	result := &tangram.Shape{Fill: shape.Fill, Stroke: shape.Stroke}
+	for _, point := range shape.Points {
+		result.Points = append(result.Points, pointFromPB(point))
+	}
+	return result
+}
+
+func tanToPB(tan *tangram.Tan) *pb.Tan {
+	return &pb.Tan{
+		Id:        tan.ID,
+		Shape:     shapeToPB(tan.Shape),
+		ShapeType: string(tan.ShapeType),
+		Player:    int64(tan.Player),
+		Location:  pointToPB(tan.Location),
+		Rotation:  tan.Rotation,
+		Clock:     tan.Clock.Time(),
+		Vector:    vectorToPB(tan.Vector),
+		Claimed:   tan.Claimed,
+		Expiry:    timeToPB(tan.Expiry),
+		Matched:   tan.Matched,
+	}
+}
+
+func tanFromPB(tan *pb.Tan) *tangram.Tan {
+	result := &tangram.Tan{
+		ID:        tan.Id,
+		Shape:     shapeFromPB(tan.Shape),
+		ShapeType: tangram.ShapeType(tan.ShapeType),
+		Player:    tangram.PlayerID(tan.Player),
+		Location:  pointFromPB(tan.Location),
+		Rotation:  tan.Rotation,
+		Vector:    vectorFromPB(tan.Vector),
+		Claimed:   tan.Claimed,
+		Expiry:    timeFromPB(tan.Expiry),
+		Matched:   tan.Matched,
+	}
+	result.Clock.Reset(tan.Clock)
+	return result
+}
+
+func targetToPB(target *tangram.TargetTan) *pb.TargetTan {
+	return &pb.TargetTan{
+		Shape:     shapeToPB(target.Shape),
+		ShapeType: string(target.ShapeType),
+		Location:  pointToPB(target.Location),
+		Rotation:  target.Rotation,
+	}
+}
+
+func targetFromPB(target *pb.TargetTan) *tangram.TargetTan {
+	return &tangram.TargetTan{
+		Shape:     shapeFromPB(target.Shape),
+		ShapeType: tangram.ShapeType(target.ShapeType),
+		Location:  pointFromPB(target.Location),
+		Rotation:  target.Rotation,
+	}
+}
+
+func playerToPB(player *tangram.Player) *pb.Player {
+	if player == nil {
+		return nil
+	}
+	return &pb.Player{Id: int64(player.ID), Name: player.Name, Addr: player.Addr, Session: player.Session}
+}
+
+func playerFromPB(player *pb.Player) *tangram.Player {
+	if player == nil {
+		return nil
+	}
+	return &tangram.Player{ID: tangram.PlayerID(player.Id), Name: player.Name, Addr: player.Addr, Session: player.Session}
+}
+
+func updatesToPB(updates []tangram.MemberUpdate) (result []*pb.MemberUpdate) {
+	for i := range updates {
+		result = append(result, &pb.MemberUpdate{
+			Player:      playerToPB(&updates[i].Player),
+			Status:      pb.MemberStatus(updates[i].Status),
+			Incarnation: updates[i].Incarnation,
+		})
+	}
+	return
+}
+
+func updatesFromPB(updates []*pb.MemberUpdate) (result []tangram.MemberUpdate) {
+	for _, update := range updates {
+		player := playerFromPB(update.Player)
+		if player == nil {
+			continue
+		}
+		result = append(result, tangram.MemberUpdate{
+			Player:      *player,
+			Status:      tangram.MemberStatus(update.Status),
+			Incarnation: update.Incarnation,
+		})
+	}
+	return
+}
+
+func stateToPB(state *tangram.GameState) *pb.GameState {
+	if state == nil {
+		return nil
+	}
+	result := &pb.GameState{
+		Timer:    timeToPB(state.Timer),
+		Host:     int64(state.Host),
+		Solved:   state.Solved,
+		Election: string(state.Election),
+		Term:     state.Term,
+		Relays:   planToPB(state.Relays),
+	}
+	for _, tan := range state.Tans {
+		result.Tans = append(result.Tans, tanToPB(tan))
+	}
+	for _, player := range state.Players {
+		result.Players = append(result.Players, playerToPB(player))
+	}
+	return result
+}
+
+func stateFromPB(state *pb.GameState) *tangram.GameState {
+	if state == nil {
+		return nil
+	}
+	result := &tangram.GameState{
+		Timer:    timeFromPB(state.Timer),
+		Host:     tangram.PlayerID(state.Host),
+		Solved:   state.Solved,
+		Election: tangram.ElectionStatus(state.Election),
+		Term:     state.Term,
+		Relays:   planFromPB(state.Relays),
+	}
+	for _, tan := range state.Tans {
+		result.Tans = append(result.Tans, tanFromPB(tan))
+	}
+	for _, player := range state.Players {
+		result.Players = append(result.Players, playerFromPB(player))
+	}
+	return result
+}
+
+func configToPB(config *tangram.GameConfig) *pb.GameConfig {
+	if config == nil {
+		return nil
+	}
+	result := &pb.GameConfig{
+		Size:   pointToPB(config.Size),
+		Offset: pointToPB(config.Offset),
+		Margin: config.Margin,
+		Host:   config.Host,
+
+		EvictionThreshold: config.EvictionThreshold,
+		HostPolicy:        config.HostPolicy,
+		Tiered:            config.Tiered,
+		RaftDir:           config.RaftDir,
+	}
+	for _, id := range config.Replicas {
+		result.Replicas = append(result.Replicas, int64(id))
+	}
+	for _, id := range config.PinnedHosts {
+		result.PinnedHosts = append(result.PinnedHosts, int64(id))
+	}
+	for _, tan := range config.Tans {
+		result.Tans = append(result.Tans, tanToPB(tan))
+	}
+	for _, target := range config.Targets {
+		result.Targets = append(result.Targets, targetToPB(target))
+	}
+	return result
+}
+
+func configFromPB(config *pb.GameConfig) *tangram.GameConfig {
+	if config == nil {
+		return nil
+	}
+	result := &tangram.GameConfig{
+		Size:   pointFromPB(config.Size),
+		Offset: pointFromPB(config.Offset),
+		Margin: config.Margin,
+		Host:   config.Host,
+
+		EvictionThreshold: config.EvictionThreshold,
+		HostPolicy:        config.HostPolicy,
+		Tiered:            config.Tiered,
+		RaftDir:           config.RaftDir,
+	}
+	for _, id := range config.Replicas {
+		result.Replicas = append(result.Replicas, tangram.PlayerID(id))
+	}
+	for _, id := range config.PinnedHosts {
+		result.PinnedHosts = append(result.PinnedHosts, tangram.PlayerID(id))
+	}
+	for _, tan := range config.Tans {
+		result.Tans = append(result.Tans, tanFromPB(tan))
+	}
+	for _, target := range config.Targets {
+		result.Targets = append(result.Targets, targetFromPB(target))
+	}
+	return result
+}
+
+func eventToPB(event tangram.Event) *pb.Event {
+	return &pb.Event{
+		Kind:     string(event.Kind),
+		Tan:      event.Tan,
+		Player:   int64(event.Player),
+		Time:     event.Time,
+		Location: pointToPB(event.Location),
+		Rotation: event.Rotation,
+		Claimed:  event.Claimed,
+		Expiry:   timeToPB(event.Expiry),
+	}
+}
+
+func eventFromPB(event *pb.Event) tangram.Event {
+	if event == nil {
+		return tangram.Event{}
+	}
+	return tangram.Event{
+		Kind:     tangram.EventKind(event.Kind),
+		Tan:      event.Tan,
+		Player:   tangram.PlayerID(event.Player),
+		Time:     event.Time,
+		Location: pointFromPB(event.Location),
+		Rotation: event.Rotation,
+		Claimed:  event.Claimed,
+		Expiry:   timeFromPB(event.Expiry),
+	}
+}
+
+func entriesToPB(entries []tangram.LogEntry) (result []*pb.LogEntry) {
+	for _, entry := range entries {
+		result = append(result, &pb.LogEntry{Term: entry.Term, Event: eventToPB(entry.Event)})
+	}
+	return
+}
+
+func entriesFromPB(entries []*pb.LogEntry) (result []tangram.LogEntry) {
+	for _, entry := range entries {
+		result = append(result, tangram.LogEntry{Term: entry.Term, Event: eventFromPB(entry.Event)})
+	}
+	return
+}
+
+func snapshotToPB(snapshot *tangram.RaftSnapshot) *pb.RaftSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	result := &pb.RaftSnapshot{Index: snapshot.Index, Term: snapshot.Term}
+	for _, event := range snapshot.Events {
+		result.Events = append(result.Events, eventToPB(event))
+	}
+	return result
+}
+
+func snapshotFromPB(snapshot *pb.RaftSnapshot) *tangram.RaftSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	result := &tangram.RaftSnapshot{Index: snapshot.Index, Term: snapshot.Term}
+	for _, event := range snapshot.Events {
+		result.Events = append(result.Events, eventFromPB(event))
+	}
+	return result
+}
+
+func candidateToPB(candidate tangram.Candidate) *pb.Candidate {
+	return &pb.Candidate{
+		Player:   int64(candidate.Player),
+		Latency:  int64(candidate.Latency),
+		Uptime:   int64(candidate.Uptime),
+		Capacity: candidate.Capacity,
+	}
+}
+
+func candidateFromPB(candidate *pb.Candidate) tangram.Candidate {
+	return tangram.Candidate{
+		Player:   tangram.PlayerID(candidate.Player),
+		Latency:  time.Duration(candidate.Latency),
+		Uptime:   time.Duration(candidate.Uptime),
+		Capacity: candidate.Capacity,
+	}
+}
+
+func reportsToPB(reports []tangram.LinkReport) (result []*pb.LinkReport) {
+	for _, report := range reports {
+		r := &pb.LinkReport{Player: int64(report.Player), Version: report.Version}
+		for _, link := range report.Links {
+			r.Links = append(r.Links, &pb.Link{
+				From:   int64(link.From),
+				To:     int64(link.To),
+				Rtt:    int64(link.RTT),
+				Jitter: int64(link.Jitter),
+				Loss:   link.Loss,
+			})
+		}
+		result = append(result, r)
+	}
+	return
+}
+
+func reportsFromPB(reports []*pb.LinkReport) (result []tangram.LinkReport) {
+	for _, report := range reports {
+		r := tangram.LinkReport{Player: tangram.PlayerID(report.Player), Version: report.Version}
+		for _, link := range report.Links {
+			r.Links = append(r.Links, tangram.Link{
+				From:   tangram.PlayerID(link.From),
+				To:     tangram.PlayerID(link.To),
+				RTT:    time.Duration(link.Rtt),
+				Jitter: time.Duration(link.Jitter),
+				Loss:   link.Loss,
+			})
+		}
+		result = append(result, r)
+	}
+	return
+}
+
+func planToPB(plan tangram.RelayPlan) *pb.RelayPlan {
+	result := &pb.RelayPlan{Planner: int64(plan.Planner), Version: plan.Version}
+	if len(plan.Relays) > 0 {
+		result.Relays = make(map[int64]int64)
+	}
+	for id, relay := range plan.Relays {
+		result.Relays[int64(id)] = int64(relay)
+	}
+	return result
+}
+
+func planFromPB(plan *pb.RelayPlan) (result tangram.RelayPlan) {
+	if plan == nil {
+		return
+	}
+	result = tangram.RelayPlan{Planner: tangram.PlayerID(plan.Planner), Version: plan.Version}
+	if len(plan.Relays) > 0 {
+		result.Relays = make(map[tangram.PlayerID]tangram.PlayerID)
+	}
+	for id, relay := range plan.Relays {
+		result.Relays[tangram.PlayerID(id)] = tangram.PlayerID(relay)
+	}
+	return
+}
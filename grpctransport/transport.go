@@ -0,0 +1,475 @@
+// Package grpctransport carries the RPCs between tangram nodes over gRPC,
+// using the protobuf schema of the tangrampb package
+package grpctransport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"time"
+
+	"../tangram"
+	pb "../tangrampb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// New creates a tangram.Transport serving gRPC over TCP
+func New() tangram.Transport {
+	return transport{}
+}
+
+type transport struct{}
+
+func (transport) Listen(addr string, node *tangram.Node) (io.Closer, error) {
+	// Listen on every interface, addr may be the public address of this machine
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, err
+	}
+
+	server := grpc.NewServer()
+	pb.RegisterNodeServer(server, &nodeServer{node: node})
+	go server.Serve(listener)
+	return serverCloser{server}, nil
+}
+
+func (transport) Dial(addr string) (tangram.Conn, error) {
+	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &conn{cc, pb.NewNodeClient(cc)}, nil
+}
+
+type serverCloser struct {
+	server *grpc.Server
+}
+
+func (s serverCloser) Close() error {
+	s.server.Stop()
+	return nil
+}
+
+// conn translates the net/rpc style calls of the tangram package into gRPC calls
+type conn struct {
+	cc     *grpc.ClientConn
+	client pb.NodeClient
+}
+
+func (c *conn) Close() error {
+	return c.cc.Close()
+}
+
+func (c *conn) Call(method string, args interface{}, reply interface{}) (err error) {
+	ctx := context.Background()
+	switch req := args.(type) {
+	case tangram.ConnectRequest:
+		var res *pb.ConnectResponse
+		res, err = c.client.Connect(ctx, &pb.ConnectRequest{Player: playerToPB(&req.Player), Token: req.Token, Stamp: stampToPB(req.Stamp), Incarnation: req.Incarnation})
+		if err == nil {
+			*reply.(*tangram.ConnectResponse) = tangram.ConnectResponse{
+				State:       stateFromPB(res.State),
+				Config:      configFromPB(res.Config),
+				Player:      playerFromPB(res.Player),
+				Stamp:       stampFromPB(res.Stamp),
+				Incarnation: res.Incarnation,
+			}
+		}
+	case tangram.LockTanRequest:
+		var res *pb.LockTanResponse
+		lock := lockToPB(req.Tan, req.Player, req.Time, req.Vector, req.Stamp)
+		lock.From = int64(req.From)
+		res, err = c.client.LockTan(ctx, lock)
+		if err == nil {
+			*reply.(*tangram.LockTanResponse) = tangram.LockTanResponse{
+				Ok:     res.Ok,
+				Time:   res.Time,
+				Vector: vectorFromPB(res.Vector),
+			}
+		}
+	case tangram.UnlockTanRequest:
+		unlock := lockToPB(req.Tan, req.Player, req.Time, req.Vector, req.Stamp)
+		unlock.From = int64(req.From)
+		err = okReply(reply)(c.client.UnlockTan(ctx, unlock))
+	case tangram.MoveTanRequest:
+		err = okReply(reply)(c.client.MoveTan(ctx, moveToPB(req)))
+	case tangram.MoveBatchRequest:
+		batch := &pb.MoveBatchRequest{Player: int64(req.Player), Stamp: stampToPB(req.Stamp)}
+		for _, move := range req.Moves {
+			batch.Moves = append(batch.Moves, moveToPB(move))
+		}
+		var res *pb.MoveBatchResponse
+		res, err = c.client.MoveTans(ctx, batch)
+		if err == nil {
+			*reply.(*tangram.MoveBatchResponse) = tangram.MoveBatchResponse{Moved: res.Moved}
+		}
+	case tangram.UpdateRequest:
+		err = okReply(reply)(c.client.PushUpdate(ctx, &pb.UpdateRequest{
+			Player:  int64(req.Player),
+			Version: req.Version,
+			State:   stateToPB(req.State),
+			Stamp:   stampToPB(req.Stamp),
+		}))
+	case tangram.DeltaRequest:
+		err = okReply(reply)(c.client.PushDelta(ctx, &pb.DeltaRequest{
+			Player:  int64(req.Player),
+			Base:    req.Base,
+			Version: req.Version,
+			Delta:   stateToPB(req.Delta),
+			Stamp:   stampToPB(req.Stamp),
+		}))
+	case tangram.PingRequest:
+		var res *pb.PingResponse
+		res, err = c.client.Ping(ctx, &pb.PingRequest{Player: int64(req.Player), Stamp: stampToPB(req.Stamp)})
+		if err == nil {
+			reply.(*tangram.PingResponse).Stamp = stampFromPB(res.Stamp)
+		}
+	case tangram.GossipRequest:
+		var res *pb.GossipResponse
+		res, err = c.client.Gossip(ctx, &pb.GossipRequest{
+			Player:  int64(req.Player),
+			Updates: updatesToPB(req.Updates),
+			Links:   reportsToPB(req.Links),
+			Relays:  planToPB(req.Relays),
+			Stamp:   stampToPB(req.Stamp),
+		})
+		if err == nil {
+			*reply.(*tangram.GossipResponse) = tangram.GossipResponse{
+				Updates: updatesFromPB(res.Updates),
+				Links:   reportsFromPB(res.Links),
+				Relays:  planFromPB(res.Relays),
+				Stamp:   stampFromPB(res.Stamp),
+			}
+		}
+	case tangram.ProbeRequest:
+		err = okReply(reply)(c.client.ProbeMember(ctx, &pb.ProbeRequest{
+			Player:  int64(req.Player),
+			Target:  int64(req.Target),
+			Updates: updatesToPB(req.Updates),
+			Stamp:   stampToPB(req.Stamp),
+		}))
+	case tangram.ElectionRequest:
+		var res *pb.ElectionResponse
+		res, err = c.client.Election(ctx, &pb.ElectionRequest{
+			Player:   int64(req.Player),
+			Term:     req.Term,
+			Latency:  int64(req.Candidate.Latency),
+			Uptime:   int64(req.Candidate.Uptime),
+			Capacity: req.Candidate.Capacity,
+			Stamp:    stampToPB(req.Stamp),
+		})
+		if err == nil {
+			*reply.(*tangram.ElectionResponse) = tangram.ElectionResponse{Answer: res.Answer, Stamp: stampFromPB(res.Stamp)}
+		}
+	case tangram.CoordinatorRequest:
+		err = okReply(reply)(c.client.Coordinator(ctx, &pb.CoordinatorRequest{
+			Player: int64(req.Player),
+			Term:   req.Term,
+			Stamp:  stampToPB(req.Stamp),
+		}))
+	case tangram.VoteRequest:
+		var res *pb.VoteResponse
+		res, err = c.client.RequestVote(ctx, &pb.VoteRequest{
+			Player:    int64(req.Player),
+			Term:      req.Term,
+			LastIndex: req.LastIndex,
+			LastTerm:  req.LastTerm,
+			Stamp:     stampToPB(req.Stamp),
+		})
+		if err == nil {
+			*reply.(*tangram.VoteResponse) = tangram.VoteResponse{Term: res.Term, Granted: res.Granted, Stamp: stampFromPB(res.Stamp)}
+		}
+	case tangram.AppendRequest:
+		var res *pb.AppendResponse
+		res, err = c.client.AppendEntries(ctx, &pb.AppendRequest{
+			Player:    int64(req.Player),
+			Term:      req.Term,
+			PrevIndex: req.PrevIndex,
+			PrevTerm:  req.PrevTerm,
+			Entries:   entriesToPB(req.Entries),
+			Commit:    req.Commit,
+			Snapshot:  snapshotToPB(req.Snapshot),
+			Stamp:     stampToPB(req.Stamp),
+		})
+		if err == nil {
+			*reply.(*tangram.AppendResponse) = tangram.AppendResponse{Term: res.Term, Success: res.Success, Match: res.Match, Stamp: stampFromPB(res.Stamp)}
+		}
+	case tangram.LeaveRequest:
+		err = okReply(reply)(c.client.Leave(ctx, &pb.LeaveRequest{
+			Player:      playerToPB(&req.Player),
+			Incarnation: req.Incarnation,
+			Stamp:       stampToPB(req.Stamp),
+		}))
+	default:
+		err = c.callInt(ctx, method, args, reply)
+	}
+
+	// Errors returned by the node itself come back as Unknown, report them the way net/rpc does
+	// so that they are not mistaken for a broken connection
+	if s, ok := status.FromError(err); ok && err != nil && s.Code() == codes.Unknown {
+		err = rpc.ServerError(s.Message())
+	}
+	return
+}
+
+// callInt handles the methods whose argument is a plain int
+func (c *conn) callInt(ctx context.Context, method string, args interface{}, reply interface{}) (err error) {
+	arg, ok := args.(int)
+	if !ok {
+		return fmt.Errorf("grpctransport: unsupported arguments %T for %s", args, method)
+	}
+
+	switch method {
+	case "Node.GetLatency":
+		var res *pb.GetLatencyResponse
+		res, err = c.client.GetLatency(ctx, &pb.GetLatencyRequest{})
+		if err == nil {
+			*reply.(*time.Duration) = time.Duration(res.Latency)
+		}
+	case "Node.GetCandidate":
+		var res *pb.Candidate
+		res, err = c.client.GetCandidate(ctx, &pb.GetCandidateRequest{})
+		if err == nil {
+			*reply.(*tangram.Candidate) = candidateFromPB(res)
+		}
+	case "Node.HostElection":
+		err = okReply(reply)(c.client.HostElection(ctx, &pb.HostElectionRequest{}))
+	case "Node.ConnectToMe":
+		err = okReply(reply)(c.client.ConnectToMe(ctx, &pb.ConnectToMeRequest{Host: int64(arg)}))
+	default:
+		err = fmt.Errorf("grpctransport: unsupported method %s", method)
+	}
+	return
+}
+
+// okReply returns a function storing the result of a call answering with an OkResponse into reply
+func okReply(reply interface{}) func(*pb.OkResponse, error) error {
+	return func(res *pb.OkResponse, err error) error {
+		if err != nil {
+			return err
+		}
+		*reply.(*bool) = res.Ok
+		return nil
+	}
+}
+
+// nodeServer serves the gRPC service by calling the RPC methods of a tangram.Node
+type nodeServer struct {
+	pb.UnimplementedNodeServer
+	node *tangram.Node
+}
+
+func (s *nodeServer) Connect(ctx context.Context, req *pb.ConnectRequest) (*pb.ConnectResponse, error) {
+	var res tangram.ConnectResponse
+	err := s.node.Connect(&tangram.ConnectRequest{Player: *playerFromPB(req.Player), Token: req.Token, Stamp: stampFromPB(req.Stamp), Incarnation: req.Incarnation}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ConnectResponse{
+		State:       stateToPB(res.State),
+		Config:      configToPB(res.Config),
+		Player:      playerToPB(res.Player),
+		Stamp:       stampToPB(res.Stamp),
+		Incarnation: res.Incarnation,
+	}, nil
+}
+
+func (s *nodeServer) LockTan(ctx context.Context, req *pb.LockTanRequest) (*pb.LockTanResponse, error) {
+	var res tangram.LockTanResponse
+	err := s.node.LockTan(tangram.LockTanRequest{
+		Tan:    req.Tan,
+		Player: tangram.PlayerID(req.Player),
+		From:   tangram.PlayerID(req.From),
+		Time:   req.Time,
+		Vector: vectorFromPB(req.Vector),
+		Stamp:  stampFromPB(req.Stamp),
+	}, &res)
+	return &pb.LockTanResponse{Ok: res.Ok, Time: res.Time, Vector: vectorToPB(res.Vector)}, err
+}
+
+func (s *nodeServer) UnlockTan(ctx context.Context, req *pb.LockTanRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.UnlockTan(tangram.UnlockTanRequest{
+		Tan:    req.Tan,
+		Player: tangram.PlayerID(req.Player),
+		From:   tangram.PlayerID(req.From),
+		Time:   req.Time,
+		Vector: vectorFromPB(req.Vector),
+		Stamp:  stampFromPB(req.Stamp),
+	}, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) MoveTan(ctx context.Context, req *pb.MoveTanRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.MoveTan(moveFromPB(req), &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) MoveTans(ctx context.Context, req *pb.MoveBatchRequest) (*pb.MoveBatchResponse, error) {
+	var res tangram.MoveBatchResponse
+	batch := tangram.MoveBatchRequest{Player: tangram.PlayerID(req.Player), Stamp: stampFromPB(req.Stamp)}
+	for _, move := range req.Moves {
+		batch.Moves = append(batch.Moves, moveFromPB(move))
+	}
+	err := s.node.MoveTans(batch, &res)
+	return &pb.MoveBatchResponse{Moved: res.Moved}, err
+}
+
+func (s *nodeServer) PushUpdate(ctx context.Context, req *pb.UpdateRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.PushUpdate(tangram.UpdateRequest{
+		Player:  tangram.PlayerID(req.Player),
+		Version: req.Version,
+		State:   stateFromPB(req.State),
+		Stamp:   stampFromPB(req.Stamp),
+	}, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) PushDelta(ctx context.Context, req *pb.DeltaRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.PushDelta(tangram.DeltaRequest{
+		Player:  tangram.PlayerID(req.Player),
+		Base:    req.Base,
+		Version: req.Version,
+		Delta:   stateFromPB(req.Delta),
+		Stamp:   stampFromPB(req.Stamp),
+	}, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	var res tangram.PingResponse
+	err := s.node.Ping(tangram.PingRequest{Player: tangram.PlayerID(req.Player), Stamp: stampFromPB(req.Stamp)}, &res)
+	return &pb.PingResponse{Stamp: stampToPB(res.Stamp)}, err
+}
+
+func (s *nodeServer) GetLatency(ctx context.Context, req *pb.GetLatencyRequest) (*pb.GetLatencyResponse, error) {
+	var latency time.Duration
+	err := s.node.GetLatency(0, &latency)
+	return &pb.GetLatencyResponse{Latency: int64(latency)}, err
+}
+
+func (s *nodeServer) GetCandidate(ctx context.Context, req *pb.GetCandidateRequest) (*pb.Candidate, error) {
+	var candidate tangram.Candidate
+	err := s.node.GetCandidate(0, &candidate)
+	return candidateToPB(candidate), err
+}
+
+func (s *nodeServer) HostElection(ctx context.Context, req *pb.HostElectionRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.HostElection(0, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) ConnectToMe(ctx context.Context, req *pb.ConnectToMeRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.ConnectToMe(tangram.PlayerID(req.Host), &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) Election(ctx context.Context, req *pb.ElectionRequest) (*pb.ElectionResponse, error) {
+	var res tangram.ElectionResponse
+	err := s.node.Election(tangram.ElectionRequest{
+		Player: tangram.PlayerID(req.Player),
+		Term:   req.Term,
+		Candidate: tangram.Candidate{
+			Player:   tangram.PlayerID(req.Player),
+			Latency:  time.Duration(req.Latency),
+			Uptime:   time.Duration(req.Uptime),
+			Capacity: req.Capacity,
+		},
+		Stamp: stampFromPB(req.Stamp),
+	}, &res)
+	return &pb.ElectionResponse{Answer: res.Answer, Stamp: stampToPB(res.Stamp)}, err
+}
+
+func (s *nodeServer) Coordinator(ctx context.Context, req *pb.CoordinatorRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.Coordinator(tangram.CoordinatorRequest{
+		Player: tangram.PlayerID(req.Player),
+		Term:   req.Term,
+		Stamp:  stampFromPB(req.Stamp),
+	}, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) RequestVote(ctx context.Context, req *pb.VoteRequest) (*pb.VoteResponse, error) {
+	var res tangram.VoteResponse
+	err := s.node.RequestVote(tangram.VoteRequest{
+		Player:    tangram.PlayerID(req.Player),
+		Term:      req.Term,
+		LastIndex: req.LastIndex,
+		LastTerm:  req.LastTerm,
+		Stamp:     stampFromPB(req.Stamp),
+	}, &res)
+	return &pb.VoteResponse{Term: res.Term, Granted: res.Granted, Stamp: stampToPB(res.Stamp)}, err
+}
+
+func (s *nodeServer) AppendEntries(ctx context.Context, req *pb.AppendRequest) (*pb.AppendResponse, error) {
+	var res tangram.AppendResponse
+	err := s.node.AppendEntries(tangram.AppendRequest{
+		Player:    tangram.PlayerID(req.Player),
+		Term:      req.Term,
+		PrevIndex: req.PrevIndex,
+		PrevTerm:  req.PrevTerm,
+		Entries:   entriesFromPB(req.Entries),
+		Commit:    req.Commit,
+		Snapshot:  snapshotFromPB(req.Snapshot),
+		Stamp:     stampFromPB(req.Stamp),
+	}, &res)
+	return &pb.AppendResponse{Term: res.Term, Success: res.Success, Match: res.Match, Stamp: stampToPB(res.Stamp)}, err
+}
+
+func (s *nodeServer) Gossip(ctx context.Context, req *pb.GossipRequest) (*pb.GossipResponse, error) {
+	var res tangram.GossipResponse
+	err := s.node.Gossip(tangram.GossipRequest{
+		Player:  tangram.PlayerID(req.Player),
+		Updates: updatesFromPB(req.Updates),
+		Links:   reportsFromPB(req.Links),
+		Relays:  planFromPB(req.Relays),
+		Stamp:   stampFromPB(req.Stamp),
+	}, &res)
+	return &pb.GossipResponse{
+		Updates: updatesToPB(res.Updates),
+		Links:   reportsToPB(res.Links),
+		Relays:  planToPB(res.Relays),
+		Stamp:   stampToPB(res.Stamp),
+	}, err
+}
+
+func (s *nodeServer) ProbeMember(ctx context.Context, req *pb.ProbeRequest) (*pb.OkResponse, error) {
+	var ok bool
+	err := s.node.ProbeMember(tangram.ProbeRequest{
+		Player:  tangram.PlayerID(req.Player),
+		Target:  tangram.PlayerID(req.Target),
+		Updates: updatesFromPB(req.Updates),
+		Stamp:   stampFromPB(req.Stamp),
+	}, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
+
+func (s *nodeServer) Leave(ctx context.Context, req *pb.LeaveRequest) (*pb.OkResponse, error) {
+	var ok bool
+	player := playerFromPB(req.Player)
+	if player == nil {
+		return nil, fmt.Errorf("grpctransport: Leave without a player")
+	}
+	err := s.node.Leave(tangram.LeaveRequest{
+		Player:      *player,
+		Incarnation: req.Incarnation,
+		Stamp:       stampFromPB(req.Stamp),
+	}, &ok)
+	return &pb.OkResponse{Ok: ok}, err
+}
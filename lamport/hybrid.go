@@ -0,0 +1,108 @@
+package lamport
+
+import (
+	"sync"
+	"time"
+)
+
+// Timestamp is a reading of a HybridClock
+// - Wall: The physical component in nanoseconds since the unix epoch
+// - Logical: Orders events sharing the same physical component
+type Timestamp struct {
+	Wall    int64
+	Logical Time
+}
+
+// Before returns whether t happened before o
+func (t Timestamp) Before(o Timestamp) bool {
+	return t.Wall < o.Wall || (t.Wall == o.Wall && t.Logical < o.Logical)
+}
+
+// Time returns the physical component of the timestamp
+func (t Timestamp) Time() time.Time {
+	return time.Unix(0, t.Wall)
+}
+
+// MaxSkew bounds how far a HybridClock moves its physical time ahead of the local wall clock
+// A node whose wall clock runs further ahead than that does not drag every peer along with it
+const MaxSkew = 5 * time.Second
+
+// HybridClock is an implementation of the hybrid logical clock algorithm.
+// Its physical time catches up with the fastest wall clock it has witnessed, up to MaxSkew,
+// which keeps time based state consistent between nodes with drifting wall clocks.
+// Timestamps are ordered after every timestamp witnessed, however far ahead: past MaxSkew
+// the wall component stays at the remote one and the logical component counts on from it.
+// HybridClock is safe for concurrent use.
+type HybridClock struct {
+	mutex sync.Mutex
+	last  Timestamp
+	skew  time.Duration
+}
+
+// NewHybridClock creates a clock starting at the local wall time
+func NewHybridClock() *HybridClock {
+	return &HybridClock{}
+}
+
+// Physical returns the local wall time corrected by the skew witnessed so far
+func (c *HybridClock) Physical() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.physical()
+}
+
+// Skew returns how far the local wall clock is behind the fastest clock witnessed, at most MaxSkew
+func (c *HybridClock) Skew() time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.skew
+}
+
+// Now returns a timestamp for a local or send event
+func (c *HybridClock) Now() Timestamp {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	wall := c.physical().UnixNano()
+	if wall > c.last.Wall {
+		c.last = Timestamp{wall, 0}
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Update witnesses a remote timestamp and returns a timestamp for the receive event
+func (c *HybridClock) Update(remote Timestamp) Timestamp {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	wall := c.physical().UnixNano()
+	if remote.Wall > wall {
+		// The remote wall clock is ahead of ours, catch up with it as far as MaxSkew allows
+		c.skew += time.Duration(remote.Wall - wall)
+		if c.skew > MaxSkew {
+			c.skew = MaxSkew
+		}
+		wall = c.physical().UnixNano()
+	}
+
+	switch {
+	case wall > c.last.Wall && wall > remote.Wall:
+		c.last = Timestamp{wall, 0}
+	case c.last.Wall == remote.Wall:
+		if remote.Logical > c.last.Logical {
+			c.last.Logical = remote.Logical
+		}
+		c.last.Logical++
+	case c.last.Wall > remote.Wall:
+		c.last.Logical++
+	default:
+		c.last = Timestamp{remote.Wall, remote.Logical + 1}
+	}
+	return c.last
+}
+
+func (c *HybridClock) physical() time.Time {
+	return time.Now().Add(c.skew)
+}
@@ -0,0 +1,53 @@
+package lamport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHybridClockOrdersEvents(t *testing.T) {
+	c := NewHybridClock()
+	now := time.Now().UnixNano()
+	tests := []struct {
+		name   string
+		remote Timestamp
+	}{
+		{"behind", Timestamp{now - int64(time.Hour), 7}},
+		{"same wall", Timestamp{now, 3}},
+		{"slightly ahead", Timestamp{now + int64(time.Second), 2}},
+		{"far ahead", Timestamp{now + int64(time.Hour), 5}},
+		{"far ahead again", Timestamp{now + int64(time.Hour), 9}},
+	}
+	last := c.Now()
+	for _, test := range tests {
+		received := c.Update(test.remote)
+		if !test.remote.Before(received) {
+			t.Fatalf("%s: received %v is not after remote %v", test.name, received, test.remote)
+		}
+		if !last.Before(received) {
+			t.Fatalf("%s: received %v is not after %v", test.name, received, last)
+		}
+		sent := c.Now()
+		if !received.Before(sent) {
+			t.Fatalf("%s: sent %v is not after received %v", test.name, sent, received)
+		}
+		last = sent
+	}
+}
+
+func TestHybridClockBoundsSkew(t *testing.T) {
+	c := NewHybridClock()
+	c.Update(Timestamp{time.Now().Add(500 * time.Millisecond).UnixNano(), 0})
+	if skew := c.Skew(); skew < 400*time.Millisecond || skew > MaxSkew {
+		t.Fatalf("skew = %v after a remote clock 500ms ahead", skew)
+	}
+
+	// A clock an hour ahead moves physical time by MaxSkew alone
+	c.Update(Timestamp{time.Now().Add(time.Hour).UnixNano(), 0})
+	if skew := c.Skew(); skew != MaxSkew {
+		t.Fatalf("skew = %v, want %v", skew, MaxSkew)
+	}
+	if ahead := c.Physical().Sub(time.Now()); ahead > MaxSkew {
+		t.Fatalf("physical time is %v ahead of the wall clock", ahead)
+	}
+}
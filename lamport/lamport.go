@@ -1,31 +1,94 @@
-package lamport
-
-// Clock is an implementation of lamport clock logical time algorithm
-type Clock struct {
-	Counter Time
-}
-
-// Time is the logical time returned by all methods of a Clock
-type Time = uint64
-
-// Time returns the current local time for the lamport clock
-func (l *Clock) Time() Time {
-	return l.Counter
-}
-
-// Increment increaments the local time and returns its value after incrementing
-func (l *Clock) Increment() Time {
-	l.Counter++
-	return l.Counter
-}
-
-// Witness updates the local time to be at least one greater than the input value
-// Returns whether the local time is increased
-func (l *Clock) Witness(v Time) bool {
-	if v < l.Counter {
-		return false
-	}
-
-	l.Counter = v
-	return true
-}
+package lamport
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+)
+
+// Clock is an implementation of lamport clock logical time algorithm
+// Clock is safe for concurrent use
+type Clock struct {
+	counter Time
+}
+
+// Time is the logical time returned by all methods of a Clock
+type Time = uint64
+
+// Time returns the current local time for the lamport clock
+func (l *Clock) Time() Time {
+	return atomic.LoadUint64(&l.counter)
+}
+
+// Send increments the local time for a local or send event and returns its value after incrementing
+func (l *Clock) Send() Time {
+	return atomic.AddUint64(&l.counter, 1)
+}
+
+// Receive updates the local time to be one greater than the maximum of itself and the input value
+// Returns the local time after updating
+func (l *Clock) Receive(v Time) Time {
+	for {
+		old := atomic.LoadUint64(&l.counter)
+		next := old
+		if v > next {
+			next = v
+		}
+		next++
+		if atomic.CompareAndSwapUint64(&l.counter, old, next) {
+			return next
+		}
+	}
+}
+
+// Reset sets the local time to v, as when restoring a clock read from another node
+func (l *Clock) Reset(v Time) {
+	atomic.StoreUint64(&l.counter, v)
+}
+
+// Compare orders two events by their lamport time, using the node ID to break ties
+// Returns -1 if event a precedes event b, 1 if b precedes a, and 0 if they are the same event
+func Compare(a Time, aID NodeID, b Time, bID NodeID) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case aID < bID:
+		return -1
+	case aID > bID:
+		return 1
+	}
+	return 0
+}
+
+// GobEncode encodes the current local time
+func (l *Clock) GobEncode() ([]byte, error) {
+	return []byte(strconv.FormatUint(l.Time(), 10)), nil
+}
+
+// GobDecode sets the local time to the decoded value
+func (l *Clock) GobDecode(data []byte) error {
+	v, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&l.counter, v)
+	return nil
+}
+
+// MarshalJSON encodes the current local time as a number
+func (l *Clock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Time())
+}
+
+// UnmarshalJSON sets the local time to the decoded number
+func (l *Clock) UnmarshalJSON(data []byte) error {
+	var v Time
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&l.counter, v)
+	return nil
+}
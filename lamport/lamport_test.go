@@ -0,0 +1,70 @@
+package lamport
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClockReceive(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote Time
+		want          Time
+	}{
+		{"remote behind", 5, 2, 6},
+		{"remote equal", 5, 5, 6},
+		{"remote ahead", 5, 9, 10},
+		{"fresh clock", 0, 0, 1},
+	}
+	for _, test := range tests {
+		var c Clock
+		c.Reset(test.local)
+		if got := c.Receive(test.remote); got != test.want || c.Time() != test.want {
+			t.Errorf("%s: Receive(%d) at %d = %d, time %d, want %d", test.name, test.remote, test.local, got, c.Time(), test.want)
+		}
+	}
+}
+
+func TestClockIsSafeForConcurrentUse(t *testing.T) {
+	var c Clock
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Send()
+				c.Receive(0)
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Time() != 16000 {
+		t.Fatalf("time = %d after 16000 events, some were lost", c.Time())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Time
+		aID  NodeID
+		b    Time
+		bID  NodeID
+		want int
+	}{
+		{"earlier time", 1, 9, 2, 1, -1},
+		{"later time", 3, 1, 2, 9, 1},
+		{"tie broken by lower id", 2, 1, 2, 3, -1},
+		{"tie broken by higher id", 2, 3, 2, 1, 1},
+		{"same event", 2, 1, 2, 1, 0},
+	}
+	for _, test := range tests {
+		if got := Compare(test.a, test.aID, test.b, test.bID); got != test.want {
+			t.Errorf("%s: Compare(%d, %d, %d, %d) = %d, want %d", test.name, test.a, test.aID, test.b, test.bID, got, test.want)
+		}
+		if got := Compare(test.b, test.bID, test.a, test.aID); got != -test.want {
+			t.Errorf("%s: Compare(%d, %d, %d, %d) = %d, want %d", test.name, test.b, test.bID, test.a, test.aID, got, -test.want)
+		}
+	}
+}
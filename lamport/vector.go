@@ -0,0 +1,101 @@
+package lamport
+
+// NodeID identifies the node owning an entry of a VectorClock
+type NodeID = int
+
+// VectorClock is an implementation of the vector clock algorithm.
+// Unlike Clock it captures causality, so concurrent events can be told apart
+// from events that happened before one another.
+// A nil VectorClock is valid and represents the zero vector.
+type VectorClock map[NodeID]Time
+
+// Ordering is the causal relationship between two vector clocks
+type Ordering int
+
+const (
+	// Equal means both clocks have witnessed exactly the same events
+	Equal Ordering = iota
+	// Before means the clock happened before the other
+	Before
+	// After means the clock happened after the other
+	After
+	// Concurrent means neither clock happened before the other
+	Concurrent
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Equal:
+		return "Equal"
+	case Before:
+		return "Before"
+	case After:
+		return "After"
+	case Concurrent:
+		return "Concurrent"
+	}
+	return "Unknown"
+}
+
+// Tick increments the entry of node id and returns its value after incrementing
+func (v *VectorClock) Tick(id NodeID) Time {
+	if *v == nil {
+		*v = make(VectorClock)
+	}
+	(*v)[id]++
+	return (*v)[id]
+}
+
+// Merge updates every entry to be the maximum of itself and the other clock
+func (v *VectorClock) Merge(other VectorClock) {
+	if len(other) == 0 {
+		return
+	}
+	if *v == nil {
+		*v = make(VectorClock, len(other))
+	}
+	for id, t := range other {
+		if t > (*v)[id] {
+			(*v)[id] = t
+		}
+	}
+}
+
+// Compare returns the ordering of v relative to other
+func (v VectorClock) Compare(other VectorClock) Ordering {
+	before, after := false, false
+	for id, t := range v {
+		if t > other[id] {
+			after = true
+		} else if t < other[id] {
+			before = true
+		}
+	}
+	for id, t := range other {
+		if _, ok := v[id]; !ok && t > 0 {
+			before = true
+		}
+	}
+
+	switch {
+	case before && after:
+		return Concurrent
+	case before:
+		return Before
+	case after:
+		return After
+	}
+	return Equal
+}
+
+// Copy returns a copy of the clock that can be modified independently
+func (v VectorClock) Copy() VectorClock {
+	if v == nil {
+		return nil
+	}
+	result := make(VectorClock, len(v))
+	for id, t := range v {
+		result[id] = t
+	}
+	return result
+}
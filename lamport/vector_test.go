@@ -0,0 +1,83 @@
+package lamport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVectorClockCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		v, o VectorClock
+		want Ordering
+	}{
+		{"both nil", nil, nil, Equal},
+		{"nil and zero entries", nil, VectorClock{1: 0}, Equal},
+		{"same entries", VectorClock{1: 2, 2: 1}, VectorClock{1: 2, 2: 1}, Equal},
+		{"smaller entry", VectorClock{1: 1, 2: 1}, VectorClock{1: 2, 2: 1}, Before},
+		{"missing entry", VectorClock{1: 2}, VectorClock{1: 2, 2: 1}, Before},
+		{"nil before", nil, VectorClock{3: 1}, Before},
+		{"larger entry", VectorClock{1: 3, 2: 1}, VectorClock{1: 2, 2: 1}, After},
+		{"extra entry", VectorClock{1: 2, 2: 1}, VectorClock{1: 2}, After},
+		{"crossed entries", VectorClock{1: 2, 2: 1}, VectorClock{1: 1, 2: 2}, Concurrent},
+		{"disjoint entries", VectorClock{1: 1}, VectorClock{2: 1}, Concurrent},
+	}
+	inverse := map[Ordering]Ordering{Equal: Equal, Before: After, After: Before, Concurrent: Concurrent}
+	for _, test := range tests {
+		if got := test.v.Compare(test.o); got != test.want {
+			t.Errorf("%s: %v.Compare(%v) = %v, want %v", test.name, test.v, test.o, got, test.want)
+		}
+		if got := test.o.Compare(test.v); got != inverse[test.want] {
+			t.Errorf("%s: %v.Compare(%v) = %v, want %v", test.name, test.o, test.v, got, inverse[test.want])
+		}
+	}
+}
+
+func TestVectorClockMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		v, o VectorClock
+		want VectorClock
+	}{
+		{"into nil", nil, VectorClock{1: 2}, VectorClock{1: 2}},
+		{"nil into", VectorClock{1: 2}, nil, VectorClock{1: 2}},
+		{"entry maxima", VectorClock{1: 3, 2: 1}, VectorClock{1: 1, 2: 4, 3: 2}, VectorClock{1: 3, 2: 4, 3: 2}},
+	}
+	for _, test := range tests {
+		v := test.v.Copy()
+		v.Merge(test.o)
+		if !reflect.DeepEqual(v, test.want) {
+			t.Errorf("%s: merged %v into %v = %v, want %v", test.name, test.o, test.v, v, test.want)
+		}
+		// The merged clock has seen both, concurrent clocks included
+		if order := test.v.Compare(v); order != Before && order != Equal {
+			t.Errorf("%s: %v is %v the merged clock", test.name, test.v, order)
+		}
+		if order := test.o.Compare(v); order != Before && order != Equal {
+			t.Errorf("%s: %v is %v the merged clock", test.name, test.o, order)
+		}
+	}
+}
+
+func TestVectorClockTickDetectsConcurrency(t *testing.T) {
+	var a, b VectorClock
+	a.Tick(1)
+	b.Merge(a)
+	if order := a.Compare(b); order != Equal {
+		t.Fatalf("a is %v b after the merge", order)
+	}
+
+	// Both tick without hearing from each other
+	a.Tick(1)
+	b.Tick(2)
+	if order := a.Compare(b); order != Concurrent {
+		t.Fatalf("a is %v b after both ticked", order)
+	}
+
+	// b hears from a, and its next event comes after
+	b.Merge(a)
+	b.Tick(2)
+	if order := a.Compare(b); order != Before {
+		t.Fatalf("a is %v b after b merged it", order)
+	}
+}
@@ -0,0 +1,567 @@
+package simnet
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// ErrDropped is the error of an RPC call whose request or response was dropped
+// Like a broken TCP connection, it fails every pending call of the client,
+// and later calls fail with rpc.ErrShutdown until the address is dialed again.
+var ErrDropped = errors.New("simnet: message dropped")
+
+// defaultTimeout is how long the caller waits before learning a message was dropped
+const defaultTimeout = 500 * time.Millisecond
+
+// Network is a simulated network for running many nodes in a single process.
+// It carries net/rpc calls between the servers and clients of its hosts.
+// Every request and response is a separate message with its own latency,
+// so messages can be reordered, dropped, or cut off by a partition.
+//
+// Messages are delivered by a scheduler on a virtual clock, in the order of their
+// delivery time, ties broken by link and by the order they were sent on the link.
+// Every link draws the latency and fate of its messages from its own random source,
+// seeded from the seed of the network and the addresses of the link, so a run is
+// reproduced as long as every link carries its messages in the same order.
+// The clock of a network made with New follows the wall clock, the clock of a
+// network made with NewVirtual only moves when Advance or Step is called.
+//
+// The network reproduces its messages, not the nodes it carries them between.
+// A node that keeps time with the wall clock, as tangram.Game does for its
+// heartbeat, leases, raft and rebalancing, sends messages at moments that vary
+// from run to run, so a run is only reproduced as far as the nodes act on the
+// messages they receive. Tests of such nodes wait for conditions within a
+// timeout rather than expect an exact schedule, and take wall clock time.
+// Network is safe for concurrent use.
+type Network struct {
+	mutex     sync.Mutex
+	seed      int64
+	links     map[link]*linkState
+	servers   map[string]*endpoint
+	latency   time.Duration
+	jitter    time.Duration
+	dropRate  float64
+	timeout   time.Duration
+	partition map[string]int
+
+	now     time.Duration
+	events  eventQueue
+	wake    chan struct{}
+	stopped chan struct{}
+}
+
+// link is the direction of a message, from one address to another
+type link struct {
+	from string
+	to   string
+}
+
+// linkState holds the random source and the number of messages sent on a link
+type linkState struct {
+	rand *rand.Rand
+	seq  uint64
+}
+
+// New creates a network with no latency, drops or partitions, whose clock follows the wall clock
+func New(seed int64) *Network {
+	n := NewVirtual(seed)
+	go n.run()
+	return n
+}
+
+// NewVirtual creates a network with no latency, drops or partitions, whose clock only moves
+// when Advance or Step is called, so that tests decide when every message is delivered
+func NewVirtual(seed int64) *Network {
+	return &Network{
+		seed:      seed,
+		links:     make(map[link]*linkState),
+		servers:   make(map[string]*endpoint),
+		timeout:   defaultTimeout,
+		partition: make(map[string]int),
+		wake:      make(chan struct{}, 1),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// SetLatency sets the delay of every message to latency plus a random amount up to jitter
+// Messages with different delays overtake each other, so jitter controls reordering
+func (n *Network) SetLatency(latency time.Duration, jitter time.Duration) {
+	n.mutex.Lock()
+	n.latency = latency
+	n.jitter = jitter
+	n.mutex.Unlock()
+}
+
+// SetDropRate sets the probability of any message being dropped
+func (n *Network) SetDropRate(rate float64) {
+	n.mutex.Lock()
+	n.dropRate = rate
+	n.mutex.Unlock()
+}
+
+// SetTimeout sets how long a caller waits before its call fails with ErrDropped
+func (n *Network) SetTimeout(timeout time.Duration) {
+	n.mutex.Lock()
+	n.timeout = timeout
+	n.mutex.Unlock()
+}
+
+// Partition splits the network so that only addresses in the same group can talk
+// Addresses that are not in any group form a group of their own
+func (n *Network) Partition(groups ...[]string) {
+	n.mutex.Lock()
+	n.partition = make(map[string]int)
+	for i, group := range groups {
+		for _, addr := range group {
+			n.partition[addr] = i + 1
+		}
+	}
+	n.mutex.Unlock()
+}
+
+// Heal removes every partition
+func (n *Network) Heal() {
+	n.Partition()
+}
+
+// Host returns the view of the network from addr
+func (n *Network) Host(addr string) *Host {
+	return &Host{n, addr}
+}
+
+// Now returns the time of the virtual clock, since the network was created
+func (n *Network) Now() time.Duration {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.now
+}
+
+// Pending returns the number of messages in flight, and of drops not yet reported
+func (n *Network) Pending() int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return len(n.events)
+}
+
+// Step moves the clock to the next message and delivers it
+// It returns false if no message is in flight
+func (n *Network) Step() bool {
+	n.mutex.Lock()
+	if len(n.events) == 0 {
+		n.mutex.Unlock()
+		return false
+	}
+	e := heap.Pop(&n.events).(*event)
+	if e.due > n.now {
+		n.now = e.due
+	}
+	n.mutex.Unlock()
+
+	e.run()
+	return true
+}
+
+// Advance moves the clock forward by d, delivering every message due meanwhile in order
+func (n *Network) Advance(d time.Duration) {
+	n.mutex.Lock()
+	until := n.now + d
+	n.mutex.Unlock()
+	n.advanceTo(until)
+}
+
+// advanceTo delivers every message due until the time, and moves the clock to it
+func (n *Network) advanceTo(until time.Duration) {
+	for {
+		n.mutex.Lock()
+		if len(n.events) == 0 || n.events[0].due > until {
+			if until > n.now {
+				n.now = until
+			}
+			n.mutex.Unlock()
+			return
+		}
+		e := heap.Pop(&n.events).(*event)
+		if e.due > n.now {
+			n.now = e.due
+		}
+		n.mutex.Unlock()
+
+		e.run()
+	}
+}
+
+// Stop stops the clock of a network made with New, messages in flight are no longer delivered
+func (n *Network) Stop() {
+	n.mutex.Lock()
+	select {
+	case <-n.stopped:
+	default:
+		close(n.stopped)
+	}
+	n.mutex.Unlock()
+}
+
+// run moves the clock along with the wall clock until the network is stopped
+func (n *Network) run() {
+	start := time.Now()
+	for {
+		n.advanceTo(time.Since(start))
+
+		wait := time.Duration(-1)
+		n.mutex.Lock()
+		if len(n.events) > 0 {
+			wait = n.events[0].due - time.Since(start)
+		}
+		n.mutex.Unlock()
+
+		var timer <-chan time.Time
+		if wait >= 0 {
+			timer = time.After(wait)
+		}
+		select {
+		case <-n.stopped:
+			return
+		case <-n.wake:
+		case <-timer:
+		}
+	}
+}
+
+// reachable returns whether from and to are in the same partition
+// Must be called while holding Network.mutex
+func (n *Network) reachable(from string, to string) bool {
+	return n.partition[from] == n.partition[to]
+}
+
+// linkFrom returns the state of the link, seeding its random source the first time it is used
+// Must be called while holding Network.mutex
+func (n *Network) linkFrom(from string, to string) *linkState {
+	l := link{from, to}
+	state, ok := n.links[l]
+	if !ok {
+		h := fnv.New64a()
+		h.Write([]byte(from + "->" + to))
+		state = &linkState{rand: rand.New(rand.NewSource(n.seed ^ int64(h.Sum64())))}
+		n.links[l] = state
+	}
+	return state
+}
+
+// send schedules the delivery of a message from one address to another after its latency
+// If the message is dropped, drop is called after the timeout instead
+func (n *Network) send(from string, to string, deliver func(), drop func()) {
+	n.mutex.Lock()
+	state := n.linkFrom(from, to)
+	delay := n.latency
+	if n.jitter > 0 {
+		delay += time.Duration(state.rand.Int63n(int64(n.jitter)))
+	}
+	dropped := state.rand.Float64() < n.dropRate || !n.reachable(from, to)
+
+	e := &event{link: link{from, to}, seq: state.seq, due: n.now + delay, run: deliver}
+	if dropped {
+		e.due, e.run = n.now+n.timeout, drop
+	}
+	state.seq++
+	heap.Push(&n.events, e)
+	n.mutex.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// event is the delivery of a message, or the report of its drop
+type event struct {
+	link link
+	seq  uint64
+	due  time.Duration
+	run  func()
+}
+
+// eventQueue orders events by delivery time, then by link and by the order they were sent on it
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.due != b.due {
+		return a.due < b.due
+	}
+	if a.link != b.link {
+		if a.link.from != b.link.from {
+			return a.link.from < b.link.from
+		}
+		return a.link.to < b.link.to
+	}
+	return a.seq < b.seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// Host is a single address on a Network
+// It implements the Network interface of the tangram package
+type Host struct {
+	network *Network
+	addr    string
+}
+
+// Listen serves the RPC server at addr until the returned Closer is closed
+func (h *Host) Listen(addr string, server *rpc.Server) (io.Closer, error) {
+	n := h.network
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if _, ok := n.servers[addr]; ok {
+		return nil, fmt.Errorf("simnet: address %s already in use", addr)
+	}
+	e := &endpoint{server: server, conns: make(map[*clientCodec]bool)}
+	n.servers[addr] = e
+	return &listener{n, addr, e}, nil
+}
+
+// Dial connects to the RPC server at addr
+func (h *Host) Dial(addr string) (*rpc.Client, error) {
+	n := h.network
+	n.mutex.Lock()
+	e, ok := n.servers[addr]
+	if !ok || !n.reachable(h.addr, addr) {
+		n.mutex.Unlock()
+		return nil, fmt.Errorf("simnet: dial %s from %s: connection refused", addr, h.addr)
+	}
+	client := &clientCodec{network: n, endpoint: e, from: h.addr, to: addr, responses: newMailbox()}
+	client.server = &serverCodec{client: client, requests: newMailbox()}
+	e.conns[client] = true
+	n.mutex.Unlock()
+
+	go e.server.ServeCodec(client.server)
+	return rpc.NewClientWithCodec(client), nil
+}
+
+// endpoint is a listening address, and the connections its server serves
+// conns is protected by Network.mutex
+type endpoint struct {
+	server *rpc.Server
+	conns  map[*clientCodec]bool
+}
+
+type listener struct {
+	network  *Network
+	addr     string
+	endpoint *endpoint
+}
+
+// Close stops listening, and closes every connection the server was serving
+func (l *listener) Close() error {
+	n := l.network
+	n.mutex.Lock()
+	if n.servers[l.addr] == l.endpoint {
+		delete(n.servers, l.addr)
+	}
+	var conns []*clientCodec
+	for conn := range l.endpoint.conns {
+		conns = append(conns, conn)
+	}
+	n.mutex.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return nil
+}
+
+// message is a request or response in flight
+type message struct {
+	seq     uint64
+	method  string
+	err     string
+	body    []byte
+	dropped bool
+}
+
+// clientCodec is the client end of a simulated connection
+type clientCodec struct {
+	network   *Network
+	endpoint  *endpoint
+	from      string
+	to        string
+	server    *serverCodec
+	responses *mailbox
+	current   *message
+}
+
+func (c *clientCodec) WriteRequest(r *rpc.Request, body interface{}) error {
+	data, err := encode(body)
+	if err != nil {
+		return err
+	}
+
+	request := &message{seq: r.Seq, method: r.ServiceMethod, body: data}
+	c.network.send(c.from, c.to, func() {
+		c.server.requests.put(request)
+	}, func() {
+		c.responses.put(&message{seq: request.seq, method: request.method, dropped: true})
+	})
+	return nil
+}
+
+func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
+	msg, ok := c.responses.get()
+	if !ok {
+		return io.EOF
+	}
+	if msg.dropped {
+		return ErrDropped
+	}
+	c.current = msg
+	r.Seq = msg.seq
+	r.ServiceMethod = msg.method
+	r.Error = msg.err
+	return nil
+}
+
+func (c *clientCodec) ReadResponseBody(body interface{}) error {
+	if body == nil {
+		return nil
+	}
+	return decode(c.current.body, body)
+}
+
+func (c *clientCodec) Close() error {
+	c.network.mutex.Lock()
+	delete(c.endpoint.conns, c)
+	c.network.mutex.Unlock()
+
+	c.responses.close()
+	c.server.requests.close()
+	return nil
+}
+
+// serverCodec is the server end of a simulated connection
+type serverCodec struct {
+	client   *clientCodec
+	requests *mailbox
+	current  *message
+}
+
+func (s *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	msg, ok := s.requests.get()
+	if !ok {
+		return io.EOF
+	}
+	s.current = msg
+	r.Seq = msg.seq
+	r.ServiceMethod = msg.method
+	return nil
+}
+
+func (s *serverCodec) ReadRequestBody(body interface{}) error {
+	if body == nil {
+		return nil
+	}
+	return decode(s.current.body, body)
+}
+
+func (s *serverCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	response := &message{seq: r.Seq, method: r.ServiceMethod, err: r.Error}
+	if r.Error == "" {
+		data, err := encode(body)
+		if err != nil {
+			return err
+		}
+		response.body = data
+	}
+
+	c := s.client
+	c.network.send(c.to, c.from, func() {
+		c.responses.put(response)
+	}, func() {
+		c.responses.put(&message{seq: response.seq, method: response.method, dropped: true})
+	})
+	return nil
+}
+
+func (s *serverCodec) Close() error {
+	s.requests.close()
+	return nil
+}
+
+// mailbox is an unbounded queue of messages
+// get blocks until a message is available or the mailbox is closed, closing drops the messages queued
+type mailbox struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	queue  []*message
+	closed bool
+}
+
+func newMailbox() *mailbox {
+	m := new(mailbox)
+	m.cond = sync.NewCond(&m.mutex)
+	return m
+}
+
+func (m *mailbox) put(msg *message) {
+	m.mutex.Lock()
+	if !m.closed {
+		m.queue = append(m.queue, msg)
+		m.cond.Signal()
+	}
+	m.mutex.Unlock()
+}
+
+func (m *mailbox) get() (msg *message, ok bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for len(m.queue) == 0 && !m.closed {
+		m.cond.Wait()
+	}
+	if len(m.queue) == 0 {
+		return nil, false
+	}
+	msg = m.queue[0]
+	m.queue = m.queue[1:]
+	return msg, true
+}
+
+func (m *mailbox) close() {
+	m.mutex.Lock()
+	m.closed = true
+	m.queue = nil
+	m.cond.Broadcast()
+	m.mutex.Unlock()
+}
+
+// encode copies the body into its own buffer, so that nodes never share memory
+func encode(body interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(body)
+	if err != nil {
+		return nil, errors.New("simnet: " + err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(data []byte, body interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(body)
+}
@@ -0,0 +1,206 @@
+package simnet
+
+import (
+	"fmt"
+	"io"
+	"net/rpc"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Echo struct{}
+
+func (Echo) Echo(req int, res *int) error {
+	*res = req
+	return nil
+}
+
+func listenEcho(t *testing.T, n *Network, addr string) io.Closer {
+	server := rpc.NewServer()
+	if err := server.Register(Echo{}); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := n.Host(addr).Listen(addr, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listener
+}
+
+// schedule sends messages on a few links and returns the order they are delivered in
+func schedule(seed int64) []string {
+	n := NewVirtual(seed)
+	n.SetLatency(10*time.Millisecond, 20*time.Millisecond)
+	n.SetDropRate(0.2)
+
+	var delivered []string
+	for i := 0; i < 20; i++ {
+		for _, l := range []link{{"a", "b"}, {"b", "a"}, {"a", "c"}} {
+			name := fmt.Sprintf("%s->%s#%d", l.from, l.to, i)
+			n.send(l.from, l.to, func() {
+				delivered = append(delivered, name)
+			}, func() {
+				delivered = append(delivered, name+" dropped")
+			})
+		}
+	}
+	for n.Step() {
+	}
+	return delivered
+}
+
+func TestScheduleIsReproducible(t *testing.T) {
+	first, second := schedule(7), schedule(7)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed, different schedules:\n%v\n%v", first, second)
+	}
+	if reflect.DeepEqual(first, schedule(8)) {
+		t.Fatal("different seeds, same schedule")
+	}
+}
+
+func TestJitterReorders(t *testing.T) {
+	delivered := schedule(7)
+	reordered := false
+	for i := 1; i < len(delivered); i++ {
+		var from, to string
+		var a, b int
+		fmt.Sscanf(delivered[i-1], "%1s->%1s#%d", &from, &to, &a)
+		fmt.Sscanf(delivered[i], "%1s->%1s#%d", &from, &to, &b)
+		if b < a {
+			reordered = true
+		}
+	}
+	if !reordered {
+		t.Fatalf("jitter did not reorder any message: %v", delivered)
+	}
+}
+
+func TestAdvanceDeliversInTime(t *testing.T) {
+	n := NewVirtual(1)
+	n.SetLatency(10*time.Millisecond, 0)
+	delivered := false
+	n.send("a", "b", func() { delivered = true }, func() {})
+
+	n.Advance(9 * time.Millisecond)
+	if delivered {
+		t.Fatal("delivered before its latency")
+	}
+	n.Advance(time.Millisecond)
+	if !delivered {
+		t.Fatal("not delivered after its latency")
+	}
+	if n.Now() != 10*time.Millisecond || n.Pending() != 0 {
+		t.Fatalf("now = %v, pending = %d", n.Now(), n.Pending())
+	}
+}
+
+// outcomes makes sequential calls over a lossy network, and returns which of them went through
+func outcomes(t *testing.T, seed int64) []bool {
+	n := NewVirtual(seed)
+	n.SetLatency(time.Millisecond, 5*time.Millisecond)
+	n.SetDropRate(0.3)
+	listenEcho(t, n, "server")
+
+	var result []bool
+	var client *rpc.Client
+	for i := 0; i < 30; i++ {
+		if client == nil {
+			var err error
+			if client, err = n.Host("client").Dial("server"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var res int
+		call := client.Go("Echo.Echo", i, &res, make(chan *rpc.Call, 1))
+		for done := false; !done; {
+			select {
+			case <-call.Done:
+				done = true
+			default:
+				if !n.Step() {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}
+		result = append(result, call.Error == nil && res == i)
+		if call.Error != nil {
+			client.Close()
+			client = nil
+		}
+	}
+	return result
+}
+
+func TestDropsAreReproducible(t *testing.T) {
+	first, second := outcomes(t, 3), outcomes(t, 3)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed, different drops:\n%v\n%v", first, second)
+	}
+	dropped := 0
+	for _, ok := range first {
+		if !ok {
+			dropped++
+		}
+	}
+	if dropped == 0 || dropped == len(first) {
+		t.Fatalf("%d of %d calls dropped", dropped, len(first))
+	}
+}
+
+func TestPartition(t *testing.T) {
+	n := New(1)
+	defer n.Stop()
+	n.SetTimeout(50 * time.Millisecond)
+	listenEcho(t, n, "server")
+
+	client, err := n.Host("client").Dial("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res int
+	if err := client.Call("Echo.Echo", 1, &res); err != nil || res != 1 {
+		t.Fatalf("res = %d, err = %v", res, err)
+	}
+
+	n.Partition([]string{"server"}, []string{"client"})
+	if err := client.Call("Echo.Echo", 2, &res); err != ErrDropped {
+		t.Fatalf("call across the partition: err = %v", err)
+	}
+	if _, err := n.Host("client").Dial("server"); err == nil {
+		t.Fatal("dialed across the partition")
+	}
+
+	n.Heal()
+	if client, err = n.Host("client").Dial("server"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call("Echo.Echo", 3, &res); err != nil || res != 3 {
+		t.Fatalf("res = %d, err = %v", res, err)
+	}
+}
+
+func TestCloseClosesServedConnections(t *testing.T) {
+	n := New(1)
+	defer n.Stop()
+	listener := listenEcho(t, n, "server")
+
+	client, err := n.Host("client").Dial("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res int
+	if err := client.Call("Echo.Echo", 1, &res); err != nil {
+		t.Fatal(err)
+	}
+
+	listener.Close()
+	if err := client.Call("Echo.Echo", 2, &res); err == nil {
+		t.Fatal("called a closed listener")
+	}
+	if _, err := n.Host("client").Dial("server"); err == nil {
+		t.Fatal("dialed a closed listener")
+	}
+}
@@ -0,0 +1,117 @@
+package tangram
+
+import (
+	"log"
+	"time"
+
+	"../lamport"
+)
+
+// In a hosted game the host is the single arbiter of tan ownership.
+// Peers send their LockTan requests to the host alone, and the host grants them
+// in the order it receives them. Requests for a held tan are queued in
+// tanMutex.deferred until the tan is released, its lease lapses, or the
+// request times out, so every reply from the host is definitive.
+// A queued request waits for the rest of the lease on the tan, and lockTimeout
+// more, so it is not refused while the holder may still let go of the tan.
+// A host that hands hosting over refuses its queue, the requesters retry
+// against the new host, so two hosts never grant the same tan.
+
+// queueTimeout is the longest the host queues a request, for a tan whose lease was just renewed
+const queueTimeout = leaseDuration + lockTimeout
+
+// hostTimeout is how long a peer waits for the host to reply to its request
+// It is longer than queueTimeout, so the host refuses the request before the peer gives up on it
+const hostTimeout = queueTimeout + lockTimeout
+
+// arbitrate decides whether the player obtains the tan when this node is the host
+// Must be called while holding Game.lock, which is released before returning
+func (game *Game) arbitrate(tan *Tan, playerID PlayerID, reqTime lamport.Time) (ok bool, err error) {
+	if game.state.Host != game.GetPlayer().ID {
+		log.Printf("[arbitrate] Refusing tan ID = %d to %d. Not the host", tan.ID, playerID)
+		game.lock.Unlock()
+		return false, nil
+	}
+
+	now := game.clock.Physical()
+	if tan.Player == playerID && tan.held(now) {
+		game.lock.Unlock()
+		return true, nil
+	}
+
+	if !tan.held(now) && len(game.mutex.deferred[tan.ID]) == 0 {
+		log.Printf("[arbitrate] Granting tan ID = %d to %d", tan.ID, playerID)
+		game.grantTan(tan, playerID, reqTime, now)
+		game.notify()
+		game.lock.Unlock()
+		return true, nil
+	}
+
+	log.Printf("[arbitrate] Queueing %d for tan ID = %d", playerID, tan.ID)
+	d := &deferredReply{playerID, reqTime, make(chan bool, 1)}
+	game.mutex.deferred[tan.ID] = append(game.mutex.deferred[tan.ID], d)
+	timeout := lockTimeout
+	if tan.held(now) {
+		timeout += tan.Expiry.Sub(now)
+	}
+	game.lock.Unlock()
+
+	ok = game.waitDeferred(tan.ID, d, timeout)
+	return
+}
+
+// grantQueued grants the tan to the first queued requester, if the tan is free
+// Must be called while holding Game.lock
+func (game *Game) grantQueued(tan *Tan) {
+	now := game.clock.Physical()
+	queue := game.mutex.deferred[tan.ID]
+	if len(queue) == 0 || tan.held(now) || game.state.Host != game.GetPlayer().ID {
+		return
+	}
+
+	d := queue[0]
+	if len(queue) == 1 {
+		delete(game.mutex.deferred, tan.ID)
+	} else {
+		game.mutex.deferred[tan.ID] = queue[1:]
+	}
+
+	log.Printf("[grantQueued] Granting tan ID = %d to %d", tan.ID, d.player)
+	game.grantTan(tan, d.player, d.time, now)
+	d.reply <- true
+}
+
+// grantTan records the player as the holder of the tan
+// The grant is a new event on the tan, so peers witnessing the host's state adopt it
+// Must be called while holding Game.lock
+func (game *Game) grantTan(tan *Tan, playerID PlayerID, reqTime lamport.Time, now time.Time) {
+	if game.state.Host != game.GetPlayer().ID {
+		return
+	}
+	tan.Clock.Send()
+	tan.Vector.Tick(game.GetPlayer().ID)
+	game.grantLease(tan, playerID, reqTime, now)
+}
+
+// setHost records the new host of the game
+// A host that is replaced refuses the requests it queued, their requesters retry against the new host
+// Must be called while holding Game.lock
+func (game *Game) setHost(host PlayerID) {
+	me := game.GetPlayer().ID
+	if game.state.Host == me && host != me {
+		game.refuseQueued()
+	}
+	game.state.Host = host
+}
+
+// refuseQueued refuses every queued request
+// Must be called while holding Game.lock
+func (game *Game) refuseQueued() {
+	for id, queue := range game.mutex.deferred {
+		log.Printf("[refuseQueued] Refusing %d queued requests for tan ID = %d. No longer the host", len(queue), id)
+		for _, d := range queue {
+			d.reply <- false
+		}
+		delete(game.mutex.deferred, id)
+	}
+}
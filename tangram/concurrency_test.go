@@ -0,0 +1,218 @@
+package tangram
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// These tests hammer games from many goroutines at once, run them with -race
+
+func TestConcurrentJoins(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	network := testNetwork(t, 5)
+	first, err := NewGameWithNetwork(network.Host(addr(1)), config, addr(1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { first.Close() })
+
+	const n = 6
+	games := make([]*Game, n)
+	games[0] = first
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for id := 2; id <= n; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			game, err := ConnectToGameWithNetwork(network.Host(addr(id)), addr(1), addr(id), id)
+			if err != nil {
+				errs <- err
+				return
+			}
+			games[id-1] = game
+		}(id)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	for _, game := range games[1:] {
+		game := game
+		t.Cleanup(func() { game.Close() })
+	}
+
+	eventually(t, 10*time.Second, "every player knows every other", func() bool {
+		for _, game := range games {
+			if len(game.GetState().Players) != n {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestConcurrentMovesAndSubscribers(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	games := startGames(t, testNetwork(t, 6), config, 3)
+	for i, game := range games {
+		if ok, err := game.ObtainTan(config.Tans[i].ID, false); !ok {
+			t.Fatalf("player %d could not obtain tan %d: %v", i+1, config.Tans[i].ID, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, game := range games {
+		// The player drags its tan around
+		wg.Add(1)
+		go func(id TanID, game *Game) {
+			defer wg.Done()
+			for step := int32(0); step < 50; step++ {
+				game.MoveTan(id, Point{X: step, Y: step}, Rotation(step%8))
+			}
+		}(config.Tans[i].ID, game)
+
+		// Browsers come and go, each waiting for an update and reading the state
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func(game *Game) {
+				defer wg.Done()
+				for k := 0; k < 10; k++ {
+					changes := game.Subscribe()
+					select {
+					case <-changes:
+					case <-time.After(50 * time.Millisecond):
+					}
+					game.GetState()
+					game.Topology()
+					game.Unsubscribe(changes)
+				}
+			}(game)
+		}
+	}
+	wg.Wait()
+
+	eventually(t, 5*time.Second, "every player sees the same tans", func() bool { return sameTans(games) })
+}
+
+func TestLockContention(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	games := startGames(t, testNetwork(t, 7), config, 3)
+	id := config.Tans[0].ID
+
+	for round := 0; round < 5; round++ {
+		// Every player asks for the tan from two goroutines at once
+		var mutex sync.Mutex
+		winners := make(map[PlayerID]*Game)
+		var wg sync.WaitGroup
+		for _, game := range games {
+			for j := 0; j < 2; j++ {
+				wg.Add(1)
+				go func(game *Game) {
+					defer wg.Done()
+					if ok, _ := game.ObtainTan(id, false); ok {
+						mutex.Lock()
+						winners[game.GetPlayer().ID] = game
+						mutex.Unlock()
+					}
+				}(game)
+			}
+		}
+		wg.Wait()
+		if len(winners) > 1 {
+			t.Fatalf("round %d: tan %d obtained by %d players at once", round, id, len(winners))
+		}
+
+		for _, winner := range winners {
+			if ok, err := winner.ObtainTan(id, true); !ok {
+				t.Fatalf("round %d: player %d could not release tan %d: %v", round, winner.GetPlayer().ID, id, err)
+			}
+		}
+		eventually(t, 5*time.Second, "every player sees the tan released", func() bool {
+			for _, holder := range holders(games, id) {
+				if holder != NoPlayer {
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	games := startGames(t, testNetwork(t, 8), config, 2)
+	id := config.Tans[0].ID
+
+	if ok, err := games[0].ObtainTan(id, false); !ok {
+		t.Fatalf("could not obtain tan %d: %v", id, err)
+	}
+	eventually(t, 5*time.Second, "the other player sees the tan held", func() bool {
+		return games[1].GetState().getTan(id).Player == 1
+	})
+	if ok, _ := games[1].ObtainTan(id, false); ok {
+		t.Fatalf("obtained tan %d while it was held", id)
+	}
+
+	// The holder never moves the tan, so its lease runs out
+	start := time.Now()
+	eventually(t, leaseDuration+5*time.Second, "the lease expires", func() bool {
+		ok, _ := games[1].ObtainTan(id, false)
+		return ok
+	})
+	if elapsed := time.Since(start); elapsed < leaseDuration-time.Second {
+		t.Fatalf("obtained tan %d after %v, before its lease of %v expired", id, elapsed, leaseDuration)
+	}
+}
+
+func TestElectionUnderLoad(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	network := testNetwork(t, 9)
+	games := startGames(t, network, config, 4)
+	peers := games[1:]
+
+	// The other players keep obtaining and moving their tans while the host fails
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, game := range peers {
+		wg.Add(1)
+		go func(id TanID, game *Game) {
+			defer wg.Done()
+			for step := int32(0); ; step++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				game.ObtainTan(id, false)
+				game.MoveTan(id, Point{X: step % 100, Y: step % 100}, 0)
+				time.Sleep(10 * time.Millisecond)
+			}
+		}(config.Tans[i].ID, game)
+	}
+
+	network.Partition([]string{addr(1)}, []string{addr(2), addr(3), addr(4)})
+	eventually(t, 45*time.Second, "the others elect a new host", func() bool {
+		first := peers[0].GetState()
+		if first.Host == 1 || first.Host == NoPlayer || first.Election != ElectionTerminated {
+			return false
+		}
+		for _, game := range peers[1:] {
+			state := game.GetState()
+			if state.Host != first.Host || state.Term != first.Term || state.Election != ElectionTerminated {
+				return false
+			}
+		}
+		return true
+	})
+	close(done)
+	wg.Wait()
+}
@@ -1,39 +1,248 @@
-package tangram
-
-import (
-	"net/rpc"
-)
-
-type connectionPool struct {
-	connections map[PlayerID]*rpc.Client
-}
-
-func newConnectionPool() *connectionPool {
-	return &connectionPool{
-		connections: make(map[PlayerID]*rpc.Client),
-	}
-}
-
-func (pool *connectionPool) getConnection(player *Player) (client *rpc.Client, err error) {
-	client, ok := pool.connections[player.ID]
-	if ok {
-		return
-	}
-
-	client, err = pool.connect(player.Addr)
-	if err != nil {
-		return
-	}
-
-	pool.connections[player.ID] = client
-	return
-}
-
-func (pool *connectionPool) connect(addr string) (client *rpc.Client, err error) {
-	client, err = rpc.Dial("tcp", addr)
-	return
-}
-
-func (pool *connectionPool) dropConnection(id PlayerID) {
-	delete(pool.connections, id)
-}
+package tangram
+
+import (
+	"fmt"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// PeerState is the health of the connection to a peer
+type PeerState int
+
+const (
+	// PeerConnected means the last call to the peer succeeded
+	PeerConnected PeerState = iota
+	// PeerDegraded means the last calls to the peer failed, but it is not given up on yet
+	PeerDegraded
+	// PeerDown means the peer could not be reached downAfter times in a row
+	PeerDown
+)
+
+func (s PeerState) String() string {
+	switch s {
+	case PeerConnected:
+		return "connected"
+	case PeerDegraded:
+		return "degraded"
+	default:
+		return "down"
+	}
+}
+
+// dialTimeout is how long connecting to a peer may take
+const dialTimeout = 2 * time.Second
+
+// rpcTimeout is how long a call to a peer may take
+// It must be longer than lockTimeout, LockTan holds its reply for up to lockTimeout.
+// The host holds it for longer, so calls made with a longer callTimeout are given that long instead.
+const rpcTimeout = 5 * time.Second
+
+// backoffMin and backoffMax bound the wait before redialing a peer
+// The wait doubles with each failure in a row
+const backoffMin = 250 * time.Millisecond
+const backoffMax = 10 * time.Second
+
+// downAfter is how many failures in a row mark a peer down
+const downAfter = 3
+
+// connectionPool holds the connection to every peer
+// It is used from the heartbeat, the streams and RPC handlers at once, so the map is guarded by mutex.
+// Each peerConn has its own mutex, so a slow dial to one peer does not hold up the others.
+type connectionPool struct {
+	transport   Transport
+	mutex       sync.Mutex
+	connections map[PlayerID]*peerConn
+}
+
+func newConnectionPool(transport Transport) *connectionPool {
+	return &connectionPool{
+		transport:   transport,
+		connections: make(map[PlayerID]*peerConn),
+	}
+}
+
+// getConnection returns the connection to the player, dialing it if needed
+// The connection redials by itself after failures, so callers may keep it
+func (pool *connectionPool) getConnection(player *Player) (client Conn, err error) {
+	pool.mutex.Lock()
+	peer, ok := pool.connections[player.ID]
+	if !ok || peer.addr != player.Addr {
+		if ok {
+			peer.Close()
+		}
+		peer = &peerConn{pool: pool, id: player.ID, addr: player.Addr}
+		pool.connections[player.ID] = peer
+	}
+	pool.mutex.Unlock()
+
+	_, err = peer.connection()
+	if err != nil {
+		return
+	}
+	return peer, nil
+}
+
+// connect dials addr, giving up after dialTimeout
+func (pool *connectionPool) connect(addr string) (client Conn, err error) {
+	type result struct {
+		client Conn
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		client, err := pool.transport.Dial(addr)
+		done <- result{client, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.client, r.err
+	case <-time.After(dialTimeout):
+		// Close the connection if it shows up after all
+		go func() {
+			if r := <-done; r.err == nil {
+				r.client.Close()
+			}
+		}()
+		return nil, fmt.Errorf("dialing %s timed out after %v", addr, dialTimeout)
+	}
+}
+
+// state returns the health of the connection to the player
+// Players that were never dialed are considered connected
+func (pool *connectionPool) state(id PlayerID) PeerState {
+	pool.mutex.Lock()
+	peer, ok := pool.connections[id]
+	pool.mutex.Unlock()
+	if !ok {
+		return PeerConnected
+	}
+	return peer.State()
+}
+
+func (pool *connectionPool) dropConnection(id PlayerID) {
+	pool.mutex.Lock()
+	peer, ok := pool.connections[id]
+	delete(pool.connections, id)
+	pool.mutex.Unlock()
+
+	if ok {
+		peer.Close()
+	}
+}
+
+// close closes the connection to every peer
+func (pool *connectionPool) close() {
+	pool.mutex.Lock()
+	peers := pool.connections
+	pool.connections = make(map[PlayerID]*peerConn)
+	pool.mutex.Unlock()
+
+	for _, peer := range peers {
+		peer.Close()
+	}
+}
+
+// peerConn is a Conn to a peer that tracks its health
+// Calls time out after rpcTimeout, and a failed call drops the underlying connection.
+// The next call redials it, unless it is still backing off from the last failure.
+type peerConn struct {
+	pool *connectionPool
+	id   PlayerID
+	addr string
+
+	mutex    sync.Mutex
+	conn     Conn
+	health   PeerState
+	failures int
+	retry    time.Time
+}
+
+// connection returns the underlying connection, redialing it if needed
+func (peer *peerConn) connection() (conn Conn, err error) {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	if peer.conn != nil {
+		return peer.conn, nil
+	}
+
+	now := time.Now()
+	if now.Before(peer.retry) {
+		return nil, fmt.Errorf("Player ID = %d is %v, redialing in %v", peer.id, peer.health, peer.retry.Sub(now))
+	}
+
+	conn, err = peer.pool.connect(peer.addr)
+	if err != nil {
+		peer.failed()
+		return
+	}
+	peer.conn = conn
+	return
+}
+
+// failed records a failure in a row, the caller must hold the mutex
+func (peer *peerConn) failed() {
+	peer.failures++
+	if peer.failures >= downAfter {
+		peer.health = PeerDown
+	} else {
+		peer.health = PeerDegraded
+	}
+
+	backoff := backoffMax
+	if peer.failures < 32 && backoffMin<<uint(peer.failures-1) < backoffMax {
+		backoff = backoffMin << uint(peer.failures-1)
+	}
+	peer.retry = time.Now().Add(backoff)
+}
+
+// Call invokes the method of the peer and records whether the peer answered
+func (peer *peerConn) Call(method string, args interface{}, reply interface{}) error {
+	return peer.call(method, args, reply, rpcTimeout)
+}
+
+// call invokes the method of the peer, giving up after timeout
+func (peer *peerConn) call(method string, args interface{}, reply interface{}, timeout time.Duration) error {
+	conn, err := peer.connection()
+	if err != nil {
+		return err
+	}
+
+	err = callTimeout(conn, method, args, reply, timeout)
+
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	if _, ok := err.(rpc.ServerError); err == nil || ok {
+		// The peer answered, even if it answered with an error
+		peer.health = PeerConnected
+		peer.failures = 0
+		peer.retry = time.Time{}
+		return err
+	}
+
+	if peer.conn == conn {
+		conn.Close()
+		peer.conn = nil
+	}
+	peer.failed()
+	return err
+}
+
+// State returns the health of the connection
+func (peer *peerConn) State() PeerState {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	return peer.health
+}
+
+// Close closes the underlying connection, the next call redials it
+func (peer *peerConn) Close() (err error) {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	if peer.conn != nil {
+		err = peer.conn.Close()
+		peer.conn = nil
+	}
+	return
+}
@@ -0,0 +1,281 @@
+package tangram
+
+import (
+	"log"
+	"sync"
+
+	"../lamport"
+)
+
+// pushQueueSize is how many deltas can wait for a slow peer before new ones are dropped
+// A peer that misses a delta is marked stale and sent a full snapshot instead
+const pushQueueSize = 64
+
+// DeltaRequest is request argument for Node.PushDelta
+// - Player: The host pushing the delta
+// - Base: The version of the state the delta applies to
+// - Version: The version of the state after applying the delta
+// - Delta: The changed tans, and the players if they changed
+type DeltaRequest struct {
+	Player  PlayerID
+	Base    uint64
+	Version uint64
+	Delta   *GameState
+	Stamp   lamport.Timestamp
+}
+
+// deltaTracker tracks the changes the host has yet to push to its peers,
+// and the version of the state this node last witnessed from the host.
+// - version: The version of the state last pushed by this node
+// - tans: The tans changed since the last push
+// - players: Whether the players changed since the last push
+// - queues: The deltas waiting to be pushed to each peer
+// - stale: The peers that missed a delta and are owed a full snapshot
+// - host: The host this node last witnessed a state from
+// - synced: The version of the state last witnessed from host
+type deltaTracker struct {
+	mutex   sync.Mutex
+	version uint64
+	tans    map[TanID]bool
+	players bool
+	queues  map[PlayerID]chan DeltaRequest
+	stale   map[PlayerID]bool
+	host    PlayerID
+	synced  uint64
+	closed  bool
+}
+
+func newDeltaTracker() *deltaTracker {
+	return &deltaTracker{
+		tans:   make(map[TanID]bool),
+		queues: make(map[PlayerID]chan DeltaRequest),
+		stale:  make(map[PlayerID]bool),
+		host:   NoPlayer,
+	}
+}
+
+// touchTan marks the tan as changed since the last push
+func (d *deltaTracker) touchTan(id TanID) {
+	d.mutex.Lock()
+	d.tans[id] = true
+	d.mutex.Unlock()
+}
+
+// touchPlayers marks the players as changed since the last push
+func (d *deltaTracker) touchPlayers() {
+	d.mutex.Lock()
+	d.players = true
+	d.mutex.Unlock()
+}
+
+// take returns the changes since the last push and the versions they move the state between
+// changed is false if there is nothing to push
+func (d *deltaTracker) take() (base uint64, version uint64, tans map[TanID]bool, players bool, changed bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.tans) == 0 && !d.players {
+		return d.version, d.version, nil, false, false
+	}
+
+	base = d.version
+	d.version++
+	tans, players = d.tans, d.players
+	d.tans = make(map[TanID]bool)
+	d.players = false
+	return base, d.version, tans, players, true
+}
+
+// current returns the version of the state last pushed by this node
+func (d *deltaTracker) current() uint64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.version
+}
+
+// witness decides what to do with a delta pushed by host
+// apply is whether the delta follows the last witnessed state
+// ok is false if a delta was missed and a full snapshot is needed
+func (d *deltaTracker) witness(host PlayerID, base uint64, version uint64) (apply bool, ok bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if host == d.host && base == d.synced {
+		d.synced = version
+		return true, true
+	}
+	if host == d.host && version <= d.synced {
+		// We already witnessed a newer state
+		return false, true
+	}
+	return false, false
+}
+
+// resync records that the full state at version was witnessed from host
+func (d *deltaTracker) resync(host PlayerID, version uint64) {
+	d.mutex.Lock()
+	d.host = host
+	d.synced = version
+	d.mutex.Unlock()
+}
+
+// markStale records that the player missed a delta
+func (d *deltaTracker) markStale(id PlayerID) {
+	d.mutex.Lock()
+	d.stale[id] = true
+	d.mutex.Unlock()
+}
+
+// takeStale returns whether the player missed a delta, and clears the mark
+func (d *deltaTracker) takeStale(id PlayerID) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	stale := d.stale[id]
+	delete(d.stale, id)
+	return stale
+}
+
+// dropQueue stops pushing deltas to the player
+func (d *deltaTracker) dropQueue(id PlayerID) {
+	d.mutex.Lock()
+	queue, ok := d.queues[id]
+	if ok {
+		close(queue)
+		delete(d.queues, id)
+	}
+	delete(d.stale, id)
+	d.mutex.Unlock()
+}
+
+// close stops pushing deltas to every player, nothing is pushed after it
+func (d *deltaTracker) close() {
+	d.mutex.Lock()
+	d.closed = true
+	for id, queue := range d.queues {
+		close(queue)
+		delete(d.queues, id)
+	}
+	d.mutex.Unlock()
+}
+
+// pushDelta sends the tans and players changed since the last push to every peer, or to its members if this node is a relay
+func (game *Game) pushDelta() {
+	base, version, tans, players, changed := game.delta.take()
+	if !changed {
+		return
+	}
+
+	delta := &GameState{Host: game.state.Host, Election: game.state.Election, Term: game.state.Term, Relays: game.state.Relays}
+	for _, tan := range game.state.Tans {
+		if tans[tan.ID] {
+			delta.Tans = append(delta.Tans, tan)
+		}
+	}
+	if players {
+		delta.Players = game.state.Players
+	}
+	req := DeltaRequest{game.GetPlayer().ID, base, version, copyState(delta), lamport.Timestamp{}}
+
+	for _, player := range game.downstream() {
+		if player.ID == game.GetPlayer().ID {
+			continue
+		}
+		game.enqueueDelta(player, req)
+	}
+}
+
+// enqueueDelta queues the delta to be pushed to the player
+// Deltas are pushed to each peer one at a time, so they arrive in order
+func (game *Game) enqueueDelta(player *Player, req DeltaRequest) {
+	game.delta.mutex.Lock()
+	if game.delta.closed {
+		game.delta.mutex.Unlock()
+		return
+	}
+	queue, ok := game.delta.queues[player.ID]
+	if !ok {
+		queue = make(chan DeltaRequest, pushQueueSize)
+		game.delta.queues[player.ID] = queue
+		go game.pushLoop(player, queue)
+	}
+	game.delta.mutex.Unlock()
+
+	select {
+	case queue <- req:
+	default:
+		log.Printf("[pushDelta] Dropping version %d for slow player %d", req.Version, player.ID)
+		game.delta.markStale(player.ID)
+	}
+}
+
+// resyncStale wakes up the push loops of the players that missed a delta, so they are sent a snapshot
+// even if nothing changes anymore
+// An empty request only wakes the loop up, it is never pushed
+func (game *Game) resyncStale() {
+	game.delta.mutex.Lock()
+	defer game.delta.mutex.Unlock()
+	for id := range game.delta.stale {
+		if queue, ok := game.delta.queues[id]; ok {
+			select {
+			case queue <- DeltaRequest{}:
+			default:
+			}
+		}
+	}
+}
+
+// pushLoop pushes the queued deltas to the player until its queue is dropped
+// A player that missed a delta, because its queue was full or a push failed, is sent a snapshot instead,
+// which also covers every delta queued before it
+func (game *Game) pushLoop(player *Player, queue chan DeltaRequest) {
+	for req := range queue {
+		client, err := game.pool.getConnection(player)
+		if err != nil {
+			log.Println(err.Error())
+			game.delta.markStale(player.ID)
+			continue
+		}
+
+		if game.delta.takeStale(player.ID) {
+			if err := game.pushSnapshot(player, client); err != nil {
+				game.delta.markStale(player.ID)
+			}
+			continue
+		}
+		if req.Delta == nil {
+			continue
+		}
+
+		var ok bool
+		req.Stamp = game.clock.Now()
+		err = client.Call("Node.PushDelta", req, &ok)
+		if err != nil {
+			log.Println(err.Error())
+			game.delta.markStale(player.ID)
+			continue
+		}
+
+		if !ok {
+			// The peer missed a delta, bring it up to date
+			if err := game.pushSnapshot(player, client); err != nil {
+				game.delta.markStale(player.ID)
+			}
+		}
+	}
+}
+
+// pushSnapshot sends the full state to the player
+func (game *Game) pushSnapshot(player *Player, client Conn) error {
+	log.Printf("[pushSnapshot] Pushing full state to %d", player.ID)
+	game.lock.RLock()
+	version := game.delta.current()
+	state := copyState(game.state)
+	game.lock.RUnlock()
+
+	var ok bool
+	err := client.Call("Node.PushUpdate", UpdateRequest{game.GetPlayer().ID, version, state, game.clock.Now()}, &ok)
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return err
+}
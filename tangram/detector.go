@@ -0,0 +1,144 @@
+package tangram
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// pingInterval is how often the heartbeat pings peers
+// The pings keep the failure detector fed while no other traffic flows
+const pingInterval = time.Second
+
+// defaultEvictionThreshold is the suspicion at which a peer is evicted when GameConfig does not set one
+// Evicting starts a suspicion in the membership protocol, the peer is dropped unless it refutes it
+// A suspicion of 8 means the chance that the peer is still alive is about 1e-8
+const defaultEvictionThreshold = 8.0
+
+// arrivalWindow is how many inter-arrival times are kept per peer
+const arrivalWindow = 100
+
+// minStdDev keeps the detector from getting jumpy when arrivals are very regular
+const minStdDev = 250 * time.Millisecond
+
+// acceptablePause is silence that is tolerated on top of the mean inter-arrival time,
+// such as a call stuck until rpcTimeout or a garbage collection pause
+const acceptablePause = 5 * time.Second
+
+// failureDetector is a phi accrual failure detector.
+// Instead of declaring a peer dead after a fixed timeout, it learns the distribution of the
+// times between messages from each peer, and reports suspicion as phi = -log10(P),
+// P being the chance that a live peer stays silent as long as it has.
+// Any message from a peer counts as an arrival, pings as well as tan traffic.
+type failureDetector struct {
+	mutex sync.Mutex
+	peers map[PlayerID]*arrivals
+}
+
+// arrivals holds the recent inter-arrival times of a peer, in seconds
+type arrivals struct {
+	last      time.Time
+	intervals []float64
+	next      int
+}
+
+func newFailureDetector() *failureDetector {
+	return &failureDetector{peers: make(map[PlayerID]*arrivals)}
+}
+
+// watch starts watching the player, as if a message had just arrived from it
+// Watching a player that is already watched does nothing
+func (d *failureDetector) watch(id PlayerID) {
+	d.mutex.Lock()
+	if _, ok := d.peers[id]; !ok {
+		d.peers[id] = newArrivals(time.Now())
+	}
+	d.mutex.Unlock()
+}
+
+// heartbeat records the arrival of a message from the player
+func (d *failureDetector) heartbeat(id PlayerID) {
+	now := time.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	a, ok := d.peers[id]
+	if !ok {
+		d.peers[id] = newArrivals(now)
+		return
+	}
+	a.add(now.Sub(a.last).Seconds())
+	a.last = now
+}
+
+// phi returns the suspicion that the player has failed, 0 if it is not watched
+func (d *failureDetector) phi(id PlayerID) float64 {
+	now := time.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	a, ok := d.peers[id]
+	if !ok {
+		return 0
+	}
+	return a.phi(now)
+}
+
+func (d *failureDetector) forget(id PlayerID) {
+	d.mutex.Lock()
+	delete(d.peers, id)
+	d.mutex.Unlock()
+}
+
+// newArrivals starts with a single interval of pingInterval, so a new peer is judged by what the heartbeat expects
+func newArrivals(now time.Time) *arrivals {
+	return &arrivals{last: now, intervals: []float64{pingInterval.Seconds()}, next: 1}
+}
+
+func (a *arrivals) add(interval float64) {
+	if len(a.intervals) < arrivalWindow {
+		a.intervals = append(a.intervals, interval)
+		return
+	}
+	a.intervals[a.next%arrivalWindow] = interval
+	a.next++
+}
+
+func (a *arrivals) phi(now time.Time) float64 {
+	var sum float64
+	for _, interval := range a.intervals {
+		sum += interval
+	}
+	mean := sum / float64(len(a.intervals))
+
+	var variance float64
+	for _, interval := range a.intervals {
+		variance += (interval - mean) * (interval - mean)
+	}
+	stdDev := math.Max(math.Sqrt(variance/float64(len(a.intervals))), minStdDev.Seconds())
+	mean += acceptablePause.Seconds()
+
+	// Logistic approximation of the cumulative normal distribution
+	elapsed := now.Sub(a.last).Seconds()
+	y := (elapsed - mean) / stdDev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// evictionThreshold returns the suspicion at which peers are evicted
+func (game *Game) evictionThreshold() float64 {
+	if game.config == nil || game.config.EvictionThreshold <= 0 {
+		return defaultEvictionThreshold
+	}
+	return game.config.EvictionThreshold
+}
+
+// GetSuspicion returns how strongly the player is suspected to have failed
+// Players whose suspicion reaches the eviction threshold are suspected by the membership protocol,
+// and dropped from the game unless they refute it
+func (game *Game) GetSuspicion(id PlayerID) float64 {
+	return game.detector.phi(id)
+}
@@ -0,0 +1,326 @@
+package tangram
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"../lamport"
+)
+
+// answerTimeout is how long a candidate waits for a better candidate to answer
+// A candidate that does not answer in time is treated as failed, it cannot be host
+const answerTimeout = 2 * time.Second
+
+// coordinatorTimeout is how long a candidate that was answered waits for the winner to announce itself
+// The better candidate may fail before it wins, in which case a new election is held
+const coordinatorTimeout = 5 * time.Second
+
+// ElectionStatus is the progress of the last host election, see GameState
+type ElectionStatus string
+
+const (
+	// ElectionNone means that no election was held since this node joined
+	ElectionNone ElectionStatus = ""
+	// ElectionRunning means that candidates are still being challenged
+	ElectionRunning ElectionStatus = "running"
+	// ElectionTerminated means that the winner announced itself as host
+	ElectionTerminated ElectionStatus = "terminated"
+)
+
+// progress orders the statuses, an election only moves forward within its term
+func (s ElectionStatus) progress() int {
+	switch s {
+	case ElectionRunning:
+		return 1
+	case ElectionTerminated:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// ElectionRequest is request argument for Node.Election
+// - Player: The candidate holding the election
+// - Term: The term of the election
+// - Candidate: What the candidate brings to the election, its latency is frozen for the election
+type ElectionRequest struct {
+	Player    PlayerID
+	Term      uint64
+	Candidate Candidate
+	Stamp     lamport.Timestamp
+}
+
+// ElectionResponse is response argument for Node.Election
+// - Answer: Whether the node is a better candidate, in which case it takes over the election
+type ElectionResponse struct {
+	Answer bool
+	Stamp  lamport.Timestamp
+}
+
+// CoordinatorRequest is request argument for Node.Coordinator
+// - Player: The winner of the election, and new host
+// - Term: The term of the election it won
+type CoordinatorRequest struct {
+	Player PlayerID
+	Term   uint64
+	Stamp  lamport.Timestamp
+}
+
+// election is the part this node takes in host elections.
+// Every election has a term, higher than the terms of the elections before it,
+// so that a coordinator message of an election that was given up on is ignored.
+// - term: The highest term this node has seen
+// - running: Whether this node is a candidate in a running election
+// - coordinated: Closed once the running election terminates
+type election struct {
+	mutex       sync.Mutex
+	term        uint64
+	running     bool
+	coordinated chan struct{}
+}
+
+func newElection() *election {
+	return &election{}
+}
+
+// begin makes this node a candidate in the election at term, or at the next term if term was already seen
+// ok is false if this node is already a candidate, the running election then moves on to term
+func (e *election) begin(term uint64) (uint64, chan struct{}, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.running {
+		if term > e.term {
+			e.term = term
+		}
+		return e.term, e.coordinated, false
+	}
+	if term <= e.term {
+		term = e.term + 1
+	}
+	e.term = term
+	e.running = true
+	e.coordinated = make(chan struct{})
+	return e.term, e.coordinated, true
+}
+
+// restart moves the running election on to the next term
+func (e *election) restart() uint64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.term++
+	return e.term
+}
+
+// end terminates the election at term
+// It returns false if the term is older than the last one seen, the coordinator is then stale
+func (e *election) end(term uint64) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if term < e.term {
+		return false
+	}
+	e.term = term
+	if e.running {
+		e.running = false
+		close(e.coordinated)
+	}
+	return true
+}
+
+// campaigning returns whether this node is a candidate in a running election
+func (e *election) campaigning() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.running
+}
+
+// current returns the highest term this node has seen
+func (e *election) current() uint64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.term
+}
+
+// Election holds a host election with the Bully algorithm
+// Candidates are ranked by the ElectionPolicy of the game, by their average latency to their peers unless configured otherwise.
+// This node sends an election message to every better candidate. If none answers in time, it
+// wins and announces itself as coordinator, otherwise it waits for the winner to announce itself.
+// Latencies are frozen until the election terminates, so candidates rank each other consistently.
+// Election returns once the election terminated, or right away if this node is already a candidate.
+// A game with replicas is hosted by the raft leader, none of its players holds an election.
+func (game *Game) Election() {
+	if game.raft.replicated() {
+		log.Println("[Election] The raft leader hosts the game")
+		return
+	}
+	term, coordinated, ok := game.election.begin(0)
+	if !ok {
+		return
+	}
+	game.campaign(term, coordinated)
+}
+
+// joinElection takes over the election of a worse candidate
+func (game *Game) joinElection(term uint64) {
+	if game.raft.replicated() {
+		return
+	}
+	term, coordinated, ok := game.election.begin(term)
+	if !ok {
+		return
+	}
+	game.campaign(term, coordinated)
+}
+
+// campaign runs the election as a candidate until a coordinator is known
+func (game *Game) campaign(term uint64, coordinated chan struct{}) {
+	game.latency.freeze(game.matrixLatency())
+	for {
+		log.Printf("[Election] Holding election, term = %d", term)
+		game.setElection(ElectionRunning, term)
+
+		if !game.challenge(term) {
+			game.declareHost(game.election.current())
+			return
+		}
+
+		select {
+		case <-coordinated:
+			return
+		case <-game.done:
+			return
+		case <-time.After(coordinatorTimeout):
+		}
+
+		term = game.election.restart()
+		log.Printf("[Election] No coordinator came forward, holding a new election")
+	}
+}
+
+// challenge sends an election message to every better candidate
+// It returns true if one of them answered, and so takes over the election
+func (game *Game) challenge(term uint64) (answered bool) {
+	candidates := game.gatherCandidates()
+	me := game.GetCandidate()
+	policy := game.electionPolicy()
+
+	var better []PlayerID
+	for id, candidate := range candidates {
+		if policy.Better(candidate, me) {
+			better = append(better, id)
+		}
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, id := range better {
+		game.lock.RLock()
+		player := game.state.getPlayer(id)
+		game.lock.RUnlock()
+		// A peer that is down cannot be host
+		if player == nil || game.pool.state(id) == PeerDown {
+			continue
+		}
+
+		client, err := game.pool.getConnection(player)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(client Conn, id PlayerID) {
+			defer wg.Done()
+			var res ElectionResponse
+			req := ElectionRequest{me.Player, term, me, game.clock.Now()}
+			err := callTimeout(client, "Node.Election", req, &res, answerTimeout)
+			if err != nil {
+				log.Printf("[Election] No answer from %d: %s", id, err.Error())
+				return
+			}
+			game.clock.Update(res.Stamp)
+			if res.Answer {
+				log.Printf("[Election] %d answered", id)
+				mutex.Lock()
+				answered = true
+				mutex.Unlock()
+			}
+		}(client, id)
+	}
+	wg.Wait()
+	return
+}
+
+// declareHost makes this node host, and announces it to every peer as the coordinator of the election at term
+// In a game with replicas only the raft leader becomes host
+func (game *Game) declareHost(term uint64) {
+	me := game.GetPlayer().ID
+	if game.raft.replicated() && !game.raft.leading() {
+		log.Printf("[Election] Not declaring host ID = %d, only the raft leader hosts the game", me)
+		return
+	}
+	log.Printf("[Election] Declaring host ID = %d, term = %d", me, term)
+	game.acceptCoordinator(me, term)
+
+	game.lock.RLock()
+	players := append([]*Player(nil), game.state.Players...)
+	game.lock.RUnlock()
+
+	game.broadcast(players, func(client Conn) error {
+		var ok bool
+		return client.Call("Node.Coordinator", CoordinatorRequest{me, term, game.clock.Now()}, &ok)
+	})
+}
+
+// acceptCoordinator terminates the election at term with host as the winner
+// It returns false if the coordinator is stale, a later election was started since
+func (game *Game) acceptCoordinator(host PlayerID, term uint64) bool {
+	if !game.election.end(term) {
+		log.Printf("[Election] Ignoring stale coordinator %d, term = %d", host, term)
+		return false
+	}
+	game.latency.thaw()
+
+	game.lock.Lock()
+	game.setHost(host)
+	game.state.Term = term
+	game.state.Election = ElectionTerminated
+	if host == game.GetPlayer().ID {
+		// Bring every peer up to date with the new host
+		game.delta.touchPlayers()
+	}
+	game.notify()
+	game.lock.Unlock()
+	return true
+}
+
+// setElection records the progress of the election in the game state
+func (game *Game) setElection(status ElectionStatus, term uint64) {
+	game.lock.Lock()
+	game.state.Term = term
+	game.state.Election = status
+	game.notify()
+	game.lock.Unlock()
+}
+
+// witnessElection adopts the host and election of a state witnessed from a peer, unless it is older
+// Within a term it only moves the election forward, so a late running state does not undo its termination
+// Must be called while holding Game.lock
+func (game *Game) witnessElection(state *GameState) {
+	if state.Term < game.state.Term {
+		return
+	}
+	if state.Term == game.state.Term && state.Election.progress() < game.state.Election.progress() {
+		return
+	}
+	ended := state.Term > game.state.Term || game.state.Election != ElectionTerminated
+	if ended && state.Election == ElectionTerminated && game.election.end(state.Term) {
+		game.latency.thaw()
+	}
+	game.setHost(state.Host)
+	game.state.Term = state.Term
+	game.state.Election = state.Election
+}
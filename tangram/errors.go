@@ -0,0 +1,46 @@
+package tangram
+
+import (
+	"fmt"
+)
+
+// Reason explains why an operation on a tan was refused
+type Reason string
+
+const (
+	// ReasonUnknownTan means the requested tan does not exist
+	ReasonUnknownTan Reason = "unknown"
+	// ReasonHeld means another player holds the tan
+	ReasonHeld Reason = "held"
+	// ReasonPending means a request for the tan is already in progress
+	ReasonPending Reason = "pending"
+	// ReasonStale means the operation was superseded, either by an earlier
+	// request for the tan or because the lease on it lapsed
+	ReasonStale Reason = "stale"
+)
+
+// TanError is returned by ObtainTan and MoveTan when the operation is refused
+// - Tan: The ID of the tan
+// - Reason: Why the operation was refused
+// - Player: The player holding the tan, NoPlayer if nobody does
+type TanError struct {
+	Tan    TanID
+	Reason Reason
+	Player PlayerID
+}
+
+func (e *TanError) Error() string {
+	if e.Reason == ReasonHeld {
+		return fmt.Sprintf("Tan ID = %d is held by %d", e.Tan, e.Player)
+	}
+	return fmt.Sprintf("Tan ID = %d refused: %s", e.Tan, e.Reason)
+}
+
+// refusal explains why the tan could not be obtained or moved, based on who holds it now
+// Must be called while holding Game.lock
+func (game *Game) refusal(tan *Tan) *TanError {
+	if tan.held(game.clock.Physical()) && tan.Player != game.GetPlayer().ID {
+		return &TanError{tan.ID, ReasonHeld, tan.Player}
+	}
+	return &TanError{tan.ID, ReasonStale, NoPlayer}
+}
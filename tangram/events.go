@@ -0,0 +1,175 @@
+package tangram
+
+import (
+	"time"
+
+	"../lamport"
+)
+
+// maxEvents is how long the event log grows before its oldest events are compacted
+const maxEvents = 4096
+
+// keptEvents is how many of the latest events are kept as they are when the log is compacted
+const keptEvents = 1024
+
+// EventKind is the kind of operation recorded by an Event
+type EventKind string
+
+const (
+	// EventGrab makes Player the holder of the tan
+	EventGrab EventKind = "grab"
+	// EventMove changes the location of the tan
+	EventMove EventKind = "move"
+	// EventRotate changes the rotation of the tan
+	EventRotate EventKind = "rotate"
+	// EventRelease ends the hold of Player on the tan
+	EventRelease EventKind = "release"
+)
+
+// Event is an immutable record of an operation on a tan.
+// The tans of a GameState are derived by folding every event in order,
+// starting from the tans of the GameConfig.
+// - Kind: The operation performed
+// - Tan: The ID of the tan
+// - Player: The player performing the operation
+// - Time: The lamport time of the tan when the operation was performed
+// - Location, Rotation: The payload of a move or rotate
+// - Claimed: The payload of a grab, the lamport time at which Player requested the tan
+// - Expiry: The expiry of the lease granted by a grab, or renewed by a move or rotate
+type Event struct {
+	Kind     EventKind    `json:"kind"`
+	Tan      TanID        `json:"tan"`
+	Player   PlayerID     `json:"player"`
+	Time     lamport.Time `json:"time"`
+	Location Point        `json:"location"`
+	Rotation Rotation     `json:"rotation"`
+	Claimed  lamport.Time `json:"claimed"`
+	Expiry   time.Time    `json:"expiry"`
+}
+
+// apply folds the event into the state
+func (state *GameState) apply(event Event) {
+	tan := state.getTan(event.Tan)
+	if tan == nil {
+		return
+	}
+
+	switch event.Kind {
+	case EventGrab:
+		tan.Player = event.Player
+		tan.Claimed = event.Claimed
+		tan.Expiry = event.Expiry
+	case EventMove, EventRotate:
+		tan.Location = event.Location
+		tan.Rotation = event.Rotation
+		// The move renews the lease of its holder
+		if tan.Player == event.Player && event.Expiry.After(tan.Expiry) {
+			tan.Expiry = event.Expiry
+		}
+	case EventRelease:
+		if tan.Player == event.Player {
+			tan.Player = NoPlayer
+		}
+	}
+}
+
+// record appends the event to the log and applies it to the game state
+// The raft leader also appends it to the replicated log
+// Must be called while holding Game.lock
+func (game *Game) record(event Event) {
+	game.fold(event)
+	game.raft.propose(event)
+}
+
+// fold appends the event to the log and applies it to the game state, without replicating it
+// Once the log grows past maxEvents, all but the latest keptEvents are compacted
+// Must be called while holding Game.lock
+func (game *Game) fold(event Event) {
+	game.events = append(game.events, event)
+	if len(game.events) > maxEvents {
+		n := len(game.events) - keptEvents
+		game.events = append(summarize(game.config, game.events[:n]), game.events[n:]...)
+	}
+	game.state.apply(event)
+	game.delta.touchTan(event.Tan)
+}
+
+// moveEvent records a move of the tan, or a rotate if its rotation changed
+func moveEvent(tan *Tan, player PlayerID, location Point, rotation Rotation, time lamport.Time, expiry time.Time) Event {
+	kind := EventMove
+	if rotation != tan.Rotation {
+		kind = EventRotate
+	}
+	return Event{
+		Kind:     kind,
+		Tan:      tan.ID,
+		Player:   player,
+		Time:     time,
+		Location: location,
+		Rotation: rotation,
+		Expiry:   expiry,
+	}
+}
+
+// summarize returns the fewest events that fold into the same tans as the events do:
+// a move or rotate for every tan that left its starting place, and a grab for every tan that was held
+// Each of them has the lamport time of the last event of its tan
+func summarize(config *GameConfig, events []Event) (summary []Event) {
+	latest := make(map[TanID]lamport.Time)
+	for _, event := range events {
+		latest[event.Tan] = event.Time
+	}
+
+	start := initTans(config)
+	for i, tan := range Replay(config, events).Tans {
+		if tan.Location != start[i].Location || tan.Rotation != start[i].Rotation {
+			kind := EventMove
+			if tan.Rotation != start[i].Rotation {
+				kind = EventRotate
+			}
+			summary = append(summary, Event{Kind: kind, Tan: tan.ID, Player: NoPlayer, Time: latest[tan.ID], Location: tan.Location, Rotation: tan.Rotation})
+		}
+		if tan.Player != NoPlayer || tan.Claimed != 0 || !tan.Expiry.IsZero() {
+			// A released tan keeps the lease it had, its grab is by NoPlayer
+			summary = append(summary, Event{Kind: EventGrab, Tan: tan.ID, Player: tan.Player, Time: latest[tan.ID], Claimed: tan.Claimed, Expiry: tan.Expiry})
+		}
+	}
+	return
+}
+
+// Events returns a copy of every event applied to the game so far, in order
+// Once the log grew long, its oldest events are compacted into the fewest events that lead to
+// the same tans, so the log only details the latest keptEvents or more
+func (game *Game) Events() []Event {
+	game.lock.RLock()
+	events := make([]Event, len(game.events))
+	copy(events, game.events)
+	game.lock.RUnlock()
+	return events
+}
+
+// StateAt returns the tans of the game as they were after the first n events of Events
+// It can be used to audit or replay operations without touching the live game.
+// Undoing operations in the live game is out of scope: other players may have moved the
+// tans since, and an undo would be a move like any other, which players can already make.
+func (game *Game) StateAt(n int) *GameState {
+	events := game.Events()
+	if n < len(events) {
+		events = events[:n]
+	}
+	return Replay(game.GetConfig(), events)
+}
+
+// Replay derives the tans of a game by folding the events over its starting configuration
+func Replay(config *GameConfig, events []Event) *GameState {
+	state := &GameState{
+		Tans:    initTans(config),
+		Players: make([]*Player, 0),
+		Host:    NoPlayer,
+	}
+	for _, event := range events {
+		state.apply(event)
+	}
+	checkSolution(config, state)
+	return state
+}
@@ -0,0 +1,41 @@
+package tangram
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// randomEvents returns events of random players on random tans of the config
+func randomEvents(config *GameConfig, seed int64, n int) (events []Event) {
+	rng := rand.New(rand.NewSource(seed))
+	kinds := []EventKind{EventGrab, EventMove, EventRotate, EventRelease}
+	start := time.Unix(0, 0)
+	for i := 0; i < n; i++ {
+		events = append(events, Event{
+			Kind:     kinds[rng.Intn(len(kinds))],
+			Tan:      config.Tans[rng.Intn(len(config.Tans))].ID,
+			Player:   PlayerID(rng.Intn(3) + 1),
+			Location: Point{X: rng.Int31n(100), Y: rng.Int31n(100)},
+			Rotation: Rotation(rng.Intn(8)),
+			Claimed:  uint64(i),
+			Expiry:   start.Add(time.Duration(rng.Intn(1000)) * time.Second),
+		})
+	}
+	return
+}
+
+func TestSummaryReplaysToTheSameTans(t *testing.T) {
+	config := testConfig(t)
+	for seed := int64(0); seed < 20; seed++ {
+		events := randomEvents(config, seed, 200)
+		for _, n := range []int{0, 1, 50, 199, 200} {
+			compacted := append(summarize(config, events[:n]), events[n:]...)
+			want, got := Replay(config, events).Tans, Replay(config, compacted).Tans
+			if !reflect.DeepEqual(want, got) {
+				t.Fatalf("seed %d, %d events compacted: tans differ", seed, n)
+			}
+		}
+	}
+}
@@ -1,620 +1,644 @@
-package tangram
-
-import (
-	"fmt"
-	"log"
-	"math"
-	"net/rpc"
-	"sync"
-	"time"
-
-	"../lamport"
-)
-
-// Game is the public interface of a tangram game
-type Game struct {
-	lock        sync.RWMutex
-	state       *GameState
-	config      *GameConfig
-	node        *Node
-	pool        *connectionPool
-	subscribers []chan bool
-	latency     *AddrPool
-}
-
-// NewGame starts a new Game
-func NewGame(config *GameConfig, addr string, playerID int) (game *Game, err error) {
-	node, err := startNode(addr, playerID)
-	if err != nil {
-		return
-	}
-
-	state := initState(config, node.player)
-	if config.Host {
-		state.Host = node.player.ID
-	} else {
-		state.Host = NoPlayer
-	}
-
-	game = &Game{
-		state:       state,
-		config:      config,
-		node:        node,
-		latency:     NewAddrPool(),
-		pool:        newConnectionPool(),
-		subscribers: make([]chan bool, 0),
-	}
-
-	node.game = game
-
-	go game.heartbeat()
-
-	return
-}
-
-// ConnectToGame connects to an existing game at addr
-func ConnectToGame(remoteAddr string, addr string, playerID int) (game *Game, err error) {
-	node, err := startNode(addr, playerID)
-	if err != nil {
-		return
-	}
-
-	client, err := rpc.Dial("tcp", remoteAddr)
-	if err != nil {
-		return
-	}
-
-	game = &Game{
-		node:        node,
-		latency:     NewAddrPool(),
-		pool:        newConnectionPool(),
-		subscribers: make([]chan bool, 0),
-	}
-	node.game = game
-
-	game.lock.Lock()
-
-	var res ConnectResponse
-	err = client.Call("Node.Connect", ConnectRequest{*node.player}, &res)
-	if err != nil {
-		return
-	}
-
-	config := res.Config
-	state := initState(config, node.player)
-
-	game.state = state
-	game.config = config
-
-	game.witnessState(res.State)
-	game.lock.Unlock()
-
-	game.syncTime(state.getPlayer(res.Player.ID))
-
-	go game.heartbeat()
-
-	return
-}
-
-func (game *Game) heartbeat() {
-	for {
-		for _, player := range game.interestingPlayers() {
-			if player.ID == game.GetPlayer().ID {
-				continue
-			}
-
-			client, err := game.pool.getConnection(player)
-			if err != nil {
-				log.Println(err.Error())
-				game.dropPlayer(player.ID)
-				delete(game.latency.MyPing, player.ID)
-				continue
-			}
-
-			go func(player *Player, client *rpc.Client) {
-				start := time.Now()
-				err := game.pingPlayer(player.ID, client)
-				end := time.Now()
-				elapsed := end.Sub(start)
-
-				if err != nil {
-					// If there is a disconnection with the host
-					if game.state.Host == player.ID {
-						game.Election()
-					}
-					game.dropPlayer(player.ID)
-					return
-				}
-
-				game.latency.UpdateLatency(player.ID, elapsed)
-			}(player, client)
-		}
-		time.Sleep(5 * time.Second)
-	}
-}
-
-func (game *Game) pingPlayer(id PlayerID, client *rpc.Client) (err error) {
-	var ok bool
-	err = client.Call("Node.Ping", game.GetPlayer().ID, &ok)
-	return
-}
-
-func (game *Game) connectToPeer(player *Player) (err error) {
-	client, err := rpc.Dial("tcp", player.Addr)
-	if err != nil {
-		fmt.Println("connectToPeer error")
-		return
-	}
-
-	var res ConnectResponse
-	err = client.Call("Node.Connect", ConnectRequest{*game.GetPlayer()}, &res)
-	if err != nil {
-		return
-	}
-	game.witnessState(res.State)
-
-	return
-}
-
-func initState(config *GameConfig, player *Player) (state *GameState) {
-	state = &GameState{
-		Timer: time.Now(),
-	}
-
-	state.Tans = make([]*Tan, len(config.Tans))
-	for i, tan := range config.Tans {
-		state.Tans[i] = new(Tan)
-		*state.Tans[i] = *tan
-		state.Tans[i].Player = NoPlayer
-	}
-
-	state.Players = make([]*Player, 1)
-	state.Players[0] = player
-	return
-}
-
-// Returns the gamestate with solved true if solved, false otherwise.
-func checkSolution(config *GameConfig, state *GameState) {
-	numMatched := 0
-	tanMap := make(map[ShapeType][]int)
-
-	//first sort tans into types
-	for i, tan := range state.Tans {
-		tanMap[tan.ShapeType] = append(tanMap[tan.ShapeType], i)
-		tan.Matched = false
-	}
-
-	// Match based on ShapeType
-	for _, target := range config.Targets {
-		switch target.ShapeType {
-		// case MTri:
-		case Cube:
-			numMatched += matchMultiple(state, config, tanMap[target.ShapeType], target, 90.0)
-		case Pgram:
-			numMatched += matchMultiple(state, config, tanMap[target.ShapeType], target, 180.0)
-		default:
-			numMatched += matchMultiple(state, config, tanMap[target.ShapeType], target, 360.0)
-		}
-	}
-	if numMatched == len(config.Targets) {
-		state.Solved = true
-	} else {
-		state.Solved = false
-	}
-}
-
-// returns 1 if matched, 0 otherwise.
-// mod allows shapes like square to match to multiple angles. 360 default
-func matchMultiple(state *GameState, config *GameConfig, indexes []int, target *TargetTan, mod float64) int {
-	for _, index := range indexes {
-		if isMatch(config, state.Tans[index], target, mod) {
-			state.Tans[index].Matched = true
-			return 1
-		}
-	}
-	return 0
-}
-
-func isMatch(config *GameConfig, tan *Tan, target *TargetTan, mod float64) bool {
-	rotationMatches := math.Mod(float64(tan.Rotation), mod) == math.Mod(float64(target.Rotation), mod)
-	return withinMargin(add(target.Location, config.Offset), tan.Location, config.Margin) && rotationMatches
-}
-
-// Subscribe returns a channel that outputs a value when the game state is updated
-func (game *Game) Subscribe() chan bool {
-	channel := make(chan bool, 1)
-	game.subscribers = append(game.subscribers, channel)
-	return channel
-}
-
-// Unsubscribe takes a channel reutrned by Subscribe() and remove & close it
-func (game *Game) Unsubscribe(s chan bool) {
-	index := -1
-	for i, subscriber := range game.subscribers {
-		if subscriber == s {
-			close(subscriber)
-			index = i
-			break
-		}
-	}
-
-	if index < 0 {
-		panic("Channel not found")
-	}
-
-	game.subscribers[index] = game.subscribers[len(game.subscribers)-1]
-	game.subscribers = game.subscribers[:len(game.subscribers)-1]
-}
-
-func (game *Game) notify() {
-	if game.state.Host == game.GetPlayer().ID {
-		state := copyState(game.state)
-		for _, player := range game.interestingPlayers() {
-			if player.ID == game.GetPlayer().ID {
-				continue
-			}
-
-			client, err := game.pool.getConnection(player)
-			if err != nil {
-				log.Println(err.Error())
-				continue
-			}
-
-			go func(client *rpc.Client) {
-				var ok bool
-				err := client.Call("Node.PushUpdate", state, &ok)
-				if err != nil {
-					log.Println(err.Error())
-				}
-			}(client)
-		}
-	}
-	for _, sub := range game.subscribers {
-		select {
-		case sub <- true:
-		default:
-		}
-	}
-	checkSolution(game.config, game.state)
-}
-
-// GetState retrieves the current state of the board
-func (game *Game) GetState() *GameState {
-	game.lock.RLock()
-	stateCopy := copyState(game.state)
-	game.lock.RUnlock()
-	return stateCopy
-}
-
-// GetTime returns the time since the game started
-func (game *Game) GetTime() time.Duration {
-	game.lock.RLock()
-	t := time.Now().Sub(game.state.Timer)
-	game.lock.RUnlock()
-	return t
-}
-
-// GetConfig returns the config of the game
-func (game *Game) GetConfig() *GameConfig {
-	return game.config
-}
-
-func (game *Game) GetPlayer() *Player {
-	return game.node.player
-}
-
-func (game *Game) syncTime(player *Player) (err error) {
-	log.Printf("[syncTime] Start with player %d", player.ID)
-	client, err := rpc.Dial("tcp", player.Addr)
-	if err != nil {
-		return
-	}
-
-	var d1, d2 time.Duration
-	err = client.Call("Node.GetTime", 0, &d1)
-	if err != nil {
-		return
-	}
-	log.Printf("[syncTime] Got first response")
-
-	err = client.Call("Node.GetTime", 0, &d2)
-	if err != nil {
-		return
-	}
-	log.Printf("[syncTime] Got second response")
-
-	t0 := time.Now()
-	rtt := d2 - d1
-
-	newTime := t0.Add(-rtt / 2).Add(-d2)
-	game.lock.Lock()
-	if true {
-		oldTime := game.state.Timer
-		d := newTime.Sub(oldTime).Nanoseconds()
-		log.Printf("Time Sync with Player %d, d = %d\n", player.ID, d)
-	}
-	game.state.Timer = newTime
-	game.lock.Unlock()
-
-	return
-}
-
-// ObtainTan tries to gain control of the specified Tan
-// This function blocks until the Tan is confirmed to be controlled
-// This function is NOT guaranteed thread safe
-func (game *Game) ObtainTan(id TanID, release bool) (ok bool, err error) {
-	log.Printf("[ObtainTan] ID = %d\n", id)
-	game.lock.Lock()
-	tan := game.state.getTan(id)
-	if tan == nil {
-		err = fmt.Errorf("[ObtainTan] Requested tan ID = %d is not found", id)
-		game.lock.Unlock()
-		return
-	}
-
-	if !release && tan.Player != NoPlayer && tan.Player != game.GetPlayer().ID {
-		log.Printf("[ObtainTan] Obtaining TanID = %d failed. Already controlled by %d", id, tan.Player)
-		game.lock.Unlock()
-		return false, nil
-	}
-
-	playerID := game.GetPlayer().ID
-	if release {
-		playerID = NoPlayer
-	}
-
-	time := tan.Clock.Increment()
-	game.lock.Unlock()
-
-	// Ask everyone for the tan!
-	n := 0
-	okChan := make(chan bool, len(game.state.Players))
-	for _, player := range game.interestingPlayers() {
-		if player.ID == game.GetPlayer().ID {
-			continue
-		}
-
-		client, err := game.pool.getConnection(player)
-		// TODO handle error properly
-		if err != nil {
-			continue
-		}
-
-		go func(client *rpc.Client, player PlayerID) {
-			var ok bool
-			client.Call("Node.LockTan", LockTanRequest{id, player, time}, &ok)
-			// TODO handle error properly?
-			if err != nil {
-				log.Println(err.Error())
-				okChan <- true
-			}
-			okChan <- ok
-		}(client, playerID)
-		n++
-	}
-	log.Printf("[ObtainTan] ID = %d. %d peer responses expected\n", id, n)
-
-	// We expect n confirmations
-	ok = true
-	for n > 0 {
-		ok = <-okChan
-		n--
-		log.Printf("[ObtainTan] ID = %d. Got response %t. %d more responses expected\n", id, ok, n)
-		if !ok {
-			return
-		}
-	}
-
-	game.lock.Lock()
-	tan.Player = playerID
-	game.lock.Unlock()
-	game.notify()
-	return
-}
-
-// MoveTan changes the location of a Tan
-// MoveTan does not block and broadcasts the content asynchronously
-func (game *Game) MoveTan(id TanID, location Point, rotation Rotation) (ok bool, err error) {
-	// log.Printf("[MoveTan] ID = %d\n", id)
-	game.lock.Lock()
-	tan := game.state.getTan(id)
-	if tan == nil {
-		err = fmt.Errorf("[ObtainTan] Requested tan ID = %d is not found", id)
-		game.lock.Unlock()
-		return
-	}
-
-	if tan.Player != game.GetPlayer().ID {
-		ok = false
-		game.lock.Unlock()
-		return
-	}
-
-	time := tan.Clock.Increment()
-	tan.Location = location
-	tan.Rotation = rotation
-	ok = true
-	game.lock.Unlock()
-
-	// Let everyone know!
-	for _, player := range game.interestingPlayers() {
-		if player.ID == game.GetPlayer().ID {
-			continue
-		}
-
-		client, err := game.pool.getConnection(player)
-		// TODO handle error properly
-		if err != nil {
-			log.Println(err.Error())
-			continue
-		}
-
-		go func(client *rpc.Client) {
-			var ok bool
-			client.Call("Node.MoveTan", MoveTanRequest{id, location, rotation, time}, &ok)
-		}(client)
-	}
-
-	game.notify()
-	return
-}
-
-func (game *Game) lockTan(tanID TanID, playerID PlayerID, time lamport.Time) (ok bool, err error) {
-	game.lock.Lock()
-	defer game.lock.Unlock()
-	tan := game.state.getTan(tanID)
-	if tan == nil {
-		err = fmt.Errorf("[lockTan] Requested tan ID = %d is not found", tanID)
-		return
-	}
-
-	oldTime := tan.Clock.Time()
-	ok = tan.Clock.Witness(time)
-	if ok {
-		tan.Player = determineOwner(tan.Player, oldTime, playerID, time)
-
-		if tan.Player != playerID {
-			ok = false
-		}
-	}
-
-	game.notify()
-	return
-}
-
-func determineOwner(currentHolder PlayerID, tanTime lamport.Time, playerID PlayerID, newTime lamport.Time) (lockHolder PlayerID) {
-	// If two requests for a tan occur at the same time, handle deterministically
-	// We will use PlayerID to determine who locks the tan
-	if currentHolder != NoPlayer && tanTime == newTime {
-		var lesserID, greaterID PlayerID
-		log.Printf("[lockTan] Resolving conflict between players %v | %v at time: %v\n", currentHolder, playerID, tanTime)
-
-		if currentHolder < playerID {
-			lesserID = currentHolder
-			greaterID = playerID
-		} else {
-			lesserID = playerID
-			greaterID = currentHolder
-		}
-
-		if tanTime%2 == 0 {
-			lockHolder = lesserID
-		} else {
-			lockHolder = greaterID
-		}
-
-		log.Printf("[lockTan] Resolution: %v holds the lock\n", lockHolder)
-	} else {
-		lockHolder = playerID
-	}
-
-	return
-}
-
-func (game *Game) moveTan(tanID TanID, location Point, rotation Rotation, time lamport.Time) (ok bool, err error) {
-	game.lock.Lock()
-	defer game.lock.Unlock()
-	tan := game.state.getTan(tanID)
-	if tan == nil {
-		err = fmt.Errorf("[moveTan] Requested tan ID = %d is not found", tanID)
-		return
-	}
-
-	ok = tan.Clock.Witness(time)
-	if ok {
-		tan.Location = location
-		tan.Rotation = rotation
-	}
-
-	game.notify()
-	return
-}
-
-func (game *Game) witnessTan(newTan *Tan) {
-	tan := game.state.getTan(newTan.ID)
-	if tan == nil {
-		log.Printf("[witnessTan] Witnessed ghost ID = %d\n", newTan.ID)
-		return
-	}
-
-	time := newTan.Clock.Time()
-	oldTime := tan.Clock.Time()
-	ok := tan.Clock.Witness(time)
-	log.Printf("[witnessTan] Witness ID = %d, ok = %t\n", tan.ID, ok)
-	if ok {
-		tan.Location = newTan.Location
-		tan.Rotation = newTan.Rotation
-		tan.Player = determineOwner(tan.Player, oldTime, newTan.Player, time)
-	}
-	checkSolution(game.config, game.state)
-}
-
-func (game *Game) witnessState(state *GameState) {
-	game.state.Host = state.Host
-	for _, tan := range state.Tans {
-		game.witnessTan(tan)
-	}
-	for _, player := range state.Players {
-		if game.state.getPlayer(player.ID) != nil {
-			continue
-		}
-
-		log.Printf("[witnessState] Adding Player %d at %s", player.ID, player.Addr)
-		game.state.Players = append(game.state.Players, player)
-
-		if game.isPlayerInteresting(player) {
-			game.connectToPeer(player)
-		}
-		go game.measureLatency(player)
-	}
-
-	checkSolution(game.config, state)
-}
-
-func (game *Game) interestingPlayers() []*Player {
-	host := game.state.Host
-	// Decentralized
-	if !game.hosted() {
-		return game.state.Players
-	}
-	// I am host, I am responsible for updating all peers
-	if host == game.GetPlayer().ID {
-		return game.state.Players
-	}
-	// I am subscribing to a host, I talk to the host alone
-	hostPlayer := game.state.getPlayer(host)
-	if hostPlayer != nil {
-		return []*Player{hostPlayer}
-	}
-	return []*Player{}
-}
-
-func (game *Game) isPlayerInteresting(player *Player) bool {
-	if !game.hosted() {
-		return true
-	}
-	if game.state.Host == game.GetPlayer().ID {
-		return true
-	}
-	if game.state.Host == player.ID {
-		return true
-	}
-	return false
-}
-
-func (game *Game) hosted() bool {
-	return game.state.Host != NoPlayer
-}
-
-func (game *Game) measureLatency(player *Player) (err error) {
-	client, err := game.pool.getConnection(player)
-	if err != nil {
-		return
-	}
-	start := time.Now()
-	err = game.pingPlayer(player.ID, client)
-	end := time.Now()
-	elapsed := end.Sub(start)
-	game.latency.UpdateLatency(player.ID, elapsed)
-	return
-}
+package tangram
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"../lamport"
+)
+
+// Game is the public interface of a tangram game
+type Game struct {
+	lock        sync.RWMutex
+	state       *GameState
+	config      *GameConfig
+	node        *Node
+	pool        *connectionPool
+	subscribers *subscriberSet
+	latency     *AddrPool
+	clock       *lamport.HybridClock
+	mutex       *tanMutex
+	events      []Event
+	delta       *deltaTracker
+	streams     *moveStreams
+	detector    *failureDetector
+	members     *membership
+	links       *linkMonitor
+	election    *election
+	raft        *raft
+	policy      ElectionPolicy
+	capacity    int64
+	joined      time.Time
+	done        chan struct{}
+	closeOnce   sync.Once
+	unlocks     sync.WaitGroup
+}
+
+// NewGame starts a new Game
+func NewGame(config *GameConfig, addr string, playerID int) (game *Game, err error) {
+	return NewGameWithTransport(TCPTransport, config, addr, playerID)
+}
+
+// NewGameWithNetwork starts a new Game serving net/rpc over network
+func NewGameWithNetwork(network Network, config *GameConfig, addr string, playerID int) (game *Game, err error) {
+	return NewGameWithTransport(NewRPCTransport(network), config, addr, playerID)
+}
+
+// NewGameWithTransport starts a new Game whose node is reached through transport
+func NewGameWithTransport(transport Transport, config *GameConfig, addr string, playerID int) (game *Game, err error) {
+	node, err := startNode(transport, addr, playerID, "")
+	if err != nil {
+		return
+	}
+
+	state := initState(config, node.player)
+	if config.Host {
+		state.Host = node.player.ID
+	} else {
+		state.Host = NoPlayer
+	}
+
+	game = &Game{
+		state:       state,
+		config:      config,
+		node:        node,
+		latency:     NewAddrPool(),
+		pool:        newConnectionPool(transport),
+		subscribers: newSubscriberSet(),
+		clock:       lamport.NewHybridClock(),
+		mutex:       newTanMutex(),
+		delta:       newDeltaTracker(),
+		streams:     newMoveStreams(),
+		detector:    newFailureDetector(),
+		members:     newMembership(*node.player),
+		links:       newLinkMonitor(node.player.ID),
+		election:    newElection(),
+		raft:        newRaft(node.player.ID),
+		joined:      time.Now(),
+		done:        make(chan struct{}),
+	}
+
+	node.game = game
+	game.raft.configure(config, state.Timer)
+
+	go game.heartbeat()
+	go game.reclaimLeases()
+	go game.gossipLoop()
+	go game.raftLoop()
+	go game.rebalanceLoop()
+	go game.relayLoop()
+
+	return
+}
+
+// ConnectToGame connects to an existing game at addr
+func ConnectToGame(remoteAddr string, addr string, playerID int) (game *Game, err error) {
+	return ConnectToGameWithTransport(TCPTransport, remoteAddr, addr, playerID)
+}
+
+// ConnectToGameWithNetwork connects to an existing game at addr, serving net/rpc over network
+func ConnectToGameWithNetwork(network Network, remoteAddr string, addr string, playerID int) (game *Game, err error) {
+	return ConnectToGameWithTransport(NewRPCTransport(network), remoteAddr, addr, playerID)
+}
+
+// ConnectToGameWithTransport connects to an existing game at addr through transport
+func ConnectToGameWithTransport(transport Transport, remoteAddr string, addr string, playerID int) (game *Game, err error) {
+	return connectToGame(transport, remoteAddr, addr, playerID, "")
+}
+
+// connectToGame connects to an existing game at addr through transport
+// The player proves its identity with token, a new one is made if it is empty
+func connectToGame(transport Transport, remoteAddr string, addr string, playerID int, token string) (game *Game, err error) {
+	node, err := startNode(transport, addr, playerID, token)
+	if err != nil {
+		return
+	}
+
+	client, err := transport.Dial(remoteAddr)
+	if err != nil {
+		return
+	}
+
+	game = &Game{
+		node:        node,
+		latency:     NewAddrPool(),
+		pool:        newConnectionPool(transport),
+		subscribers: newSubscriberSet(),
+		clock:       lamport.NewHybridClock(),
+		mutex:       newTanMutex(),
+		delta:       newDeltaTracker(),
+		streams:     newMoveStreams(),
+		detector:    newFailureDetector(),
+		members:     newMembership(*node.player),
+		links:       newLinkMonitor(node.player.ID),
+		election:    newElection(),
+		raft:        newRaft(node.player.ID),
+		joined:      time.Now(),
+		done:        make(chan struct{}),
+	}
+	node.game = game
+
+	game.lock.Lock()
+
+	var res ConnectResponse
+	err = client.Call("Node.Connect", ConnectRequest{*node.player, node.token, game.clock.Now(), game.members.ownIncarnation()}, &res)
+	if err != nil {
+		return
+	}
+	game.clock.Update(res.Stamp)
+	game.members.outlive(res.Incarnation)
+
+	config := res.Config
+	state := initState(config, node.player)
+
+	// The game timer is shared, it started when the first player created the game
+	state.Timer = res.State.Timer
+
+	game.state = state
+	game.config = config
+	game.raft.configure(config, state.Timer)
+
+	game.witnessState(res.State)
+	game.lock.Unlock()
+
+	go game.heartbeat()
+	go game.reclaimLeases()
+	go game.gossipLoop()
+	go game.raftLoop()
+	go game.rebalanceLoop()
+	go game.relayLoop()
+
+	return
+}
+
+// heartbeat pings the interesting players every pingInterval and suspects
+// those the failure detector is confident have failed
+func (game *Game) heartbeat() {
+	for {
+		game.lock.RLock()
+		players := append([]*Player(nil), game.interestingPlayers()...)
+		game.lock.RUnlock()
+
+		threshold := game.evictionThreshold()
+		for _, player := range players {
+			if player.ID == game.GetPlayer().ID {
+				continue
+			}
+
+			// Suspected players are evicted once the suspicion is confirmed, see membership
+			game.detector.watch(player.ID)
+			if phi := game.detector.phi(player.ID); phi >= threshold {
+				log.Printf("[heartbeat] Player %d is unresponsive, suspicion = %.2f", player.ID, phi)
+				game.members.suspect(player.ID)
+			}
+
+			// A failed ping is not a verdict, the pool keeps redialing while suspicion builds up
+			client, err := game.pool.getConnection(player)
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+
+			go func(player *Player, client Conn) {
+				start := time.Now()
+				err := game.pingPlayer(player.ID, client)
+				end := time.Now()
+				elapsed := end.Sub(start)
+
+				if err != nil {
+					game.links.lost(player.ID)
+					return
+				}
+
+				game.links.sample(player.ID, elapsed)
+			}(player, client)
+		}
+		// Peers that missed a delta are owed a snapshot, whether or not anything changes
+		game.resyncStale()
+		game.prunePeers()
+		if !game.sleep(pingInterval) {
+			return
+		}
+	}
+}
+
+// evict drops a player that has failed, and elects a new host if it was the host
+func (game *Game) evict(id PlayerID) {
+	game.lock.Lock()
+	host := game.state.Host
+	game.dropPlayer(id)
+	game.lock.Unlock()
+
+	if host == id {
+		go game.Election()
+	}
+}
+
+func (game *Game) pingPlayer(id PlayerID, client Conn) (err error) {
+	var res PingResponse
+	err = client.Call("Node.Ping", PingRequest{game.GetPlayer().ID, game.clock.Now()}, &res)
+	if err != nil {
+		return
+	}
+	game.clock.Update(res.Stamp)
+	game.detector.heartbeat(id)
+	return
+}
+
+// connectToPeer connects this player to the peer over the pooled connection, which is kept for later calls
+func (game *Game) connectToPeer(player *Player) (err error) {
+	client, err := game.pool.getConnection(player)
+	if err != nil {
+		fmt.Println("connectToPeer error")
+		return
+	}
+
+	var res ConnectResponse
+	err = client.Call("Node.Connect", ConnectRequest{*game.GetPlayer(), game.node.token, game.clock.Now(), game.members.ownIncarnation()}, &res)
+	if err != nil {
+		return
+	}
+	game.clock.Update(res.Stamp)
+	game.members.outlive(res.Incarnation)
+	game.lock.Lock()
+	game.witnessState(res.State)
+	game.lock.Unlock()
+
+	return
+}
+
+func initState(config *GameConfig, player *Player) (state *GameState) {
+	state = &GameState{
+		Timer: time.Now(),
+		Tans:  initTans(config),
+	}
+
+	state.Players = make([]*Player, 1)
+	state.Players[0] = player
+	return
+}
+
+// initTans returns the tans of a game before any event is applied
+func initTans(config *GameConfig) (tans []*Tan) {
+	tans = make([]*Tan, len(config.Tans))
+	for i, tan := range config.Tans {
+		tans[i] = new(Tan)
+		*tans[i] = *tan
+		tans[i].Player = NoPlayer
+	}
+	return
+}
+
+// Returns the gamestate with solved true if solved, false otherwise.
+func checkSolution(config *GameConfig, state *GameState) {
+	numMatched := 0
+	tanMap := make(map[ShapeType][]int)
+
+	//first sort tans into types
+	for i, tan := range state.Tans {
+		tanMap[tan.ShapeType] = append(tanMap[tan.ShapeType], i)
+		tan.Matched = false
+	}
+
+	// Match based on ShapeType
+	for _, target := range config.Targets {
+		switch target.ShapeType {
+		// case MTri:
+		case Cube:
+			numMatched += matchMultiple(state, config, tanMap[target.ShapeType], target, 90.0)
+		case Pgram:
+			numMatched += matchMultiple(state, config, tanMap[target.ShapeType], target, 180.0)
+		default:
+			numMatched += matchMultiple(state, config, tanMap[target.ShapeType], target, 360.0)
+		}
+	}
+	if numMatched == len(config.Targets) {
+		state.Solved = true
+	} else {
+		state.Solved = false
+	}
+}
+
+// returns 1 if matched, 0 otherwise.
+// mod allows shapes like square to match to multiple angles. 360 default
+func matchMultiple(state *GameState, config *GameConfig, indexes []int, target *TargetTan, mod float64) int {
+	for _, index := range indexes {
+		if isMatch(config, state.Tans[index], target, mod) {
+			state.Tans[index].Matched = true
+			return 1
+		}
+	}
+	return 0
+}
+
+func isMatch(config *GameConfig, tan *Tan, target *TargetTan, mod float64) bool {
+	rotationMatches := math.Mod(float64(tan.Rotation), mod) == math.Mod(float64(target.Rotation), mod)
+	return withinMargin(add(target.Location, config.Offset), tan.Location, config.Margin) && rotationMatches
+}
+
+// Subscribe returns a channel that outputs a value when the game state is updated
+func (game *Game) Subscribe() chan bool {
+	return game.subscribers.add()
+}
+
+// Unsubscribe takes a channel reutrned by Subscribe() and remove & close it
+func (game *Game) Unsubscribe(s chan bool) {
+	if !game.subscribers.remove(s) {
+		panic("Channel not found")
+	}
+}
+
+func (game *Game) notify() {
+	if game.state.Host == game.GetPlayer().ID || game.relaying() {
+		game.pushDelta()
+	}
+	game.subscribers.signal()
+	checkSolution(game.config, game.state)
+}
+
+// GetState retrieves the current state of the board
+func (game *Game) GetState() *GameState {
+	game.lock.RLock()
+	stateCopy := copyState(game.state)
+	game.lock.RUnlock()
+	return stateCopy
+}
+
+// GetTime returns the time since the game started
+// The time is measured by the hybrid clock, so it is consistent across nodes
+func (game *Game) GetTime() time.Duration {
+	game.lock.RLock()
+	t := game.clock.Physical().Sub(game.state.Timer)
+	game.lock.RUnlock()
+	return t
+}
+
+// GetConfig returns the config of the game
+func (game *Game) GetConfig() *GameConfig {
+	return game.config
+}
+
+func (game *Game) GetPlayer() *Player {
+	return game.node.player
+}
+
+// GetPeerState returns the health of the connection to the player
+func (game *Game) GetPeerState(id PlayerID) PeerState {
+	return game.pool.state(id)
+}
+
+// ObtainTan tries to gain control of the specified Tan, or releases it
+// This function blocks until the Tan is confirmed to be controlled
+// Requests for the same Tan are mutually exclusive across nodes
+// When the Tan cannot be obtained, err is a *TanError explaining why
+func (game *Game) ObtainTan(id TanID, release bool) (ok bool, err error) {
+	log.Printf("[ObtainTan] ID = %d, release = %t\n", id, release)
+	if release {
+		return game.releaseTan(id)
+	}
+	return game.acquireTan(id)
+}
+
+// MoveTan changes the location of a Tan
+// Moving a Tan renews the lease on it
+// When the Tan is not held by this player, err is a *TanError explaining why
+// MoveTan does not block and broadcasts the content asynchronously
+func (game *Game) MoveTan(id TanID, location Point, rotation Rotation) (ok bool, err error) {
+	// log.Printf("[MoveTan] ID = %d\n", id)
+	game.lock.Lock()
+	tan := game.state.getTan(id)
+	if tan == nil {
+		err = &TanError{id, ReasonUnknownTan, NoPlayer}
+		game.lock.Unlock()
+		return
+	}
+
+	now := game.clock.Physical()
+	if tan.Player != game.GetPlayer().ID || !tan.held(now) {
+		ok = false
+		err = game.refusal(tan)
+		game.lock.Unlock()
+		return
+	}
+
+	time := tan.Clock.Send()
+	tan.Vector.Tick(game.GetPlayer().ID)
+	vector := tan.Vector.Copy()
+	expiry := now.Add(leaseDuration)
+	game.record(moveEvent(tan, tan.Player, location, rotation, time, expiry))
+	ok = true
+
+	// Let everyone know!
+	// Streaming never blocks, so this is safe under the lock
+	for _, player := range game.interestingPlayers() {
+		if player.ID == game.GetPlayer().ID {
+			continue
+		}
+
+		game.streamMove(player, MoveTanRequest{id, game.GetPlayer().ID, game.GetPlayer().ID, location, rotation, time, vector, expiry, game.clock.Now()})
+	}
+
+	game.notify()
+	game.lock.Unlock()
+	return
+}
+
+// determineOwner resolves two concurrent requests for the same tan
+// The request with the earliest lamport time wins, ties are broken by PlayerID
+func determineOwner(currentHolder PlayerID, claimed lamport.Time, playerID PlayerID, time lamport.Time) (PlayerID, lamport.Time) {
+	if currentHolder == NoPlayer {
+		return playerID, time
+	}
+	// A release cannot undo a grab it has not seen
+	if playerID == NoPlayer {
+		return currentHolder, claimed
+	}
+
+	log.Printf("[lockTan] Resolving conflict between players %v@%v | %v@%v\n", currentHolder, claimed, playerID, time)
+	if lamport.Compare(claimed, currentHolder, time, playerID) < 0 {
+		log.Printf("[lockTan] Resolution: %v holds the lock\n", currentHolder)
+		return currentHolder, claimed
+	}
+	log.Printf("[lockTan] Resolution: %v holds the lock\n", playerID)
+	return playerID, time
+}
+
+func (game *Game) moveTan(tanID TanID, playerID PlayerID, location Point, rotation Rotation, time lamport.Time, vector lamport.VectorClock, expiry time.Time) (ok bool, err error) {
+	game.lock.Lock()
+	defer game.lock.Unlock()
+	tan := game.state.getTan(tanID)
+	if tan == nil {
+		err = fmt.Errorf("[moveTan] Requested tan ID = %d is not found", tanID)
+		return
+	}
+
+	order := tan.Vector.Compare(vector)
+	tan.Clock.Receive(time)
+	tan.Vector.Merge(vector)
+	ok = order == lamport.Before || order == lamport.Concurrent
+	if ok {
+		if tan.Player != playerID {
+			// Only the holder moves a tan, so a peer that showed another holder learns who obtained it
+			game.record(Event{Kind: EventGrab, Tan: tanID, Player: playerID, Time: time, Claimed: time, Expiry: expiry})
+		}
+		game.record(moveEvent(tan, playerID, location, rotation, time, expiry))
+		game.relayMove(MoveTanRequest{tanID, playerID, NoPlayer, location, rotation, time, vector, expiry, lamport.Timestamp{}})
+	}
+
+	game.notify()
+	return
+}
+
+func (game *Game) witnessTan(newTan *Tan) {
+	tan := game.state.getTan(newTan.ID)
+	if tan == nil {
+		log.Printf("[witnessTan] Witnessed ghost ID = %d\n", newTan.ID)
+		return
+	}
+
+	order := tan.Vector.Compare(newTan.Vector)
+	tan.Clock.Receive(newTan.Clock.Time())
+	tan.Vector.Merge(newTan.Vector)
+	log.Printf("[witnessTan] Witness ID = %d, order = %v\n", tan.ID, order)
+	switch order {
+	case lamport.Before:
+		game.witnessMove(tan, newTan)
+		game.witnessHolder(tan, newTan.Player, newTan.Claimed, newTan.Expiry)
+	case lamport.Concurrent:
+		game.witnessMove(tan, newTan)
+		holder, claimed := determineOwner(tan.Player, tan.Claimed, newTan.Player, newTan.Claimed)
+		expiry := tan.Expiry
+		if holder == newTan.Player && (holder != tan.Player || newTan.Expiry.After(expiry)) {
+			expiry = newTan.Expiry
+		}
+		game.witnessHolder(tan, holder, claimed, expiry)
+	}
+	checkSolution(game.config, game.state)
+}
+
+// witnessMove records the location and rotation of a witnessed tan, if they changed
+func (game *Game) witnessMove(tan *Tan, newTan *Tan) {
+	if tan.Location == newTan.Location && tan.Rotation == newTan.Rotation {
+		return
+	}
+	game.record(moveEvent(tan, newTan.Player, newTan.Location, newTan.Rotation, tan.Clock.Time(), time.Time{}))
+}
+
+// witnessHolder records the holder of a witnessed tan, if it changed
+func (game *Game) witnessHolder(tan *Tan, holder PlayerID, claimed lamport.Time, expiry time.Time) {
+	if holder == NoPlayer {
+		game.revokeLease(tan)
+		return
+	}
+	if holder == tan.Player && claimed == tan.Claimed && expiry.Equal(tan.Expiry) {
+		return
+	}
+	game.record(Event{
+		Kind:    EventGrab,
+		Tan:     tan.ID,
+		Player:  holder,
+		Time:    tan.Clock.Time(),
+		Claimed: claimed,
+		Expiry:  expiry,
+	})
+}
+
+func (game *Game) witnessState(state *GameState) {
+	game.witnessElection(state)
+	game.witnessRelays(state.Relays)
+	for _, tan := range state.Tans {
+		game.witnessTan(tan)
+	}
+	for _, player := range state.Players {
+		// The state may predate the player dying or leaving
+		if game.state.getPlayer(player.ID) != nil || game.members.gone(player.ID) {
+			continue
+		}
+
+		log.Printf("[witnessState] Adding Player %d at %s", player.ID, player.Addr)
+		game.addPlayer(player)
+		game.members.add(*player)
+	}
+
+	checkSolution(game.config, state)
+}
+
+// addPlayer adds a player learned about from a peer
+// Must be called while holding Game.lock
+func (game *Game) addPlayer(player *Player) {
+	game.state.Players = append(game.state.Players, player)
+	game.delta.touchPlayers()
+
+	// Connecting waits on the lock of the peer, which may be connecting to us
+	if game.isPlayerInteresting(player) {
+		go game.connectToPeer(player)
+	}
+	// A tiered node measures its tiered peers alone, the latency matrix tells it about the others
+	if !game.tiered() || game.isPlayerInteresting(player) {
+		go game.measureLatency(player)
+	}
+}
+
+func (game *Game) interestingPlayers() []*Player {
+	host := game.state.Host
+	// Tiered, I talk to my relay, or to the other relays and my members if I am a relay
+	if game.tiered() {
+		return game.tieredPeers()
+	}
+	// Decentralized
+	if !game.hosted() {
+		return game.state.Players
+	}
+	// I am host, I am responsible for updating all peers
+	if host == game.GetPlayer().ID {
+		return game.state.Players
+	}
+	// I am subscribing to a host, I talk to the host alone
+	hostPlayer := game.state.getPlayer(host)
+	if hostPlayer != nil {
+		return []*Player{hostPlayer}
+	}
+	return []*Player{}
+}
+
+func (game *Game) isPlayerInteresting(player *Player) bool {
+	if game.tiered() {
+		relay := game.relayOf(player.ID)
+		if game.relaying() {
+			return relay == player.ID || relay == game.GetPlayer().ID
+		}
+		return player.ID == game.relayOf(game.GetPlayer().ID)
+	}
+	if !game.hosted() {
+		return true
+	}
+	if game.state.Host == game.GetPlayer().ID {
+		return true
+	}
+	if game.state.Host == player.ID {
+		return true
+	}
+	return false
+}
+
+func (game *Game) hosted() bool {
+	return game.state.Host != NoPlayer
+}
+
+func (game *Game) measureLatency(player *Player) (err error) {
+	client, err := game.pool.getConnection(player)
+	if err != nil {
+		return
+	}
+	start := time.Now()
+	err = game.pingPlayer(player.ID, client)
+	if err != nil {
+		game.links.lost(player.ID)
+		return
+	}
+	game.links.sample(player.ID, time.Since(start))
+	return
+}
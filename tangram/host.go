@@ -1,49 +1,60 @@
 package tangram
 
 import (
-	"fmt"
 	"log"
-	"net/rpc"
 	"sync"
 	"time"
 )
 
-// AddrPool is a struct with the following fields:
-// - Pool: A map of int player IDs and their latency
+// AddrPool pins the latency this node reports while an election runs, the latencies
+// themselves are the round trip times of the latency matrix, see linkMonitor
+// - frozen: Whether the average latency is pinned, it is during an election
+// - frozenAvg: The average latency of this node when the election started, see GetAvgLatency
 type AddrPool struct {
-	MyPing  map[PlayerID]time.Duration
-	AvgPing map[PlayerID]time.Duration
-	Mutex   *sync.Mutex
+	Mutex     *sync.Mutex
+	frozen    bool
+	frozenAvg time.Duration
 }
 
-// unknownLatency specifies latencies that have not been measured or is unknown
-const unknownLatency = -1
-
 // hostSwitchTimeout is a setting for how long before switching a host
-const hostSwitchTimeout = 60
+// A peer has to stay clearly better than the host for that long, see rebalanceLoop
+const hostSwitchTimeout = 60 * time.Second
 
 // NewAddrPool creates a new address pool
 func NewAddrPool() *AddrPool {
 	return &AddrPool{
-		MyPing:  make(map[PlayerID]time.Duration),
-		AvgPing: make(map[PlayerID]time.Duration),
-		Mutex:   &sync.Mutex{},
+		Mutex: &sync.Mutex{},
 	}
 }
 
-// UpdateLatency updates the latency of the corresponding address
-func (a *AddrPool) UpdateLatency(id PlayerID, latency time.Duration) {
+// freeze pins the reported latency, so that candidates rank each other the same way throughout an election
+// - avg: The average latency of this node, which is reported until thaw
+func (a *AddrPool) freeze(avg time.Duration) {
+	a.Mutex.Lock()
+	a.frozen = true
+	a.frozenAvg = avg
+	a.Mutex.Unlock()
+}
+
+// thaw reports the latency of the matrix again
+func (a *AddrPool) thaw() {
 	a.Mutex.Lock()
-	log.Printf("[UpdateLatency] ID = %d, latency = %d", id, latency)
-	a.MyPing[id] = latency
+	a.frozen = false
 	a.Mutex.Unlock()
 }
 
-// SendLatenciesOver gets other nodes to send their average latencies over
-func (game *Game) SendLatenciesOver() {
+// gatherCandidates gets other nodes to send what they bring to a host election
+// Peers that do not answer within answerTimeout are left out, they cannot be host
+func (game *Game) gatherCandidates() map[PlayerID]Candidate {
+	game.lock.RLock()
+	players := append([]*Player(nil), game.state.Players...)
+	game.lock.RUnlock()
+
+	var mutex sync.Mutex
+	candidates := make(map[PlayerID]Candidate)
 	var wg sync.WaitGroup
-	for _, player := range game.state.Players {
-		if player.ID == game.node.player.ID {
+	for _, player := range players {
+		if player.ID == game.node.player.ID || game.pool.state(player.ID) == PeerDown {
 			continue
 		}
 
@@ -55,105 +66,67 @@ func (game *Game) SendLatenciesOver() {
 		}
 
 		wg.Add(1)
-		go func(client *rpc.Client, player PlayerID) {
+		go func(client Conn, player PlayerID) {
 			defer wg.Done()
-			var latency time.Duration
-			err := client.Call("Node.GetLatency", 0, &latency)
+			var candidate Candidate
+			err := callTimeout(client, "Node.GetCandidate", 0, &candidate, answerTimeout)
 			if err != nil {
-				fmt.Println("[Get Latency] Cannot get latency from %d.", player)
+				log.Printf("[Get Candidate] Cannot get candidate from %d.", player)
 				return
 			}
-			log.Printf("[Get Latency] Got latency from %d.", player)
-			game.latency.AvgPing[player] = latency
+			log.Printf("[Get Candidate] Got candidate from %d.", player)
+			mutex.Lock()
+			candidates[player] = candidate
+			mutex.Unlock()
 		}(client, player.ID)
 	}
 	wg.Wait()
+	return candidates
 }
 
-// Election will start an election with nodes with higher IDs
-func (game *Game) Election() {
-	// Don't allow any action during an election
-	game.lock.Lock()
-	defer game.lock.Unlock()
+// GetAvgLatency averages the round trip times of this node to the other players in the latency matrix
+// It is frozen during an election, see AddrPool
+func (game *Game) GetAvgLatency() time.Duration {
+	avg := game.matrixLatency()
 	game.latency.Mutex.Lock()
 	defer game.latency.Mutex.Unlock()
-
-	// Tell others to send their latencies over
-	// TODO This should also signal that an election started
-	// Until the election ends, nobody should be allowed to update their latency
-	game.SendLatenciesOver()
-
-	myPlayer := game.node.player.ID
-	myLatency := game.GetAvgLatency()
-
-	// We use Bully Algorithm to figure out who should be the new host
-	// Let's first see if someone else would be the host
-	for player, latency := range game.latency.AvgPing {
-		if less(player, latency, myPlayer, myLatency) {
-			player := game.state.getPlayer(player)
-			if player == nil {
-				continue
-			}
-
-			client, err := game.pool.getConnection(player)
-			if err != nil {
-				continue
-			}
-
-			var ok bool
-			err = client.Call("Node.HostElection", 0, &ok)
-			if err != nil {
-				continue
-			}
-
-			// If we reached here, someone finished the election?
-			// TODO Figure out what it means to reach here
-			log.Printf("Node.HostElection returned from ID = %d", player.ID)
-			return
-		}
+	if game.latency.frozen {
+		return game.latency.frozenAvg
 	}
+	return avg
+}
 
-	// It is now our turn to become host
-	// TODO tell everyone to listen to you
-	game.state.Host = myPlayer
-	log.Printf("[Election] Declaring host ID = %d", myPlayer)
-	for _, player := range game.interestingPlayers() {
-
-		if player.ID == myPlayer {
-			continue
-		}
-
-		client, err := game.pool.getConnection(player)
-		if err != nil {
-			log.Println(err.Error())
-			continue
-		}
+// matrixLatency averages the round trip times of this node to the other players, as gossiped in the latency matrix
+func (game *Game) matrixLatency() time.Duration {
+	return averageLatency(game.Topology(), game.GetPlayer().ID)
+}
 
-		var ok bool
-		err = client.Call("Node.ConnectToMe", myPlayer, &ok)
-		if err != nil {
-			log.Println(err.Error())
-			continue
+// averageLatency averages the round trip times of the player to the other players of the topology
+// A link the player did not measure is read from the report of the other end, one neither end measured is left out.
+// Unlike newLatencies it ignores loss, which grows with every ping lost to a failed host, so candidates
+// would rank each other differently from one moment to the next while they elect the next one.
+func averageLatency(topology Topology, me PlayerID) (avg time.Duration) {
+	rtt := make(latencies)
+	for _, link := range topology.Links {
+		if link.RTT != 0 {
+			rtt[[2]PlayerID{link.From, link.To}] = link.RTT
 		}
 	}
-
-	return
-}
-
-// GetAvgLatency will check all of the latencies collected and
-// average them.
-func (game *Game) GetAvgLatency() (avg time.Duration) {
-	avg = 0
 	var sum time.Duration
 	var count time.Duration
-	for _, latency := range game.latency.MyPing {
-		sum += latency
-		count++
+	for _, id := range topology.Players {
+		if id == me {
+			continue
+		}
+		if d, ok := rtt.measured(me, id); ok {
+			sum += d
+			count++
+		}
 	}
 	if count != 0 {
 		avg = sum / count
 	}
-	return avg
+	return
 }
 
 func less(player1 PlayerID, latency1 time.Duration, player2 PlayerID, latency2 time.Duration) bool {
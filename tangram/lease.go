@@ -0,0 +1,73 @@
+package tangram
+
+import (
+	"log"
+	"time"
+
+	"../lamport"
+)
+
+// leaseDuration is how long a player holds a tan without moving it
+const leaseDuration = 15 * time.Second
+
+// leaseCheckInterval is how often lapsed leases are reclaimed
+const leaseCheckInterval = time.Second
+
+// held returns whether the tan is held by a player whose lease has not lapsed
+// now must be measured by the hybrid clock so that every node agrees on expiry
+func (tan *Tan) held(now time.Time) bool {
+	return tan.Player != NoPlayer && now.Before(tan.Expiry)
+}
+
+// grantLease makes the player the holder of the tan until the lease lapses
+// - claimed: The lamport time at which the player requested the tan
+// Must be called while holding Game.lock
+func (game *Game) grantLease(tan *Tan, player PlayerID, claimed lamport.Time, now time.Time) {
+	game.record(Event{
+		Kind:    EventGrab,
+		Tan:     tan.ID,
+		Player:  player,
+		Time:    tan.Clock.Time(),
+		Claimed: claimed,
+		Expiry:  now.Add(leaseDuration),
+	})
+}
+
+// revokeLease ends the hold of the current holder on the tan
+// Must be called while holding Game.lock
+func (game *Game) revokeLease(tan *Tan) {
+	if tan.Player == NoPlayer {
+		return
+	}
+	game.record(Event{
+		Kind:   EventRelease,
+		Tan:    tan.ID,
+		Player: tan.Player,
+		Time:   tan.Clock.Time(),
+	})
+}
+
+// reclaimLeases periodically releases every tan whose lease has lapsed
+// Every node reclaims independently, so abandoned tans become grabbable
+// again without having to evict their holder from the game
+func (game *Game) reclaimLeases() {
+	for game.sleep(leaseCheckInterval) {
+		now := game.clock.Physical()
+		reclaimed := false
+		game.lock.Lock()
+		for _, tan := range game.state.Tans {
+			if tan.Player == NoPlayer || tan.held(now) {
+				continue
+			}
+
+			log.Printf("[reclaimLeases] Lease of player %d on tan ID = %d lapsed", tan.Player, tan.ID)
+			game.revokeLease(tan)
+			game.replyDeferred(tan)
+			reclaimed = true
+		}
+		if reclaimed {
+			game.notify()
+		}
+		game.lock.Unlock()
+	}
+}
@@ -0,0 +1,141 @@
+package tangram
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"../lamport"
+)
+
+// LeaveRequest is request argument for Node.Leave
+// - Incarnation: The incarnation of the player in the membership protocol, see MemberUpdate
+type LeaveRequest struct {
+	Player      Player
+	Incarnation uint64
+	Stamp       lamport.Timestamp
+}
+
+// Leave leaves the game gracefully and closes it
+// The tans held by this player are released, hosting is handed off to the
+// best peer of the election policy, and every peer is told to drop this player right away
+func (game *Game) Leave() error {
+	me := game.GetPlayer().ID
+
+	game.lock.RLock()
+	var held []TanID
+	for _, tan := range game.state.Tans {
+		if tan.Player == me {
+			held = append(held, tan.ID)
+		}
+	}
+	hosting := game.state.Host == me
+	game.lock.RUnlock()
+
+	for _, id := range held {
+		_, err := game.ObtainTan(id, true)
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	// The replicas elect a new leader, and so host, by themselves
+	if hosting && !game.raft.replicated() {
+		game.handOffHost()
+	}
+
+	game.announceLeave()
+	return game.Close()
+}
+
+// Close stops the game without telling the peers, who drop this player once they notice it is gone
+// The listener, the connections to peers and the background goroutines of the game are all stopped
+func (game *Game) Close() (err error) {
+	game.closeOnce.Do(func() {
+		log.Println("[Close] Closing the game")
+		close(game.done)
+		// No unlock starts once done is closed, see broadcastUnlock
+		game.lock.Lock()
+		game.lock.Unlock()
+		game.unlocks.Wait()
+		game.streams.close()
+		game.delta.close()
+		game.pool.close()
+		err = game.node.listener.Close()
+	})
+	return
+}
+
+// sleep waits for d, and returns false if the game was closed meanwhile
+func (game *Game) sleep(d time.Duration) bool {
+	select {
+	case <-game.done:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// handOffHost makes the peer the election policy ranks best the new host, as an election would
+// Peers that do not answer within answerTimeout are left out, see gatherCandidates
+func (game *Game) handOffHost() {
+	policy := game.electionPolicy()
+	successor := NoPlayer
+	var best Candidate
+	for id, candidate := range game.gatherCandidates() {
+		if successor == NoPlayer || policy.Better(candidate, best) {
+			successor, best = id, candidate
+		}
+	}
+
+	if successor == NoPlayer {
+		return
+	}
+
+	log.Printf("[Leave] Handing off hosting to %d", successor)
+	game.handOver(successor)
+}
+
+// announceLeave tells every peer that this player left
+// A tiered node tells its tiered peers alone, membership gossip tells the other players
+func (game *Game) announceLeave() {
+	game.lock.RLock()
+	players := game.state.Players
+	if game.tiered() {
+		players = game.tieredPeers()
+	}
+	players = append([]*Player(nil), players...)
+	game.lock.RUnlock()
+
+	game.broadcast(players, func(client Conn) error {
+		var ok bool
+		req := LeaveRequest{*game.GetPlayer(), game.members.ownIncarnation(), game.clock.Now()}
+		return client.Call("Node.Leave", req, &ok)
+	})
+}
+
+// broadcast makes the call to every player but this one, and waits for all of them
+func (game *Game) broadcast(players []*Player, call func(client Conn) error) {
+	var wg sync.WaitGroup
+	for _, player := range players {
+		if player.ID == game.GetPlayer().ID {
+			continue
+		}
+
+		client, err := game.pool.getConnection(player)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(client Conn) {
+			defer wg.Done()
+			err := call(client)
+			if err != nil {
+				log.Println(err.Error())
+			}
+		}(client)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,475 @@
+package tangram
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"../lamport"
+)
+
+// Membership is gossiped between peers, SWIM style.
+// Every protocolPeriod each node probes one member with Node.Gossip. If the member does not answer,
+// indirectProbes other members are asked to probe it with Node.ProbeMember, and if none of them
+// reaches it either, it is suspected. A suspected member that does not refute the suspicion within
+// suspicionTimeout is confirmed dead and dropped from the game.
+// Changes of membership ride along probes and their replies until every member has likely seen them.
+// Incarnation numbers order the updates about a member: only the member itself increments its
+// incarnation, which it does to refute a suspicion, or to outlive the incarnation of its previous
+// session when it rejoins.
+// Gossip never moves a member that is in the game to another address, only a Node.Connect whose
+// session token checks out does, see membership.join.
+
+// protocolPeriod is how often a member is probed
+const protocolPeriod = time.Second
+
+// probeTimeout is how long a probe waits for its reply
+const probeTimeout = 500 * time.Millisecond
+
+// indirectProbes is how many members are asked to probe a member that did not answer
+const indirectProbes = 3
+
+// suspicionTimeout is how long a suspected member has to refute the suspicion
+const suspicionTimeout = 5 * time.Second
+
+// retransmitMult scales how many times an update is gossiped, which is retransmitMult * log2(members)
+const retransmitMult = 3
+
+// maxPiggyback is the most updates carried by a single message
+const maxPiggyback = 16
+
+// MemberStatus is what a member is believed to be
+type MemberStatus int
+
+const (
+	// MemberAlive means the member answers probes
+	MemberAlive MemberStatus = iota
+	// MemberSuspect means the member did not answer a probe, directly or indirectly
+	MemberSuspect
+	// MemberDead means the suspicion was confirmed
+	MemberDead
+	// MemberLeft means the member left the game on its own
+	MemberLeft
+)
+
+func (s MemberStatus) String() string {
+	switch s {
+	case MemberAlive:
+		return "alive"
+	case MemberSuspect:
+		return "suspect"
+	case MemberDead:
+		return "dead"
+	default:
+		return "left"
+	}
+}
+
+// MemberUpdate is a change of membership, as gossiped between peers
+type MemberUpdate struct {
+	Player      Player
+	Status      MemberStatus
+	Incarnation uint64
+}
+
+// GossipRequest is request argument for Node.Gossip, which is the direct probe
+// - Links: The rows of the latency matrix known to Player, see Game.Topology
+// - Relays: The relay plan known to Player, see RelayPlan
+type GossipRequest struct {
+	Player  PlayerID
+	Updates []MemberUpdate
+	Links   []LinkReport
+	Relays  RelayPlan
+	Stamp   lamport.Timestamp
+}
+
+// GossipResponse is the response of Node.Gossip
+type GossipResponse struct {
+	Updates []MemberUpdate
+	Links   []LinkReport
+	Relays  RelayPlan
+	Stamp   lamport.Timestamp
+}
+
+// ProbeRequest is request argument for Node.ProbeMember, which is the indirect probe
+// - Target: The member to probe on behalf of Player
+type ProbeRequest struct {
+	Player  PlayerID
+	Target  PlayerID
+	Updates []MemberUpdate
+	Stamp   lamport.Timestamp
+}
+
+type member struct {
+	player      Player
+	status      MemberStatus
+	incarnation uint64
+	suspected   time.Time
+}
+
+type broadcast struct {
+	update    MemberUpdate
+	transmits int
+}
+
+// membership is the view of this node on the members of the game
+type membership struct {
+	mutex       sync.Mutex
+	self        Player
+	incarnation uint64
+	members     map[PlayerID]*member
+	queue       []*broadcast
+	probes      []PlayerID
+	rand        *rand.Rand
+}
+
+// membershipEffect is what the game has to do after an update is applied
+type membershipEffect int
+
+const (
+	effectNone membershipEffect = iota
+	effectJoin
+	effectDrop
+)
+
+func newMembership(self Player) *membership {
+	return &membership{
+		self:    self,
+		members: make(map[PlayerID]*member),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// add registers a player that joined the game some other way, and gossips that it is alive
+// Players that are already known are left alone
+func (m *membership) add(player Player) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if player.ID == m.self.ID {
+		return
+	}
+	if _, ok := m.members[player.ID]; ok {
+		return
+	}
+	m.members[player.ID] = &member{player: player, status: MemberAlive}
+	m.enqueue(MemberUpdate{player, MemberAlive, 0})
+}
+
+// join registers a player that connected to this node with its own incarnation, and gossips that it is alive
+// Unlike add, it brings back a player that died or left, and takes up the new address of a player that
+// rejoined, which the caller vouches for by checking its session token
+// It returns the incarnation known for the player, which the player outlives if it is not its own
+func (m *membership) join(player Player, incarnation uint64) uint64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	current, ok := m.members[player.ID]
+	if !ok {
+		m.members[player.ID] = &member{player: player, status: MemberAlive, incarnation: incarnation}
+		m.enqueue(MemberUpdate{player, MemberAlive, incarnation})
+		return incarnation
+	}
+	if incarnation > current.incarnation {
+		current.incarnation = incarnation
+	}
+	if current.status == MemberDead || current.status == MemberLeft || current.player.Addr != player.Addr {
+		current.player = player
+		current.status = MemberAlive
+		m.enqueue(MemberUpdate{player, MemberAlive, current.incarnation})
+	}
+	return current.incarnation
+}
+
+// outlive raises the own incarnation above the one a peer knows for this node, and gossips that it is alive
+// A player that rejoins starts its new session over, its previous one may have reached a higher incarnation
+func (m *membership) outlive(incarnation uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if incarnation > m.incarnation {
+		m.incarnation = incarnation + 1
+		log.Printf("[membership] Outliving incarnation %d, incarnation = %d", incarnation, m.incarnation)
+		m.enqueue(MemberUpdate{m.self, MemberAlive, m.incarnation})
+	}
+}
+
+func (m *membership) ownIncarnation() uint64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.incarnation
+}
+
+// gone returns whether the player is known to have died or left
+func (m *membership) gone(id PlayerID) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	member, ok := m.members[id]
+	return ok && (member.status == MemberDead || member.status == MemberLeft)
+}
+
+// apply merges an update into the view, and returns what the game has to do about it
+func (m *membership) apply(u MemberUpdate) membershipEffect {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if u.Player.ID == m.self.ID {
+		// Refute anything but alive by outliving the incarnation it was said about
+		if u.Status != MemberAlive && u.Incarnation >= m.incarnation {
+			m.incarnation = u.Incarnation + 1
+			log.Printf("[membership] Refuting %v, incarnation = %d", u.Status, m.incarnation)
+			m.enqueue(MemberUpdate{m.self, MemberAlive, m.incarnation})
+		}
+		return effectNone
+	}
+
+	current, ok := m.members[u.Player.ID]
+	if !ok {
+		m.members[u.Player.ID] = &member{player: u.Player, status: u.Status, incarnation: u.Incarnation, suspected: time.Now()}
+		m.enqueue(u)
+		if u.Status == MemberAlive || u.Status == MemberSuspect {
+			return effectJoin
+		}
+		return effectNone
+	}
+
+	wasIn := current.status == MemberAlive || current.status == MemberSuspect
+	switch u.Status {
+	case MemberAlive:
+		if u.Incarnation <= current.incarnation {
+			return effectNone
+		}
+	case MemberSuspect:
+		if u.Incarnation < current.incarnation || (current.status != MemberAlive && u.Incarnation == current.incarnation) {
+			return effectNone
+		}
+		current.suspected = time.Now()
+	case MemberDead, MemberLeft:
+		if !wasIn && u.Incarnation <= current.incarnation {
+			return effectNone
+		}
+	}
+
+	log.Printf("[membership] Player %d is %v, incarnation = %d", u.Player.ID, u.Status, u.Incarnation)
+
+	// Gossip only brings a player back at a new address, it does not move a player that is in the game
+	if !wasIn && u.Incarnation >= current.incarnation {
+		current.player = u.Player
+	}
+	current.status = u.Status
+	if u.Incarnation > current.incarnation {
+		current.incarnation = u.Incarnation
+	}
+	m.enqueue(MemberUpdate{current.player, u.Status, u.Incarnation})
+
+	isIn := u.Status == MemberAlive || u.Status == MemberSuspect
+	if isIn && !wasIn {
+		return effectJoin
+	}
+	if !isIn && wasIn {
+		return effectDrop
+	}
+	return effectNone
+}
+
+// suspect marks the member suspected, unless it already is or is gone
+func (m *membership) suspect(id PlayerID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	current, ok := m.members[id]
+	if !ok || current.status != MemberAlive {
+		return
+	}
+	log.Printf("[membership] Suspecting player %d", id)
+	current.status = MemberSuspect
+	current.suspected = time.Now()
+	m.enqueue(MemberUpdate{current.player, MemberSuspect, current.incarnation})
+}
+
+// expire confirms the suspicions that were not refuted in time, and returns the members now dead
+func (m *membership) expire() (dead []PlayerID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	for id, current := range m.members {
+		if current.status == MemberSuspect && now.Sub(current.suspected) >= suspicionTimeout {
+			log.Printf("[membership] Confirming player %d dead", id)
+			current.status = MemberDead
+			m.enqueue(MemberUpdate{current.player, MemberDead, current.incarnation})
+			dead = append(dead, id)
+		}
+	}
+	return
+}
+
+// enqueue queues the update for gossip, replacing older updates about the same member
+// Must be called while holding membership.mutex
+func (m *membership) enqueue(u MemberUpdate) {
+	for i, b := range m.queue {
+		if b.update.Player.ID == u.Player.ID {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			break
+		}
+	}
+	m.queue = append(m.queue, &broadcast{update: u})
+}
+
+// gossip returns the updates to piggyback on the next message
+// Updates that were sent the fewest times go first, and are dropped once they were sent enough times
+func (m *membership) gossip() (updates []MemberUpdate) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	limit := retransmitMult * int(math.Ceil(math.Log2(float64(len(m.members)+2))))
+
+	kept := m.queue[:0]
+	for _, b := range m.queue {
+		if len(updates) < maxPiggyback {
+			updates = append(updates, b.update)
+			b.transmits++
+		}
+		if b.transmits < limit {
+			kept = append(kept, b)
+		}
+	}
+	m.queue = kept
+	return
+}
+
+// nextProbe returns the member to probe, going round robin through the members in a random order
+// Members missing from peers are skipped, unless peers is nil
+func (m *membership) nextProbe(peers map[PlayerID]bool) (player Player, ok bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for attempts := 0; attempts < 2; attempts++ {
+		for len(m.probes) > 0 {
+			id := m.probes[0]
+			m.probes = m.probes[1:]
+			current, ok := m.members[id]
+			if ok && (current.status == MemberAlive || current.status == MemberSuspect) && (peers == nil || peers[id]) {
+				return current.player, true
+			}
+		}
+		for id := range m.members {
+			m.probes = append(m.probes, id)
+		}
+		m.rand.Shuffle(len(m.probes), func(i, j int) {
+			m.probes[i], m.probes[j] = m.probes[j], m.probes[i]
+		})
+	}
+	return
+}
+
+// helpers returns up to n random members other than target that are alive
+// Members missing from peers are left out, unless peers is nil
+func (m *membership) helpers(target PlayerID, n int, peers map[PlayerID]bool) (players []Player) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id, current := range m.members {
+		if id != target && current.status == MemberAlive && (peers == nil || peers[id]) {
+			players = append(players, current.player)
+		}
+	}
+	m.rand.Shuffle(len(players), func(i, j int) {
+		players[i], players[j] = players[j], players[i]
+	})
+	if len(players) > n {
+		players = players[:n]
+	}
+	return
+}
+
+func (m *membership) lookup(id PlayerID) (player Player, ok bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	current, ok := m.members[id]
+	if !ok {
+		return
+	}
+	return current.player, true
+}
+
+// gossipLoop runs the protocol periods of this node
+func (game *Game) gossipLoop() {
+	for game.sleep(protocolPeriod) {
+		for _, id := range game.members.expire() {
+			game.evict(id)
+		}
+
+		peers := game.swimPeers()
+		target, ok := game.members.nextProbe(peers)
+		if !ok {
+			continue
+		}
+		if game.probe(&target) || game.probeIndirectly(target.ID, peers) {
+			continue
+		}
+		game.members.suspect(target.ID)
+	}
+}
+
+// probe gossips with the player, and returns whether it answered in time
+func (game *Game) probe(player *Player) bool {
+	client, err := game.pool.getConnection(player)
+	if err != nil {
+		return false
+	}
+
+	var res GossipResponse
+	req := GossipRequest{game.GetPlayer().ID, game.members.gossip(), game.links.gossip(), game.relayPlan(), game.clock.Now()}
+	start := time.Now()
+	err = callTimeout(client, "Node.Gossip", req, &res, probeTimeout)
+	if err != nil {
+		game.links.lost(player.ID)
+		return false
+	}
+	game.links.sample(player.ID, time.Since(start))
+	game.clock.Update(res.Stamp)
+	game.detector.heartbeat(player.ID)
+	game.applyUpdates(res.Updates)
+	game.links.merge(res.Links)
+	game.gossipRelays(res.Relays)
+	return true
+}
+
+// probeIndirectly asks other members among peers to probe the target, and returns whether any of them reached it
+func (game *Game) probeIndirectly(target PlayerID, peers map[PlayerID]bool) bool {
+	helpers := game.members.helpers(target, indirectProbes, peers)
+	acks := make(chan bool, len(helpers))
+	for i := range helpers {
+		go func(helper *Player) {
+			client, err := game.pool.getConnection(helper)
+			if err != nil {
+				acks <- false
+				return
+			}
+			var ok bool
+			req := ProbeRequest{game.GetPlayer().ID, target, game.members.gossip(), game.clock.Now()}
+			err = callTimeout(client, "Node.ProbeMember", req, &ok, 2*probeTimeout)
+			acks <- err == nil && ok
+		}(&helpers[i])
+	}
+
+	for range helpers {
+		if <-acks {
+			return true
+		}
+	}
+	return false
+}
+
+// applyUpdates merges gossiped updates into the view, adding and dropping players as needed
+func (game *Game) applyUpdates(updates []MemberUpdate) {
+	for _, u := range updates {
+		switch game.members.apply(u) {
+		case effectJoin:
+			player := u.Player
+			game.lock.Lock()
+			if game.state.getPlayer(player.ID) == nil {
+				game.addPlayer(&player)
+				game.notify()
+			}
+			game.lock.Unlock()
+		case effectDrop:
+			game.evict(u.Player.ID)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tangram
+
+import "testing"
+
+func TestGossipDoesNotMovePlayers(t *testing.T) {
+	m := newMembership(Player{ID: 1, Addr: "n1:1"})
+	m.add(Player{ID: 2, Addr: "n2:1"})
+
+	// A peer claims player 2 moved, only a Connect that checks its token can move it
+	if effect := m.apply(MemberUpdate{Player{ID: 2, Addr: "evil:1"}, MemberAlive, 5}); effect != effectNone {
+		t.Fatalf("effect = %v of a gossiped move", effect)
+	}
+	if player, _ := m.lookup(2); player.Addr != "n2:1" {
+		t.Fatalf("player 2 moved to %s by gossip", player.Addr)
+	}
+	if incarnation := m.join(Player{ID: 2, Addr: "n2:2"}, 0); incarnation != 5 {
+		t.Fatalf("join returned incarnation %d, want 5", incarnation)
+	}
+	if player, _ := m.lookup(2); player.Addr != "n2:2" {
+		t.Fatalf("player 2 is at %s after it connected from n2:2", player.Addr)
+	}
+}
+
+func TestRejoinOutlivesTheOldIncarnation(t *testing.T) {
+	peer := newMembership(Player{ID: 1, Addr: "n1:1"})
+	peer.add(Player{ID: 2, Addr: "n2:1"})
+	if effect := peer.apply(MemberUpdate{Player{ID: 2, Addr: "n2:1"}, MemberDead, 4}); effect != effectDrop {
+		t.Fatalf("effect = %v of player 2 dying", effect)
+	}
+
+	// Player 2 rejoins afresh, the node it connects to does not raise its incarnation for it
+	rejoined := newMembership(Player{ID: 2, Addr: "n2:2"})
+	host := newMembership(Player{ID: 3, Addr: "n3:1"})
+	host.add(Player{ID: 2, Addr: "n2:1"})
+	host.apply(MemberUpdate{Player{ID: 2, Addr: "n2:1"}, MemberDead, 4})
+	known := host.join(Player{ID: 2, Addr: "n2:2"}, rejoined.ownIncarnation())
+	if known != 4 {
+		t.Fatalf("join returned incarnation %d, want 4", known)
+	}
+
+	// It outlives its old incarnation itself, and the peer that saw it die takes it back
+	rejoined.outlive(known)
+	if incarnation := rejoined.ownIncarnation(); incarnation != 5 {
+		t.Fatalf("own incarnation = %d, want 5", incarnation)
+	}
+	var alive MemberUpdate
+	for _, u := range rejoined.gossip() {
+		if u.Player.ID == 2 {
+			alive = u
+		}
+	}
+	if effect := peer.apply(alive); effect != effectJoin {
+		t.Fatalf("effect = %v of the rejoined player", effect)
+	}
+	if player, _ := peer.lookup(2); player.Addr != "n2:2" {
+		t.Fatalf("the rejoined player is at %s", player.Addr)
+	}
+}
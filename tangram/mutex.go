@@ -0,0 +1,436 @@
+package tangram
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"../lamport"
+)
+
+// lockTimeout is how long a request for a tan waits for its replies before giving up
+const lockTimeout = 2 * time.Second
+
+// tanMutex holds the state of the Ricart-Agrawala mutual exclusion algorithm for every tan.
+// A node replies to a request right away unless it holds the tan or has an outstanding
+// request that precedes it, in which case the reply is deferred until the tan is released.
+// It is protected by Game.lock.
+// - requests: The lamport time of our outstanding request for each tan
+// - requesters: The member a relay requests each tan for, requests missing from it are our own
+// - deferred: The replies we deferred for each tan
+type tanMutex struct {
+	requests   map[TanID]lamport.Time
+	requesters map[TanID]PlayerID
+	deferred   map[TanID][]*deferredReply
+}
+
+// deferredReply is a reply to a LockTan request that is waiting for the tan to be released
+type deferredReply struct {
+	player PlayerID
+	time   lamport.Time
+	reply  chan bool
+}
+
+// UnlockTanRequest is request argument for Node.UnlockTan
+// - Player: The player whose hold on the tan is released
+// - From: The player sending the release, Player itself or its relay
+type UnlockTanRequest struct {
+	Tan    TanID
+	Player PlayerID
+	From   PlayerID
+	Time   lamport.Time
+	Vector lamport.VectorClock
+	Stamp  lamport.Timestamp
+}
+
+func newTanMutex() *tanMutex {
+	return &tanMutex{
+		requests:   make(map[TanID]lamport.Time),
+		requesters: make(map[TanID]PlayerID),
+		deferred:   make(map[TanID][]*deferredReply),
+	}
+}
+
+// requester returns the player our outstanding request for the tan is for
+func (m *tanMutex) requester(id TanID, me PlayerID) PlayerID {
+	if member, ok := m.requesters[id]; ok {
+		return member
+	}
+	return me
+}
+
+// acquireTan requests the tan from every peer and waits for all of them to reply
+// In a hosted game the only peer asked is the host, whose reply is definitive,
+// in a tiered game a member asks its relay and a relay the other relays
+// If any peer refuses or does not reply in time, the request is withdrawn
+func (game *Game) acquireTan(id TanID) (ok bool, err error) {
+	myID := game.GetPlayer().ID
+
+	game.lock.Lock()
+	tan := game.state.getTan(id)
+	if tan == nil {
+		err = &TanError{id, ReasonUnknownTan, NoPlayer}
+		game.lock.Unlock()
+		return
+	}
+
+	now := game.clock.Physical()
+	if tan.Player == myID && tan.held(now) {
+		game.lock.Unlock()
+		return true, nil
+	}
+
+	if tan.held(now) {
+		log.Printf("[ObtainTan] Obtaining TanID = %d failed. Already controlled by %d", id, tan.Player)
+		err = &TanError{id, ReasonHeld, tan.Player}
+		game.lock.Unlock()
+		return false, err
+	}
+
+	if _, requesting := game.mutex.requests[id]; requesting {
+		log.Printf("[ObtainTan] Obtaining TanID = %d failed. A request is already in progress", id)
+		game.lock.Unlock()
+		return false, &TanError{id, ReasonPending, NoPlayer}
+	}
+
+	time := tan.Clock.Send()
+	tan.Vector.Tick(myID)
+	vector := tan.Vector.Copy()
+
+	if game.hosted() && game.state.Host == myID {
+		// We are the arbiter, there is nobody else to ask
+		ok, err = game.arbitrate(tan, myID, time)
+		if !ok && err == nil {
+			game.lock.Lock()
+			err = game.refusal(tan)
+			game.lock.Unlock()
+		}
+		return
+	}
+
+	game.mutex.requests[id] = time
+	peers := game.lockPeers()
+	// The host may queue the request until the lease on the tan lapses
+	timeout := lockTimeout
+	if game.hosted() {
+		timeout = hostTimeout
+	}
+	game.lock.Unlock()
+
+	ok, seen := game.requestTan(id, myID, time, vector, peers, timeout)
+
+	game.lock.Lock()
+	delete(game.mutex.requests, id)
+	if ok {
+		// The hold comes after everything the peers saw of the tan, a late move of the previous holder included
+		tan.Clock.Receive(seen.Time)
+		tan.Vector.Merge(seen.Vector)
+		game.grantLease(tan, myID, time, game.clock.Physical())
+		game.announceHold(tan)
+	} else {
+		// Let waiting requests through, we are no longer competing with them
+		game.replyDeferred(tan)
+		err = game.refusal(tan)
+	}
+	game.notify()
+	game.lock.Unlock()
+
+	if !ok {
+		// Withdraw the request from peers that already granted it
+		game.broadcastUnlock(id, myID)
+	}
+	return
+}
+
+// announceHold tells the peers that this node obtained the tan
+// Peers show the player they last granted the tan to, which may have lost the race,
+// so the winner streams a move that leaves the tan where it is and peers take the mover as holder
+// Streaming never blocks, so this is safe under the lock
+// Must be called while holding Game.lock
+func (game *Game) announceHold(tan *Tan) {
+	myID := game.GetPlayer().ID
+	time := tan.Clock.Send()
+	tan.Vector.Tick(myID)
+	vector := tan.Vector.Copy()
+	for _, player := range game.interestingPlayers() {
+		if player.ID == myID {
+			continue
+		}
+		game.streamMove(player, MoveTanRequest{tan.ID, myID, myID, tan.Location, tan.Rotation, time, vector, tan.Expiry, game.clock.Now()})
+	}
+}
+
+// requestTan asks every peer for the tan on behalf of the player, and returns whether all of them granted it
+// along with the merged clocks of the tan at the peers that replied
+// A peer that does not reply within timeout refuses
+func (game *Game) requestTan(id TanID, player PlayerID, time lamport.Time, vector lamport.VectorClock, peers []*Player, timeout time.Duration) (ok bool, seen LockTanResponse) {
+	myID := game.GetPlayer().ID
+
+	// Ask everyone for the tan!
+	n := 0
+	resChan := make(chan LockTanResponse, len(peers))
+	for _, peer := range peers {
+		if peer.ID == myID {
+			continue
+		}
+
+		n++
+		client, err := game.pool.getConnection(peer)
+		if err != nil {
+			log.Println(err.Error())
+			resChan <- LockTanResponse{}
+			continue
+		}
+
+		go func(client Conn) {
+			var res LockTanResponse
+			req := LockTanRequest{id, player, myID, time, vector, game.clock.Now()}
+			err := callTimeout(client, "Node.LockTan", req, &res, timeout)
+			if err != nil {
+				log.Println(err.Error())
+				res.Ok = false
+			}
+			resChan <- res
+		}(client)
+	}
+	log.Printf("[ObtainTan] ID = %d. %d peer responses expected\n", id, n)
+
+	// We expect n confirmations
+	ok = true
+	for ; n > 0 && ok; n-- {
+		res := <-resChan
+		ok = res.Ok
+		if res.Time > seen.Time {
+			seen.Time = res.Time
+		}
+		seen.Vector.Merge(res.Vector)
+		log.Printf("[ObtainTan] ID = %d. Got response %t. %d more responses expected\n", id, ok, n-1)
+	}
+	return
+}
+
+// releaseTan gives up control of the tan and lets the deferred requests through
+// Only the tans of the players this node represents are released
+func (game *Game) releaseTan(id TanID) (ok bool, err error) {
+	game.lock.Lock()
+	tan := game.state.getTan(id)
+	if tan == nil {
+		err = &TanError{id, ReasonUnknownTan, NoPlayer}
+		game.lock.Unlock()
+		return
+	}
+	if tan.Player != NoPlayer && !game.represents(tan.Player) {
+		log.Printf("[releaseTan] Refusing to release tan ID = %d of %d", id, tan.Player)
+		err = game.refusal(tan)
+		game.lock.Unlock()
+		return
+	}
+
+	holder := tan.Player
+	game.revokeLease(tan)
+	game.replyDeferred(tan)
+	game.notify()
+	game.lock.Unlock()
+
+	if holder != NoPlayer {
+		game.broadcastUnlock(id, holder)
+	}
+	return true, nil
+}
+
+// broadcastUnlock tells every peer that holder no longer holds the tan
+// Close waits for the unlocks in flight before closing the connections they are sent on
+func (game *Game) broadcastUnlock(id TanID, holder PlayerID) {
+	game.lock.Lock()
+	select {
+	case <-game.done:
+		game.lock.Unlock()
+		return
+	default:
+	}
+	tan := game.state.getTan(id)
+	time := tan.Clock.Send()
+	tan.Vector.Tick(game.GetPlayer().ID)
+	vector := tan.Vector.Copy()
+	peers := game.lockPeers()
+	// Added under the lock, so that Close does not wait before every unlock started is counted
+	game.unlocks.Add(1)
+	game.lock.Unlock()
+	defer game.unlocks.Done()
+
+	for _, player := range peers {
+		if player.ID == game.GetPlayer().ID {
+			continue
+		}
+
+		client, err := game.pool.getConnection(player)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+
+		game.unlocks.Add(1)
+		go func(client Conn) {
+			defer game.unlocks.Done()
+			var ok bool
+			req := UnlockTanRequest{id, holder, game.GetPlayer().ID, time, vector, game.clock.Now()}
+			err := client.Call("Node.UnlockTan", req, &ok)
+			if err != nil {
+				log.Println(err.Error())
+			}
+		}(client)
+	}
+}
+
+// lockTan handles a request for a tan from another player
+// A relay answers for its members, it asks the other relays for the tan when a member requests it
+// It blocks while the reply is deferred
+func (game *Game) lockTan(tanID TanID, playerID PlayerID, reqTime lamport.Time, vector lamport.VectorClock) (ok bool, err error) {
+	game.lock.Lock()
+	tan := game.state.getTan(tanID)
+	if tan == nil {
+		err = fmt.Errorf("[lockTan] Requested tan ID = %d is not found", tanID)
+		game.lock.Unlock()
+		return
+	}
+
+	tan.Clock.Receive(reqTime)
+	tan.Vector.Merge(vector)
+
+	if game.hosted() {
+		return game.arbitrate(tan, playerID, reqTime)
+	}
+
+	myID := game.GetPlayer().ID
+	if playerID != myID && game.tiered() && game.relayOf(playerID) == myID {
+		return game.relayTan(tan, playerID, reqTime)
+	}
+
+	now := game.clock.Physical()
+	myTime, requesting := game.mutex.requests[tanID]
+	requester := game.mutex.requester(tanID, myID)
+	holding := game.represents(tan.Player) && tan.held(now)
+	if !holding && !(requesting && lamport.Compare(myTime, requester, reqTime, playerID) < 0) {
+		// Another player may hold the tan and defer the request, it keeps the tan until it says otherwise
+		if !tan.held(now) {
+			game.grantLease(tan, playerID, reqTime, now)
+		}
+		game.notify()
+		game.lock.Unlock()
+		return true, nil
+	}
+
+	log.Printf("[lockTan] Deferring reply to %d for tan ID = %d", playerID, tanID)
+	d := &deferredReply{playerID, reqTime, make(chan bool, 1)}
+	game.mutex.deferred[tanID] = append(game.mutex.deferred[tanID], d)
+	game.lock.Unlock()
+
+	ok = game.waitDeferred(tanID, d, lockTimeout)
+	return
+}
+
+// tanClocks returns the clocks of the tan, which a peer replying to LockTan sends along
+func (game *Game) tanClocks(id TanID) (time lamport.Time, vector lamport.VectorClock) {
+	game.lock.RLock()
+	defer game.lock.RUnlock()
+	if tan := game.state.getTan(id); tan != nil {
+		time, vector = tan.Clock.Time(), tan.Vector.Copy()
+	}
+	return
+}
+
+// waitDeferred blocks until the deferred reply is sent, or refuses it after timeout
+func (game *Game) waitDeferred(tanID TanID, d *deferredReply, timeout time.Duration) (ok bool) {
+	select {
+	case ok = <-d.reply:
+	case <-time.After(timeout):
+		game.lock.Lock()
+		game.dropDeferred(tanID, d.player)
+		game.lock.Unlock()
+		// The reply may have been sent while we were timing out
+		select {
+		case ok = <-d.reply:
+		default:
+		}
+	}
+	return
+}
+
+// unlockTan handles a release of the tan, or the withdrawal of a request for it
+// A hold is only released by the holder, or by its relay, who sends the release on its behalf
+// A relay lets the requests it deferred for its member through, and forwards the release to the other relays
+func (game *Game) unlockTan(tanID TanID, playerID PlayerID, from PlayerID, time lamport.Time, vector lamport.VectorClock) (ok bool, err error) {
+	game.lock.Lock()
+	defer game.lock.Unlock()
+	tan := game.state.getTan(tanID)
+	if tan == nil {
+		err = fmt.Errorf("[unlockTan] Requested tan ID = %d is not found", tanID)
+		return
+	}
+	if !game.speaksFor(from, playerID) {
+		log.Printf("[unlockTan] Refusing the release of tan ID = %d for %d by %d", tanID, playerID, from)
+		err = &TanError{tanID, ReasonHeld, tan.Player}
+		return
+	}
+
+	tan.Clock.Receive(time)
+	tan.Vector.Merge(vector)
+	game.dropDeferred(tanID, playerID)
+	relayed := playerID != game.GetPlayer().ID && game.tiered() && game.relayOf(playerID) == game.GetPlayer().ID
+	if tan.Player == playerID {
+		game.revokeLease(tan)
+		if game.hosted() {
+			// The host hands the tan to the next requester in line
+			game.grantQueued(tan)
+		} else if relayed {
+			game.replyDeferred(tan)
+		}
+	}
+	if relayed {
+		go game.broadcastUnlock(tanID, playerID)
+	}
+
+	game.notify()
+	return true, nil
+}
+
+// replyDeferred grants every deferred request for the tan
+// The earliest request is the one that will obtain the tan, so we show it as the holder
+// In a hosted game only the first queued request is granted
+// Must be called while holding Game.lock
+func (game *Game) replyDeferred(tan *Tan) {
+	if game.hosted() {
+		game.grantQueued(tan)
+		return
+	}
+
+	deferred := game.mutex.deferred[tan.ID]
+	delete(game.mutex.deferred, tan.ID)
+
+	// Only the earliest of them can obtain the tan, it announces itself if another one does
+	var first *deferredReply
+	for _, d := range deferred {
+		if first == nil || lamport.Compare(d.time, d.player, first.time, first.player) < 0 {
+			first = d
+		}
+	}
+	if now := game.clock.Physical(); first != nil && !tan.held(now) {
+		game.grantLease(tan, first.player, first.time, now)
+	}
+	for _, d := range deferred {
+		d.reply <- true
+	}
+}
+
+// dropDeferred refuses the deferred request of the player for the tan, if any
+// Must be called while holding Game.lock
+func (game *Game) dropDeferred(id TanID, player PlayerID) {
+	deferred := game.mutex.deferred[id]
+	for i, d := range deferred {
+		if d.player == player {
+			d.reply <- false
+			game.mutex.deferred[id] = append(deferred[:i], deferred[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package tangram
+
+import (
+	"io"
+	"net"
+	"net/rpc"
+	"strings"
+	"sync"
+)
+
+// Network is how a node reaches its peers
+// - Listen: Serves the RPC server at addr until the returned Closer is closed
+// - Dial: Connects to the RPC server of a peer at addr
+type Network interface {
+	Listen(addr string, server *rpc.Server) (io.Closer, error)
+	Dial(addr string) (*rpc.Client, error)
+}
+
+// TCPNetwork serves net/rpc over TCP, it is the network real games are played on
+var TCPNetwork Network = tcpNetwork{}
+
+type tcpNetwork struct{}
+
+func (tcpNetwork) Listen(addr string, server *rpc.Server) (listener io.Closer, err error) {
+	// Listen on every interface, addr may be the public address of this machine
+	port := strings.Split(addr, ":")[1]
+	resolvedAddr, err := net.ResolveTCPAddr("tcp", addr[len(addr)-len(port)-1:])
+	if err != nil {
+		return
+	}
+
+	inbound, err := net.ListenTCP("tcp", resolvedAddr)
+	if err != nil {
+		return
+	}
+
+	tcp := &tcpListener{inbound: inbound, conns: make(map[net.Conn]bool)}
+	go tcp.serve(server)
+	return tcp, nil
+}
+
+// tcpListener serves the connections it accepts until it is closed, which also closes them
+type tcpListener struct {
+	inbound *net.TCPListener
+	mutex   sync.Mutex
+	conns   map[net.Conn]bool
+	closed  bool
+}
+
+func (l *tcpListener) serve(server *rpc.Server) {
+	for {
+		conn, err := l.inbound.Accept()
+		if err != nil {
+			return
+		}
+
+		l.mutex.Lock()
+		if l.closed {
+			l.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		l.conns[conn] = true
+		l.mutex.Unlock()
+
+		go func() {
+			server.ServeConn(conn)
+			l.mutex.Lock()
+			delete(l.conns, conn)
+			l.mutex.Unlock()
+		}()
+	}
+}
+
+// Close stops accepting connections, and closes those being served
+func (l *tcpListener) Close() error {
+	l.mutex.Lock()
+	l.closed = true
+	conns := l.conns
+	l.conns = make(map[net.Conn]bool)
+	l.mutex.Unlock()
+
+	err := l.inbound.Close()
+	for conn := range conns {
+		conn.Close()
+	}
+	return err
+}
+
+func (tcpNetwork) Dial(addr string) (*rpc.Client, error) {
+	return rpc.Dial("tcp", addr)
+}
@@ -0,0 +1,40 @@
+package tangram
+
+import (
+	"net/rpc"
+	"testing"
+)
+
+func TestCloseStopsServing(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.Register(new(Node)); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := TCPNetwork.Listen("127.0.0.1:0", server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.(*tcpListener).inbound.Addr().String()
+
+	client, err := TCPNetwork.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var latency int64
+	if err := client.Call("Node.Missing", 0, &latency); err == nil {
+		t.Fatal("called a missing method")
+	} else if _, ok := err.(rpc.ServerError); !ok {
+		t.Fatalf("the connection is not served: %v", err)
+	}
+
+	listener.Close()
+	if err := client.Call("Node.Missing", 0, &latency); err == nil {
+		t.Fatal("called a closed listener")
+	} else if _, ok := err.(rpc.ServerError); ok {
+		t.Fatal("the connection is still served after the listener closed")
+	}
+	if _, err := TCPNetwork.Dial(addr); err == nil {
+		t.Fatal("dialed a closed listener")
+	}
+}
@@ -1,171 +1,360 @@
-package tangram
-
-import (
-	"fmt"
-	"log"
-	"math/rand"
-	"net"
-	"net/rpc"
-	"strings"
-	"time"
-
-	"../lamport"
-)
-
-// Node is the exposed RPC interface for a tangram node
-type Node struct {
-	game     *Game
-	player   *Player
-	listener net.Listener
-}
-
-// ConnectRequest is request argument for Node.Connect
-type ConnectRequest struct {
-	Player Player
-}
-
-// ConnectResponse is response argument for Node.Connect
-type ConnectResponse struct {
-	State  *GameState
-	Config *GameConfig
-	Player *Player
-}
-
-// LockTanRequest is request argument for Node.LockTan
-type LockTanRequest struct {
-	Tan    TanID
-	Player PlayerID
-	Time   lamport.Time
-}
-
-// MoveTanRequest is request argument for Node.MoveTan
-type MoveTanRequest struct {
-	Tan      TanID
-	Location Point
-	Rotation Rotation
-	Time     lamport.Time
-}
-
-// startNode instantiates the RPC server which will allow for communication between client nodes
-func startNode(addr string, playerID int) (node *Node, err error) {
-	port := strings.Split(addr, ":")[1]
-	resolvedAddr, err := net.ResolveTCPAddr("tcp", addr[len(addr)-len(port)-1:])
-	if err != nil {
-		return
-	}
-
-	inbound, err := net.ListenTCP("tcp", resolvedAddr)
-	if err != nil {
-		return
-	}
-
-	node = new(Node)
-	node.listener = inbound
-
-	node.player = newPlayer(addr, playerID)
-
-	server := rpc.NewServer()
-	server.Register(node)
-	go server.Accept(inbound)
-	log.Printf("Listening on %s as %d\n", addr, node.player.ID)
-	return
-}
-
-func newPlayer(addr string, id int) (player *Player) {
-	player = new(Player)
-
-	if id == 0 {
-		// Randomize if no id specified
-		player.ID = rand.Int()
-	} else {
-		player.ID = id
-	}
-
-	player.Addr = addr
-	return
-}
-
-// RPC
-
-// Connect connects to a node with the new player's information
-func (node *Node) Connect(req *ConnectRequest, res *ConnectResponse) (err error) {
-	for _, player := range node.game.state.Players {
-		if req.Player.ID == player.ID {
-			return fmt.Errorf("Player ID = %d is already in the game", player.ID)
-		}
-	}
-
-	log.Printf("[Connect] Connected by %d", req.Player.ID)
-	node.game.state.Players = append(node.game.state.Players, &req.Player)
-	node.game.notify()
-
-	*res = ConnectResponse{node.game.GetState(), node.game.GetConfig(), node.player}
-	return
-}
-
-// GetState returns the current game state
-func (node *Node) GetState(req int, res *GameState) (err error) {
-	*res = *node.game.GetState()
-	return
-}
-
-// GetTime returns the local timer
-func (node *Node) GetTime(req int, res *time.Duration) (err error) {
-	*res = node.game.GetTime()
-	return
-}
-
-// LockTan locks the tan according to request
-func (node *Node) LockTan(req LockTanRequest, ok *bool) (err error) {
-	log.Println("[Node.LockTan]")
-	*ok, err = node.game.lockTan(req.Tan, req.Player, req.Time)
-	return
-}
-
-// MoveTan moves the tan according to request
-func (node *Node) MoveTan(req MoveTanRequest, ok *bool) (err error) {
-	log.Println("[Node.Move]")
-	*ok, err = node.game.moveTan(req.Tan, req.Location, req.Rotation, req.Time)
-	return
-}
-
-// Ping simply confirms that the connection is good
-func (node *Node) Ping(incID PlayerID, ok *bool) (err error) {
-	//do something
-	*ok = true
-	return
-}
-
-// GetLatency retrieves the average latency from a remote node
-func (node *Node) GetLatency(req int, latency *time.Duration) (err error) {
-	*latency = node.game.GetAvgLatency()
-	return
-}
-
-// ConnectToMe broadcasts yourself as the new host and makes everyone
-// connect to you.
-func (node *Node) ConnectToMe(host PlayerID, ok *bool) (err error) {
-	log.Printf("[ConnectToMe] %d", host)
-	node.game.state.Host = host
-	// TODO
-	// We need to signal that election ended
-	// Error if we don't have an election?
-	return
-}
-
-// HostElection makes everyone with higher latency than you host
-// their own election.
-func (node *Node) HostElection(args int, ok *bool) (err error) {
-	node.game.Election()
-	*ok = true
-	return
-}
-
-func (node *Node) PushUpdate(update *GameState, ok *bool) (err error) {
-	node.game.lock.Lock()
-	node.game.witnessState(update)
-	node.game.lock.Unlock()
-	node.game.notify()
-	*ok = true
-	return
-}
+package tangram
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"time"
+
+	"../lamport"
+)
+
+// Node is the exposed RPC interface for a tangram node
+type Node struct {
+	game     *Game
+	player   *Player
+	token    string
+	listener io.Closer
+}
+
+// ConnectRequest is request argument for Node.Connect
+// - Token: The session token of the player, which lets it rejoin with the same ID
+// - Incarnation: The incarnation of the player in the membership protocol, see MemberUpdate
+type ConnectRequest struct {
+	Player      Player
+	Token       string
+	Stamp       lamport.Timestamp
+	Incarnation uint64
+}
+
+// ConnectResponse is response argument for Node.Connect
+// - Incarnation: The incarnation the node knows for the connecting player,
+// a player that rejoins raises its own above it, see membership.outlive
+type ConnectResponse struct {
+	State       *GameState
+	Config      *GameConfig
+	Player      *Player
+	Stamp       lamport.Timestamp
+	Incarnation uint64
+}
+
+// LockTanRequest is request argument for Node.LockTan
+// - Player: The player requesting the tan
+// - From: The player sending the request, Player itself or its relay
+type LockTanRequest struct {
+	Tan    TanID
+	Player PlayerID
+	From   PlayerID
+	Time   lamport.Time
+	Vector lamport.VectorClock
+	Stamp  lamport.Timestamp
+}
+
+// LockTanResponse is response argument for Node.LockTan
+// - Time, Vector: The clocks of the tan when the reply was sent, the requester merges them
+// so that its hold comes after the moves and the release of the player it obtained the tan from
+type LockTanResponse struct {
+	Ok     bool
+	Time   lamport.Time
+	Vector lamport.VectorClock
+}
+
+// MoveTanRequest is request argument for Node.MoveTan
+// - Player: The player moving the tan
+// - From: The player sending the move, Player itself or a relay forwarding it
+// - Expiry: The renewed expiry of the player's lease on the tan
+type MoveTanRequest struct {
+	Tan      TanID
+	Player   PlayerID
+	From     PlayerID
+	Location Point
+	Rotation Rotation
+	Time     lamport.Time
+	Vector   lamport.VectorClock
+	Expiry   time.Time
+	Stamp    lamport.Timestamp
+}
+
+// UpdateRequest is request argument for Node.PushUpdate
+// - Player: The host pushing the state
+// - Version: The version of the state, deltas following it are based on it
+type UpdateRequest struct {
+	Player  PlayerID
+	Version uint64
+	State   *GameState
+	Stamp   lamport.Timestamp
+}
+
+// PingRequest is request argument for Node.Ping
+type PingRequest struct {
+	Player PlayerID
+	Stamp  lamport.Timestamp
+}
+
+// PingResponse is response argument for Node.Ping
+type PingResponse struct {
+	Stamp lamport.Timestamp
+}
+
+// startNode instantiates the RPC server which will allow for communication between client nodes
+func startNode(transport Transport, addr string, playerID int, token string) (node *Node, err error) {
+	node = new(Node)
+	node.player = newPlayer(addr, playerID)
+	if token == "" {
+		token = newSessionToken()
+	}
+	node.token = token
+	node.player.Session = sessionHash(token)
+
+	node.listener, err = transport.Listen(addr, node)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Listening on %s as %d\n", addr, node.player.ID)
+	return
+}
+
+func newPlayer(addr string, id int) (player *Player) {
+	player = new(Player)
+
+	if id == 0 {
+		// Randomize if no id specified
+		player.ID = rand.Int()
+	} else {
+		player.ID = id
+	}
+
+	player.Addr = addr
+	return
+}
+
+// RPC
+
+// Connect connects to a node with the new player's information
+func (node *Node) Connect(req *ConnectRequest, res *ConnectResponse) (err error) {
+	// Only the token proves the identity, whatever the player claims
+	req.Player.Session = sessionHash(req.Token)
+
+	node.game.lock.Lock()
+	existing := node.game.state.getPlayer(req.Player.ID)
+	if existing != nil && (existing.Session == "" || existing.Session != req.Player.Session) {
+		node.game.lock.Unlock()
+		return fmt.Errorf("Player ID = %d is already in the game", existing.ID)
+	}
+
+	node.game.clock.Update(req.Stamp)
+	if existing == nil {
+		log.Printf("[Connect] Connected by %d", req.Player.ID)
+		node.game.state.Players = append(node.game.state.Players, &req.Player)
+	} else if existing.Addr != req.Player.Addr {
+		log.Printf("[Connect] Player %d rejoined from %s", req.Player.ID, req.Player.Addr)
+		node.game.replacePlayer(&req.Player)
+	}
+	incarnation := node.game.members.join(req.Player, req.Incarnation)
+	node.game.delta.touchPlayers()
+	node.game.notify()
+	node.game.lock.Unlock()
+
+	*res = ConnectResponse{node.game.GetState(), node.game.GetConfig(), node.player, node.game.clock.Now(), incarnation}
+	return
+}
+
+// GetState returns the current game state
+func (node *Node) GetState(req int, res *GameState) (err error) {
+	*res = *node.game.GetState()
+	return
+}
+
+// GetTime returns the local timer
+func (node *Node) GetTime(req int, res *time.Duration) (err error) {
+	*res = node.game.GetTime()
+	return
+}
+
+// LockTan locks the tan according to request
+// The reply is deferred while this node holds the tan or has an earlier request for it
+func (node *Node) LockTan(req LockTanRequest, res *LockTanResponse) (err error) {
+	log.Println("[Node.LockTan]")
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.From)
+	res.Ok, err = node.game.lockTan(req.Tan, req.Player, req.Time, req.Vector)
+	if err == nil {
+		res.Time, res.Vector = node.game.tanClocks(req.Tan)
+	}
+	return
+}
+
+// UnlockTan releases the tan, or withdraws a request for it
+func (node *Node) UnlockTan(req UnlockTanRequest, ok *bool) (err error) {
+	log.Println("[Node.UnlockTan]")
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.From)
+	*ok, err = node.game.unlockTan(req.Tan, req.Player, req.From, req.Time, req.Vector)
+	return
+}
+
+// MoveTan moves the tan according to request
+func (node *Node) MoveTan(req MoveTanRequest, ok *bool) (err error) {
+	log.Println("[Node.Move]")
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.From)
+	*ok, err = node.game.moveTan(req.Tan, req.Player, req.Location, req.Rotation, req.Time, req.Vector, req.Expiry)
+	return
+}
+
+// MoveTans moves tans in the order of the batch, each move is applied or refused on its own
+// Peers stream their moves through it, see moveStream
+func (node *Node) MoveTans(req MoveBatchRequest, res *MoveBatchResponse) (err error) {
+	log.Println("[Node.MoveTans]")
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	res.Moved = node.game.moveTans(req.Moves)
+	return
+}
+
+// Gossip is the direct probe of the membership protocol
+// Both ends exchange the membership updates they have to spread
+func (node *Node) Gossip(req GossipRequest, res *GossipResponse) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	node.game.applyUpdates(req.Updates)
+	node.game.links.merge(req.Links)
+	node.game.gossipRelays(req.Relays)
+	*res = GossipResponse{node.game.members.gossip(), node.game.links.gossip(), node.game.relayPlan(), node.game.clock.Now()}
+	return
+}
+
+// ProbeMember probes the target on behalf of a member that could not reach it
+func (node *Node) ProbeMember(req ProbeRequest, ok *bool) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	node.game.applyUpdates(req.Updates)
+
+	// A tiered node probes its tiered peers alone
+	target, known := node.game.members.lookup(req.Target)
+	if peers := node.game.swimPeers(); peers != nil && !peers[req.Target] {
+		known = false
+	}
+	*ok = known && node.game.probe(&target)
+	return
+}
+
+// Leave drops a player that is leaving the game
+// The membership protocol spreads the news to peers the player could not reach
+func (node *Node) Leave(req LeaveRequest, ok *bool) (err error) {
+	log.Printf("[Leave] Player %d left", req.Player.ID)
+	node.game.clock.Update(req.Stamp)
+	node.game.applyUpdates([]MemberUpdate{{req.Player, MemberLeft, req.Incarnation}})
+	*ok = true
+	return
+}
+
+// RequestVote asks the replica to vote for a candidate to lead the replicated log
+func (node *Node) RequestVote(req VoteRequest, res *VoteResponse) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	*res = node.game.raft.vote(req)
+	res.Stamp = node.game.clock.Now()
+	return
+}
+
+// AppendEntries stores the entries of the replicated log sent by the leader
+func (node *Node) AppendEntries(req AppendRequest, res *AppendResponse) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	*res = node.game.raft.appendEntries(req)
+	res.Stamp = node.game.clock.Now()
+	return
+}
+
+// Ping simply confirms that the connection is good
+// Both ends use it to keep their hybrid clocks synchronised
+func (node *Node) Ping(req PingRequest, res *PingResponse) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	res.Stamp = node.game.clock.Now()
+	return
+}
+
+// GetLatency retrieves the average latency from a remote node
+func (node *Node) GetLatency(req int, latency *time.Duration) (err error) {
+	*latency = node.game.GetAvgLatency()
+	return
+}
+
+// Election is the election message of a candidate in a host election
+// The node answers if it is a better candidate than the sender, and holds its own election in turn
+func (node *Node) Election(req ElectionRequest, res *ElectionResponse) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	// No Bully election is held in a game with replicas, see Game.Election
+	res.Answer = !node.game.raft.replicated() && node.game.electionPolicy().Better(node.game.GetCandidate(), req.Candidate)
+	if res.Answer {
+		go node.game.joinElection(req.Term)
+	}
+	res.Stamp = node.game.clock.Now()
+	return
+}
+
+// Coordinator announces the winner of a host election, which terminates it
+// ok is false if the announcement is from an election that was given up on
+func (node *Node) Coordinator(req CoordinatorRequest, ok *bool) (err error) {
+	log.Printf("[Coordinator] %d, term = %d", req.Player, req.Term)
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	*ok = node.game.acceptCoordinator(req.Player, req.Term)
+	return
+}
+
+// GetCandidate retrieves what a remote node brings to a host election
+func (node *Node) GetCandidate(req int, candidate *Candidate) (err error) {
+	*candidate = node.game.GetCandidate()
+	return
+}
+
+// ConnectToMe broadcasts yourself as the new host and makes everyone
+// connect to you.
+// It is the coordinator message of peers that do not know about terms, see Node.Coordinator
+func (node *Node) ConnectToMe(host PlayerID, ok *bool) (err error) {
+	log.Printf("[ConnectToMe] %d", host)
+	*ok = node.game.acceptCoordinator(host, node.game.election.current())
+	return
+}
+
+// HostElection makes everyone with higher latency than you host
+// their own election.
+func (node *Node) HostElection(args int, ok *bool) (err error) {
+	go node.game.Election()
+	*ok = true
+	return
+}
+
+// PushUpdate witnesses the full state pushed by the host
+func (node *Node) PushUpdate(req UpdateRequest, ok *bool) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	node.game.lock.Lock()
+	node.game.witnessState(req.State)
+	node.game.delta.resync(req.Player, req.Version)
+	node.game.notify()
+	node.game.lock.Unlock()
+	*ok = true
+	return
+}
+
+// PushDelta witnesses the changes pushed by the host
+// ok is false if a previous delta was missed, in which case the host pushes the full state
+func (node *Node) PushDelta(req DeltaRequest, ok *bool) (err error) {
+	node.game.clock.Update(req.Stamp)
+	node.game.detector.heartbeat(req.Player)
+	node.game.lock.Lock()
+	apply, synced := node.game.delta.witness(req.Player, req.Base, req.Version)
+	if apply {
+		node.game.witnessState(req.Delta)
+		node.game.notify()
+	}
+	node.game.lock.Unlock()
+	*ok = synced
+	return
+}
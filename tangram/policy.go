@@ -0,0 +1,151 @@
+package tangram
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Host policies that can be selected with GameConfig.HostPolicy
+const (
+	// PolicyLatency makes the candidate with the lowest average latency to its peers host
+	PolicyLatency = "latency"
+	// PolicyUptime makes the candidate that has been in the game the longest host
+	PolicyUptime = "uptime"
+	// PolicyCapacity makes the candidate with the most spare bandwidth host
+	PolicyCapacity = "capacity"
+)
+
+// Candidate is what a node brings to a host election
+// - Latency: The average round trip time of the node to its peers in the latency matrix, frozen during an election
+// - Uptime: How long the node has been in the game
+// - Capacity: The spare bandwidth of the node in kbit/s, see Game.SetCapacity
+type Candidate struct {
+	Player   PlayerID
+	Latency  time.Duration
+	Uptime   time.Duration
+	Capacity int64
+}
+
+// ElectionPolicy ranks the candidates of a host election
+// Every node of a game must use the same policy, or they may disagree on the winner
+type ElectionPolicy interface {
+	// Better returns whether a should be host rather than b
+	// It must be a strict order, ties are usually broken by PlayerID
+	Better(a Candidate, b Candidate) bool
+}
+
+// LatencyPolicy prefers the candidate with the lowest average latency in the latency matrix
+type LatencyPolicy struct{}
+
+// Better implements ElectionPolicy
+func (LatencyPolicy) Better(a Candidate, b Candidate) bool {
+	return less(a.Player, a.Latency, b.Player, b.Latency)
+}
+
+// UptimePolicy prefers the candidate that has been in the game the longest
+// Uptimes are compared to the second, nodes that joined at about the same time are ranked by latency
+type UptimePolicy struct{}
+
+// Better implements ElectionPolicy
+func (UptimePolicy) Better(a Candidate, b Candidate) bool {
+	ua, ub := a.Uptime/time.Second, b.Uptime/time.Second
+	if ua != ub {
+		return ua > ub
+	}
+	return LatencyPolicy{}.Better(a, b)
+}
+
+// CapacityPolicy prefers the candidate with the most spare bandwidth
+type CapacityPolicy struct{}
+
+// Better implements ElectionPolicy
+func (CapacityPolicy) Better(a Candidate, b Candidate) bool {
+	if a.Capacity != b.Capacity {
+		return a.Capacity > b.Capacity
+	}
+	return LatencyPolicy{}.Better(a, b)
+}
+
+// PinnedPolicy prefers the pinned hosts, in order, whenever they are candidates
+// Candidates that are not pinned are ranked by Fallback
+type PinnedPolicy struct {
+	Hosts    []PlayerID
+	Fallback ElectionPolicy
+}
+
+// Better implements ElectionPolicy
+func (p PinnedPolicy) Better(a Candidate, b Candidate) bool {
+	ra, rb := p.rank(a.Player), p.rank(b.Player)
+	if ra != rb {
+		return ra < rb
+	}
+	return p.Fallback.Better(a, b)
+}
+
+// rank returns the position of the player among the pinned hosts, after all of them if it is not pinned
+func (p PinnedPolicy) rank(id PlayerID) int {
+	for i, host := range p.Hosts {
+		if host == id {
+			return i
+		}
+	}
+	return len(p.Hosts)
+}
+
+// NewElectionPolicy returns the policy selected by the config
+// An unknown HostPolicy falls back to PolicyLatency
+func NewElectionPolicy(config *GameConfig) (policy ElectionPolicy) {
+	policy = LatencyPolicy{}
+	if config == nil {
+		return
+	}
+
+	switch config.HostPolicy {
+	case "", PolicyLatency:
+	case PolicyUptime:
+		policy = UptimePolicy{}
+	case PolicyCapacity:
+		policy = CapacityPolicy{}
+	default:
+		log.Printf("[NewElectionPolicy] Unknown host policy %q, ranking by latency", config.HostPolicy)
+	}
+
+	if len(config.PinnedHosts) > 0 {
+		policy = PinnedPolicy{config.PinnedHosts, policy}
+	}
+	return
+}
+
+// SetElectionPolicy replaces the policy selected by the config
+// Every node of the game must set the same policy
+func (game *Game) SetElectionPolicy(policy ElectionPolicy) {
+	game.lock.Lock()
+	game.policy = policy
+	game.lock.Unlock()
+}
+
+// electionPolicy returns the policy ranking candidates in host elections
+func (game *Game) electionPolicy() ElectionPolicy {
+	game.lock.RLock()
+	defer game.lock.RUnlock()
+	if game.policy == nil {
+		return NewElectionPolicy(game.config)
+	}
+	return game.policy
+}
+
+// SetCapacity sets the spare bandwidth this node advertises in host elections, in kbit/s
+func (game *Game) SetCapacity(kbps int64) {
+	atomic.StoreInt64(&game.capacity, kbps)
+}
+
+// GetCandidate returns what this node brings to a host election
+func (game *Game) GetCandidate() Candidate {
+	return Candidate{
+		Player:   game.GetPlayer().ID,
+		Latency:  game.GetAvgLatency(),
+		Uptime:   time.Since(game.joined),
+		Capacity: atomic.LoadInt64(&game.capacity),
+	}
+}
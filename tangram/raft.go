@@ -0,0 +1,759 @@
+package tangram
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"../lamport"
+)
+
+// raftInterval is how often the replicas check their timers and apply newly committed entries
+const raftInterval = 50 * time.Millisecond
+
+// raftHeartbeat is how often the leader sends entries to each replica, even if there are none
+const raftHeartbeat = 250 * time.Millisecond
+
+// raftElectionTimeout is the least silence from the leader after which a replica stands for election
+// The actual timeout is randomized up to twice as long, so that replicas rarely stand at the same time
+const raftElectionTimeout = 1500 * time.Millisecond
+
+// raftBatch is the most entries sent to a replica at once
+const raftBatch = 64
+
+// raftCompaction is how many applied entries the log keeps before they are compacted into the snapshot
+const raftCompaction = 1024
+
+type raftRole int
+
+const (
+	raftFollower raftRole = iota
+	raftCandidate
+	raftLeader
+)
+
+// LogEntry is an entry of the replicated log
+// - Term: The raft term of the leader that appended it
+// - Event: The operation, the zero Event is the no-op a new leader appends to commit the entries before it
+type LogEntry struct {
+	Term  uint64
+	Event Event
+}
+
+// VoteRequest is request argument for Node.RequestVote
+// - Player: The replica standing for election
+// - LastIndex, LastTerm: The last entry of its log, it only gets the vote of replicas whose log is not more up to date
+type VoteRequest struct {
+	Player    PlayerID
+	Term      uint64
+	LastIndex uint64
+	LastTerm  uint64
+	Stamp     lamport.Timestamp
+}
+
+// VoteResponse is response argument for Node.RequestVote
+type VoteResponse struct {
+	Term    uint64
+	Granted bool
+	Stamp   lamport.Timestamp
+}
+
+// RaftSnapshot is the compacted prefix of the replicated log
+// - Index, Term: The last entry compacted
+// - Events: The fewest events that fold into the same tans as the compacted entries, see summarize
+type RaftSnapshot struct {
+	Index  uint64
+	Term   uint64
+	Events []Event
+}
+
+// AppendRequest is request argument for Node.AppendEntries
+// - Player: The leader
+// - PrevIndex, PrevTerm: The entry preceding Entries, which the replica must hold to accept them
+// - Commit: The index of the last entry the leader knows to be committed
+// - Snapshot: Sent to a replica that misses entries the leader compacted, Entries then follow it
+type AppendRequest struct {
+	Player    PlayerID
+	Term      uint64
+	PrevIndex uint64
+	PrevTerm  uint64
+	Entries   []LogEntry
+	Commit    uint64
+	Snapshot  *RaftSnapshot
+	Stamp     lamport.Timestamp
+}
+
+// AppendResponse is response argument for Node.AppendEntries
+// - Match: The last index known to match the log of the leader, which sends the entries after it
+type AppendResponse struct {
+	Term    uint64
+	Success bool
+	Match   uint64
+	Stamp   lamport.Timestamp
+}
+
+// raft replicates the events of a hosted game among the replicas of GameConfig with the Raft consensus algorithm.
+// The leader hosts the game and appends every event it records to the log. An event is committed
+// once a majority of the replicas stored it, and only a replica holding every committed event can be
+// elected, so a change of host never loses or forks committed tan moves.
+// No player of a replicated game holds a Bully election, only the leader claims host.
+// A replica persists its term, vote and log before it replies or counts itself in a majority,
+// so it keeps its promises when it restarts, and catches up on what it missed from the leader.
+// Applied entries are compacted into a snapshot once there are more than raftCompaction of them.
+// - config: The config of the game, the snapshot is folded over its tans
+// - replicas: Every replica, this one included
+// - hasReplicas: Whether the game has replicas, this node may not be one of them
+// - enabled: Whether this node is a replica
+// - log: The entries after the snapshot, log[0] stands for the last entry of the snapshot, see entry
+// - snapshot: The compacted prefix of the log
+// - install: Whether the snapshot has yet to be folded into the game state
+// - storage: Where the term, vote and log are persisted, nil keeps them in memory
+// - started: When the game started, it tells the state persisted for it from that of other games
+// - dirty, stored: Whether the state changed since it was persisted, and the last index persisted
+// - commit: The index of the last entry known to be committed
+// - applied: The index of the last entry folded into the game state
+// - next, match: For the leader, the next entry to send to each replica and the last one it is known to hold
+// - deadline: When this replica stands for election unless it hears from a leader
+type raft struct {
+	mutex       sync.Mutex
+	me          PlayerID
+	config      *GameConfig
+	replicas    []PlayerID
+	hasReplicas bool
+	enabled     bool
+	role        raftRole
+	term        uint64
+	votedFor    PlayerID
+	leader      PlayerID
+	log         []LogEntry
+	snapshot    RaftSnapshot
+	install     bool
+	storage     raftStorage
+	started     time.Time
+	dirty       bool
+	stored      uint64
+	commit      uint64
+	applied     uint64
+	next        map[PlayerID]uint64
+	match       map[PlayerID]uint64
+	inflight    map[PlayerID]bool
+	deadline    time.Time
+	sent        time.Time
+}
+
+func newRaft(me PlayerID) *raft {
+	return &raft{
+		me:       me,
+		votedFor: NoPlayer,
+		leader:   NoPlayer,
+		log:      make([]LogEntry, 1),
+		next:     make(map[PlayerID]uint64),
+		match:    make(map[PlayerID]uint64),
+		inflight: make(map[PlayerID]bool),
+	}
+}
+
+// configure enables replication if the game is hosted and this node is one of its replicas
+// A replica restores the state it persisted in GameConfig.RaftDir for the game that started at started, if any
+func (r *raft) configure(config *GameConfig, started time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.config = config
+	r.replicas = append([]PlayerID(nil), config.Replicas...)
+	r.hasReplicas = config.Host && len(r.replicas) > 0
+	r.enabled = false
+	for _, id := range r.replicas {
+		if id == r.me {
+			r.enabled = config.Host
+		}
+	}
+	if r.enabled && config.RaftDir != "" {
+		r.started = started
+		r.storage = fileStorage{filepath.Join(config.RaftDir, fmt.Sprintf("raft-%d.gob", r.me))}
+		r.restore()
+	}
+	r.resetDeadline()
+}
+
+// restore loads the state persisted by the replica before it restarted
+func (r *raft) restore() {
+	state, ok, err := r.storage.load()
+	if err != nil {
+		log.Printf("[raft] Could not restore the persisted state: %s", err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+	if !state.Game.Equal(r.started) {
+		log.Printf("[raft] Ignoring the state persisted for the game that started at %v", state.Game)
+		return
+	}
+	log.Printf("[raft] Restoring term = %d, last index = %d", state.Term, state.Snapshot.Index+uint64(len(state.Log)-1))
+	r.term = state.Term
+	r.votedFor = state.VotedFor
+	r.snapshot = state.Snapshot
+	r.log = state.Log
+	r.commit = r.snapshot.Index
+	r.install = r.snapshot.Index > 0
+	r.stored = r.lastIndex()
+}
+
+// persist saves the term, vote and log if they changed since they were last saved
+// A replica that could not save them must not vote, acknowledge entries, nor count itself for them
+func (r *raft) persist() error {
+	if !r.dirty {
+		return nil
+	}
+	if r.storage != nil {
+		err := r.storage.save(raftState{r.started, r.term, r.votedFor, r.snapshot, r.log})
+		if err != nil {
+			log.Printf("[raft] Could not persist the state: %s", err.Error())
+			return err
+		}
+	}
+	r.dirty = false
+	r.stored = r.lastIndex()
+	return nil
+}
+
+// replicated returns whether the game has replicas, in which case the raft leader is the host
+// and no Bully election is held, whether or not this node is one of the replicas
+func (r *raft) replicated() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.hasReplicas
+}
+
+// leading returns whether this replica is the raft leader
+func (r *raft) leading() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.enabled && r.role == raftLeader
+}
+
+// propose appends the event to the log if this replica is the leader
+// It is persisted before the leader counts itself as holding it, see stepRaft
+// Must be called while holding Game.lock
+func (r *raft) propose(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.enabled || r.role != raftLeader {
+		return
+	}
+	r.log = append(r.log, LogEntry{r.term, event})
+	r.dirty = true
+}
+
+func (r *raft) resetDeadline() {
+	timeout := raftElectionTimeout + time.Duration(rand.Int63n(int64(raftElectionTimeout)))
+	r.deadline = time.Now().Add(timeout)
+}
+
+// entry returns the entry at index, which must be the last compacted one or later
+func (r *raft) entry(index uint64) LogEntry {
+	return r.log[index-r.snapshot.Index]
+}
+
+func (r *raft) lastIndex() uint64 {
+	return r.snapshot.Index + uint64(len(r.log)-1)
+}
+
+func (r *raft) lastTerm() uint64 {
+	return r.log[len(r.log)-1].Term
+}
+
+// peers returns the other replicas
+func (r *raft) peers() (peers []PlayerID) {
+	for _, id := range r.replicas {
+		if id != r.me {
+			peers = append(peers, id)
+		}
+	}
+	return
+}
+
+// follow moves on to term as a follower, if it is later than the current one
+func (r *raft) follow(term uint64) {
+	if term <= r.term {
+		return
+	}
+	r.term = term
+	r.role = raftFollower
+	r.votedFor = NoPlayer
+	r.dirty = true
+}
+
+// stand makes this replica a candidate in the next term and returns its vote request
+// won is true if this replica is the only one, and so the leader right away
+func (r *raft) stand() (req VoteRequest, won bool) {
+	r.term++
+	r.role = raftCandidate
+	r.votedFor = r.me
+	r.leader = NoPlayer
+	r.dirty = true
+	r.resetDeadline()
+	log.Printf("[raft] Standing for election, term = %d", r.term)
+
+	req = VoteRequest{r.me, r.term, r.lastIndex(), r.lastTerm(), lamport.Timestamp{}}
+	if len(r.replicas)/2+1 <= 1 {
+		r.lead()
+		return req, true
+	}
+	return req, false
+}
+
+// lead makes this replica the leader of the current term
+func (r *raft) lead() {
+	log.Printf("[raft] Leading, term = %d", r.term)
+	r.role = raftLeader
+	r.leader = r.me
+	for _, id := range r.peers() {
+		r.next[id] = r.lastIndex() + 1
+		r.match[id] = 0
+	}
+	// Entries of earlier terms are only committed along with one of the current term
+	r.log = append(r.log, LogEntry{r.term, Event{}})
+	r.dirty = true
+	r.sent = time.Time{}
+	r.advanceCommit()
+}
+
+// vote decides whether to vote for the candidate
+// The vote is persisted before it is granted
+func (r *raft) vote(req VoteRequest) (res VoteResponse) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	defer func() {
+		if r.persist() != nil {
+			res.Granted = false
+		}
+	}()
+
+	if !r.enabled {
+		return VoteResponse{Term: req.Term}
+	}
+	r.follow(req.Term)
+
+	upToDate := req.LastTerm > r.lastTerm() || (req.LastTerm == r.lastTerm() && req.LastIndex >= r.lastIndex())
+	if req.Term == r.term && (r.votedFor == NoPlayer || r.votedFor == req.Player) && upToDate {
+		r.votedFor = req.Player
+		r.dirty = true
+		r.resetDeadline()
+		res.Granted = true
+	}
+	res.Term = r.term
+	return
+}
+
+// tally counts the vote of a replica, and returns true if it made this replica the leader
+func (r *raft) tally(term uint64, res VoteResponse, votes *int) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.follow(res.Term)
+	if r.role != raftCandidate || r.term != term || !res.Granted {
+		return false
+	}
+	*votes++
+	if *votes < len(r.replicas)/2+1 {
+		return false
+	}
+	r.lead()
+	return true
+}
+
+// appendEntries stores the entries sent by the leader
+// They are persisted before they are acknowledged
+func (r *raft) appendEntries(req AppendRequest) (res AppendResponse) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	defer func() {
+		if r.persist() != nil {
+			res.Success = false
+			res.Match = r.commit
+		}
+	}()
+
+	res.Term = r.term
+	if !r.enabled || req.Term < r.term {
+		return
+	}
+	r.follow(req.Term)
+	r.role = raftFollower
+	r.leader = req.Player
+	r.resetDeadline()
+	res.Term = r.term
+
+	if req.Snapshot != nil && req.Snapshot.Index > r.snapshot.Index {
+		r.installSnapshot(*req.Snapshot)
+	}
+
+	prevIndex, prevTerm, entries := req.PrevIndex, req.PrevTerm, req.Entries
+	if prevIndex < r.snapshot.Index {
+		// Compacted entries were committed, so they match those of the leader
+		skip := r.snapshot.Index - prevIndex
+		if skip > uint64(len(entries)) {
+			skip = uint64(len(entries))
+		}
+		prevIndex, prevTerm, entries = r.snapshot.Index, r.snapshot.Term, entries[skip:]
+	}
+
+	if prevIndex > r.lastIndex() || r.entry(prevIndex).Term != prevTerm {
+		// Let the leader back up to an entry both logs may share
+		res.Match = r.lastIndex()
+		if prevIndex <= res.Match {
+			res.Match = prevIndex - 1
+		}
+		return
+	}
+
+	for i, entry := range entries {
+		index := prevIndex + uint64(i) + 1
+		if index <= r.lastIndex() && r.entry(index).Term != entry.Term {
+			// Entries that conflict with the leader were never committed
+			r.log = r.log[:index-r.snapshot.Index]
+		}
+		if index > r.lastIndex() {
+			r.log = append(r.log, entry)
+			r.dirty = true
+		}
+	}
+
+	res.Success = true
+	res.Match = req.PrevIndex + uint64(len(req.Entries))
+	if req.Commit > r.commit {
+		r.commit = req.Commit
+		if r.commit > res.Match {
+			r.commit = res.Match
+		}
+	}
+	return
+}
+
+// nextAppend returns the entries to send to the replica, ok is false if they need not be sent now
+func (r *raft) nextAppend(id PlayerID) (req AppendRequest, ok bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.role != raftLeader || r.inflight[id] {
+		return req, false
+	}
+	next := r.next[id]
+	if next < 1 {
+		next = 1
+	}
+	req = AppendRequest{Player: r.me, Term: r.term, Commit: r.commit}
+	if next <= r.snapshot.Index {
+		// The replica misses entries that were compacted
+		snapshot := r.snapshot
+		req.Snapshot = &snapshot
+		next = r.snapshot.Index + 1
+	}
+	last := r.lastIndex()
+	if last-next+1 > raftBatch {
+		last = next + raftBatch - 1
+	}
+	req.PrevIndex = next - 1
+	req.PrevTerm = r.entry(next - 1).Term
+	req.Entries = append([]LogEntry(nil), r.log[next-r.snapshot.Index:last-r.snapshot.Index+1]...)
+	r.inflight[id] = true
+	return req, true
+}
+
+// acknowledge records the response of the replica to the entries sent to it
+func (r *raft) acknowledge(id PlayerID, req AppendRequest, res AppendResponse, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.inflight[id] = false
+	if err != nil {
+		return
+	}
+	r.follow(res.Term)
+	if r.role != raftLeader || r.term != req.Term {
+		return
+	}
+	if res.Success {
+		if res.Match > r.match[id] {
+			r.match[id] = res.Match
+		}
+		r.next[id] = r.match[id] + 1
+		r.advanceCommit()
+		return
+	}
+	r.next[id] = res.Match + 1
+}
+
+// advanceCommit commits the last entry of the current term that a majority of the replicas hold
+// The leader only counts itself for the entries it persisted
+func (r *raft) advanceCommit() {
+	for index := r.lastIndex(); index > r.commit; index-- {
+		if r.entry(index).Term != r.term {
+			return
+		}
+		count := 0
+		if index <= r.stored {
+			count++
+		}
+		for _, id := range r.peers() {
+			if r.match[id] >= index {
+				count++
+			}
+		}
+		if count >= len(r.replicas)/2+1 {
+			r.commit = index
+			return
+		}
+	}
+}
+
+// takeCommitted returns the committed entries that were not applied yet, the events of an installed snapshot first
+// The leader leaves out the entries of its own term, which it recorded itself
+// Applied entries are compacted once there are enough of them
+func (r *raft) takeCommitted() (entries []LogEntry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.install {
+		r.install = false
+		r.applied = r.snapshot.Index
+		for _, event := range r.snapshot.Events {
+			entries = append(entries, LogEntry{r.snapshot.Term, event})
+		}
+	}
+	for ; r.applied < r.commit; r.applied++ {
+		entry := r.entry(r.applied + 1)
+		if r.role == raftLeader && entry.Term == r.term {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	r.compact()
+	return
+}
+
+// installSnapshot replaces the log up to the snapshot sent by the leader
+// The entries after it are kept if the log holds the last entry of the snapshot
+func (r *raft) installSnapshot(snapshot RaftSnapshot) {
+	log.Printf("[raft] Installing snapshot, index = %d", snapshot.Index)
+	if snapshot.Index <= r.lastIndex() && r.entry(snapshot.Index).Term == snapshot.Term {
+		r.log = append([]LogEntry{{Term: snapshot.Term}}, r.log[snapshot.Index-r.snapshot.Index+1:]...)
+	} else {
+		r.log = []LogEntry{{Term: snapshot.Term}}
+	}
+	r.snapshot = snapshot
+	if r.commit < snapshot.Index {
+		r.commit = snapshot.Index
+	}
+	if r.applied < snapshot.Index {
+		r.install = true
+	}
+	r.dirty = true
+}
+
+// compact folds the applied entries into the snapshot once there are more than raftCompaction of them
+func (r *raft) compact() {
+	if r.install || r.applied < r.snapshot.Index+raftCompaction {
+		return
+	}
+
+	events := append([]Event(nil), r.snapshot.Events...)
+	for _, entry := range r.log[1 : r.applied-r.snapshot.Index+1] {
+		if entry.Event.Kind != "" {
+			events = append(events, entry.Event)
+		}
+	}
+	term := r.entry(r.applied).Term
+	r.log = append([]LogEntry{{Term: term}}, r.log[r.applied-r.snapshot.Index+1:]...)
+	r.snapshot = RaftSnapshot{r.applied, term, summarize(r.config, events)}
+	r.dirty = true
+}
+
+// committed returns the events of the committed entries, those compacted are summarized by the snapshot
+func (r *raft) committed() (events []Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	events = append(events, r.snapshot.Events...)
+	for _, entry := range r.log[1 : r.commit-r.snapshot.Index+1] {
+		if entry.Event.Kind != "" {
+			events = append(events, entry.Event)
+		}
+	}
+	return
+}
+
+// raftLoop runs the timers of the replica until the game is closed
+func (game *Game) raftLoop() {
+	for game.sleep(raftInterval) {
+		game.stepRaft()
+	}
+}
+
+// stepRaft stands for election once the leader has been silent for too long, sends
+// the leader's entries to the other replicas, and applies newly committed entries
+func (game *Game) stepRaft() {
+	r := game.raft
+	r.mutex.Lock()
+	if !r.enabled {
+		r.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	peers := r.peers()
+	if r.persist() != nil {
+		r.mutex.Unlock()
+		return
+	}
+	if r.role == raftLeader {
+		// The leader counts itself for the entries it just persisted
+		r.advanceCommit()
+	}
+	switch {
+	case r.role == raftLeader:
+		if now.Sub(r.sent) >= raftHeartbeat || r.lastIndex() >= r.commit+1 {
+			r.sent = now
+			r.mutex.Unlock()
+			for _, id := range peers {
+				go game.replicate(id)
+			}
+		} else {
+			r.mutex.Unlock()
+		}
+	case now.After(r.deadline):
+		req, won := r.stand()
+		if r.persist() != nil {
+			// Votes must not be asked for a term this replica may forget
+			r.mutex.Unlock()
+			return
+		}
+		r.mutex.Unlock()
+		if won {
+			go game.claimHost()
+		} else {
+			game.requestVotes(peers, req)
+		}
+	default:
+		r.mutex.Unlock()
+	}
+
+	if entries := r.takeCommitted(); len(entries) > 0 {
+		game.applyCommitted(entries)
+	}
+}
+
+// requestVotes asks every other replica for its vote, and claims host once a majority voted for this replica
+func (game *Game) requestVotes(peers []PlayerID, req VoteRequest) {
+	var mutex sync.Mutex
+	votes := 1
+	for _, id := range peers {
+		game.lock.RLock()
+		player := game.state.getPlayer(id)
+		game.lock.RUnlock()
+		if player == nil {
+			continue
+		}
+
+		client, err := game.pool.getConnection(player)
+		if err != nil {
+			continue
+		}
+
+		go func(client Conn, req VoteRequest) {
+			var res VoteResponse
+			req.Stamp = game.clock.Now()
+			err := callTimeout(client, "Node.RequestVote", req, &res, raftElectionTimeout)
+			if err != nil {
+				return
+			}
+			game.clock.Update(res.Stamp)
+
+			mutex.Lock()
+			won := game.raft.tally(req.Term, res, &votes)
+			mutex.Unlock()
+			if won {
+				game.claimHost()
+			}
+		}(client, req)
+	}
+}
+
+// replicate sends the entries the replica is missing
+func (game *Game) replicate(id PlayerID) {
+	game.lock.RLock()
+	player := game.state.getPlayer(id)
+	game.lock.RUnlock()
+	if player == nil {
+		return
+	}
+
+	req, ok := game.raft.nextAppend(id)
+	if !ok {
+		return
+	}
+
+	var res AppendResponse
+	client, err := game.pool.getConnection(player)
+	if err == nil {
+		req.Stamp = game.clock.Now()
+		err = callTimeout(client, "Node.AppendEntries", req, &res, raftElectionTimeout)
+	}
+	if err == nil {
+		game.clock.Update(res.Stamp)
+	}
+	game.raft.acknowledge(id, req, res, err)
+}
+
+// claimHost makes the leader host, with a term later than any host election seen
+// Only the leader claims host, see declareHost
+func (game *Game) claimHost() {
+	game.declareHost(game.election.current() + 1)
+}
+
+// applyCommitted folds committed entries into the game state, for the tans whose state is behind the log
+// A tan whose clock is ahead of its last committed event has witnessed later operations, which are kept
+func (game *Game) applyCommitted(entries []LogEntry) {
+	game.lock.Lock()
+	defer game.lock.Unlock()
+
+	latest := make(map[TanID]lamport.Time)
+	for _, entry := range entries {
+		if entry.Event.Kind != "" {
+			latest[entry.Event.Tan] = entry.Event.Time
+		}
+	}
+
+	behind := make(map[TanID]bool)
+	for id, time := range latest {
+		tan := game.state.getTan(id)
+		if tan != nil && time >= tan.Clock.Time() {
+			behind[id] = true
+		}
+	}
+	if len(behind) == 0 {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Event.Kind != "" && behind[entry.Event.Tan] {
+			game.fold(entry.Event)
+		}
+	}
+	for id := range behind {
+		game.state.getTan(id).Clock.Receive(latest[id])
+	}
+	checkSolution(game.config, game.state)
+	game.notify()
+}
+
+// CommittedEvents returns the events replicated to a majority of the replicas, in order
+// It is empty unless the game has replicas, see GameConfig
+func (game *Game) CommittedEvents() []Event {
+	return game.raft.committed()
+}
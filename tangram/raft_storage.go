@@ -0,0 +1,74 @@
+package tangram
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// raftState is what a replica must not forget when it restarts
+// - Game: When the game started, the state of another game is not restored
+// - Term, VotedFor: So that it never votes twice in a term
+// - Snapshot, Log: So that the entries it acknowledged stay committed
+type raftState struct {
+	Game     time.Time
+	Term     uint64
+	VotedFor PlayerID
+	Snapshot RaftSnapshot
+	Log      []LogEntry
+}
+
+// raftStorage persists the state of a replica
+// - save: Replaces the state persisted, it must be durable once save returns
+// - load: Returns the state persisted, ok is false if there is none
+type raftStorage interface {
+	save(state raftState) error
+	load() (state raftState, ok bool, err error)
+}
+
+// fileStorage persists the state of a replica in a file
+// The file is replaced by renaming a new one over it, so a crash while saving leaves the old state
+type fileStorage struct {
+	path string
+}
+
+func (s fileStorage) save(state raftState) error {
+	dir := filepath.Dir(s.path)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(dir, filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = gob.NewEncoder(file).Encode(state)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.path)
+}
+
+func (s fileStorage) load() (state raftState, ok bool, err error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return state, false, nil
+	}
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	err = gob.NewDecoder(file).Decode(&state)
+	return state, err == nil, err
+}
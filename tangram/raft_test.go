@@ -0,0 +1,171 @@
+package tangram
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testReplica returns a replica of a game whose replicas are players 1 to 3, persisting in dir
+func testReplica(t *testing.T, me PlayerID, dir string, started time.Time) *raft {
+	config := testConfig(t)
+	config.Host = true
+	config.Replicas = []PlayerID{1, 2, 3}
+	config.RaftDir = dir
+	r := newRaft(me)
+	r.configure(config, started)
+	return r
+}
+
+// persistRaft persists the replica and lets the leader count itself, as stepRaft does
+func persistRaft(t *testing.T, r *raft) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.persist(); err != nil {
+		t.Fatal(err)
+	}
+	if r.role == raftLeader {
+		r.advanceCommit()
+	}
+}
+
+// elect makes the replica the leader with the vote of another
+func elect(t *testing.T, r *raft, voter *raft) {
+	r.mutex.Lock()
+	req, _ := r.stand()
+	r.mutex.Unlock()
+	persistRaft(t, r)
+	votes := 1
+	if !r.tally(req.Term, voter.vote(req), &votes) {
+		t.Fatalf("replica %d was not elected", r.me)
+	}
+}
+
+// catchUp sends the entries of the leader to the replica until it holds all of them
+func catchUp(t *testing.T, leader *raft, r *raft) {
+	for i := 0; i < 1000; i++ {
+		persistRaft(t, leader)
+		req, ok := leader.nextAppend(r.me)
+		if !ok {
+			t.Fatalf("leader %d has nothing to send to %d", leader.me, r.me)
+		}
+		res := r.appendEntries(req)
+		leader.acknowledge(r.me, req, res, nil)
+		if res.Success && res.Match == leader.lastIndex() {
+			persistRaft(t, leader)
+			return
+		}
+	}
+	t.Fatalf("replica %d did not catch up with leader %d", r.me, leader.me)
+}
+
+func TestVotePersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Now()
+	r := testReplica(t, 1, dir, started)
+	req := VoteRequest{Player: 2, Term: 1}
+	if !r.vote(req).Granted {
+		t.Fatal("vote for 2 refused")
+	}
+
+	// The restarted replica remembers its vote, and does not vote again in the term
+	r = testReplica(t, 1, dir, started)
+	if r.term != 1 || r.votedFor != 2 {
+		t.Fatalf("restored term = %d, votedFor = %d", r.term, r.votedFor)
+	}
+	if r.vote(VoteRequest{Player: 3, Term: 1}).Granted {
+		t.Fatal("voted twice in term 1")
+	}
+
+	// The state of another game is not restored
+	r = testReplica(t, 1, dir, started.Add(time.Second))
+	if r.term != 0 || r.votedFor != NoPlayer {
+		t.Fatalf("restored term = %d, votedFor = %d of another game", r.term, r.votedFor)
+	}
+}
+
+func TestCompactedLogCatchesUpReplicas(t *testing.T) {
+	started := time.Now()
+	leader := testReplica(t, 1, t.TempDir(), started)
+	follower := testReplica(t, 2, t.TempDir(), started)
+	elect(t, leader, follower)
+
+	events := randomEvents(leader.config, 1, 2*raftCompaction)
+	for _, event := range events {
+		leader.propose(event)
+	}
+	catchUp(t, leader, follower)
+	leader.takeCommitted()
+	if leader.snapshot.Index == 0 {
+		t.Fatal("the leader did not compact its log")
+	}
+
+	// A replica that missed every entry is sent the snapshot, and replays to the same tans
+	dir := t.TempDir()
+	late := testReplica(t, 3, dir, started)
+	catchUp(t, leader, late)
+	want := Replay(leader.config, events).Tans
+	if got := Replay(late.config, late.committed()).Tans; !reflect.DeepEqual(want, got) {
+		t.Fatal("the late replica replays to other tans")
+	}
+
+	var applied []Event
+	for _, entry := range late.takeCommitted() {
+		if entry.Event.Kind != "" {
+			applied = append(applied, entry.Event)
+		}
+	}
+	if got := Replay(late.config, applied).Tans; !reflect.DeepEqual(want, got) {
+		t.Fatal("the late replica applies other tans")
+	}
+
+	// And it keeps the snapshot when it restarts
+	late = testReplica(t, 3, dir, started)
+	if got := Replay(late.config, late.committed()).Tans; !reflect.DeepEqual(want, got) {
+		t.Fatal("the restarted replica replays to other tans")
+	}
+}
+
+func TestReplicatedHostFailover(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	config.Replicas = []PlayerID{1, 2, 3}
+	config.RaftDir = t.TempDir()
+	network := testNetwork(t, 10)
+	games := startGames(t, network, config, 4)
+
+	// sameHost returns the host the games agree on, NoPlayer if they do not
+	sameHost := func(games []*Game) PlayerID {
+		host := games[0].GetState().Host
+		for _, game := range games[1:] {
+			if game.GetState().Host != host {
+				return NoPlayer
+			}
+		}
+		return host
+	}
+	eventually(t, 10*time.Second, "the raft leader hosts the game", func() bool {
+		host := sameHost(games)
+		return host != NoPlayer && games[host-1].raft.leading()
+	})
+
+	// Only the replicas stand for host once it fails, the other player holds no Bully election
+	failed := sameHost(games)
+	var rest []*Game
+	var others []string
+	for _, game := range games {
+		if id := game.GetPlayer().ID; id != failed {
+			rest = append(rest, game)
+			others = append(others, addr(id))
+		}
+	}
+	network.Partition([]string{addr(failed)}, others)
+	eventually(t, 30*time.Second, "a new raft leader hosts the game", func() bool {
+		host := sameHost(rest)
+		return host != NoPlayer && host != failed && games[host-1].raft.leading()
+	})
+	if host := sameHost(rest); host == 4 {
+		t.Fatal("a player that is not a replica hosts the game")
+	}
+}
@@ -0,0 +1,115 @@
+package tangram
+
+import (
+	"log"
+	"time"
+)
+
+// rebalanceInterval is how often the host looks for a better host among its peers
+const rebalanceInterval = 10 * time.Second
+
+// rebalanceMargin is how much better, in percent, a peer must be for hosting to be handed over to it
+const rebalanceMargin = 20
+
+// handoverDrain is how long the old host waits for the moves peers sent it before they learned of the new host
+const handoverDrain = time.Second
+
+// rebalanceLoop hands hosting over to a peer that has been clearly better than this host for hostSwitchTimeout
+// Peers are ranked by the ElectionPolicy of the game, as in an election
+func (game *Game) rebalanceLoop() {
+	challenger := NoPlayer
+	var since time.Time
+	for game.sleep(rebalanceInterval) {
+		best, ok := game.betterHost()
+		if !ok {
+			challenger = NoPlayer
+			continue
+		}
+		if best != challenger {
+			log.Printf("[rebalance] %d would be a better host", best)
+			challenger, since = best, time.Now()
+			continue
+		}
+		if time.Since(since) < hostSwitchTimeout {
+			continue
+		}
+
+		log.Printf("[rebalance] Handing over hosting to %d", best)
+		game.handOver(best)
+		challenger = NoPlayer
+	}
+}
+
+// betterHost returns the peer that would be a clearly better host than this one
+// ok is false if there is none, or if this node does not decide who hosts
+func (game *Game) betterHost() (best PlayerID, ok bool) {
+	me := game.GetPlayer().ID
+	game.lock.RLock()
+	hosting := game.state.Host == me
+	game.lock.RUnlock()
+	if !hosting || game.raft.replicated() || game.election.campaigning() {
+		return NoPlayer, false
+	}
+
+	policy := game.electionPolicy()
+	candidate := handicap(game.GetCandidate())
+	for _, peer := range game.gatherCandidates() {
+		if policy.Better(peer, candidate) {
+			candidate = peer
+		}
+	}
+	return candidate.Player, candidate.Player != me
+}
+
+// handicap returns the candidate improved by rebalanceMargin, which a peer has to beat to take over hosting
+func handicap(candidate Candidate) Candidate {
+	candidate.Latency = candidate.Latency * (100 - rebalanceMargin) / 100
+	candidate.Uptime = candidate.Uptime * (100 + rebalanceMargin) / 100
+	candidate.Capacity = candidate.Capacity * (100 + rebalanceMargin) / 100
+	return candidate
+}
+
+// handOver makes the player host in a new term without dropping moves
+// The successor is sent the full state before it is announced, and again once the moves
+// peers sent to this node before they learned of the new host have drained
+// This node stays host if the successor does not accept
+func (game *Game) handOver(successor PlayerID) {
+	game.lock.RLock()
+	player := game.state.getPlayer(successor)
+	var others []*Player
+	for _, other := range game.state.Players {
+		if other.ID != successor {
+			others = append(others, other)
+		}
+	}
+	game.lock.RUnlock()
+	if player == nil {
+		return
+	}
+
+	client, err := game.pool.getConnection(player)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	game.pushSnapshot(player, client)
+
+	// The handover is an election with a single candidate, its term keeps stale coordinators out
+	var ok bool
+	term := game.election.current() + 1
+	err = client.Call("Node.Coordinator", CoordinatorRequest{successor, term, game.clock.Now()}, &ok)
+	if err != nil || !ok {
+		log.Printf("[handOver] %d did not take over hosting", successor)
+		return
+	}
+	game.acceptCoordinator(successor, term)
+
+	game.broadcast(others, func(client Conn) error {
+		var ok bool
+		return client.Call("Node.Coordinator", CoordinatorRequest{successor, term, game.clock.Now()}, &ok)
+	})
+
+	if game.sleep(handoverDrain) {
+		game.pushSnapshot(player, client)
+	}
+}
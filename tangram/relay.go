@@ -0,0 +1,417 @@
+package tangram
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"../lamport"
+)
+
+// In a tiered game players cluster under relays, see GameConfig.Tiered.
+// Relays mesh among themselves with Ricart-Agrawala, as the players of a decentralized game do.
+// A member talks to its relay alone. The relay asks the other relays for tans on behalf of its
+// members, forwards their moves to the other relays, and pushes its state to them as a host would.
+// Relays are picked from the latency matrix by the planner, the player with the lowest ID.
+
+// relayInterval is how often the planner checks whether the relays should change
+const relayInterval = 5 * time.Second
+
+// relayLockTimeout is how long a relay asks the other relays for a tan on behalf of a member
+// It is shorter than lockTimeout, so the relay replies before the member gives up
+const relayLockTimeout = lockTimeout / 2
+
+// unknownRTT is the round trip time assumed between players whose link nobody measured yet
+const unknownRTT = time.Second
+
+// RelayPlan assigns every player of a tiered game to a relay
+// - Planner: The player that made the plan
+// - Version: Later plans have higher versions, ties go to the lower Planner
+// - Relays: The relay of each player, a relay is its own relay
+type RelayPlan struct {
+	Planner PlayerID              `json:"planner"`
+	Version uint64                `json:"version"`
+	Relays  map[PlayerID]PlayerID `json:"relays"`
+}
+
+// newer returns whether the plan replaces other
+func (plan RelayPlan) newer(other RelayPlan) bool {
+	return plan.Version > other.Version || (plan.Version == other.Version && plan.Planner < other.Planner)
+}
+
+// covers returns whether the plan assigns every player to a relay that is still in the game
+func (plan RelayPlan) covers(players []PlayerID) bool {
+	present := make(map[PlayerID]bool)
+	for _, id := range players {
+		present[id] = true
+	}
+	for _, id := range players {
+		relay, ok := plan.Relays[id]
+		if !ok || !present[relay] || plan.Relays[relay] != relay {
+			return false
+		}
+	}
+	return true
+}
+
+// tiered returns whether the players of the game cluster under relays
+// Must be called while holding Game.lock
+func (game *Game) tiered() bool {
+	return game.config != nil && game.config.Tiered && !game.hosted()
+}
+
+// relayOf returns the relay of the player
+// A player that is not in the plan yet, or whose relay is gone, is its own relay until the next plan
+// Must be called while holding Game.lock
+func (game *Game) relayOf(id PlayerID) PlayerID {
+	relay, ok := game.state.Relays.Relays[id]
+	if !ok || game.state.getPlayer(relay) == nil {
+		return id
+	}
+	return relay
+}
+
+// relaying returns whether this node is a relay of a tiered game
+// Must be called while holding Game.lock
+func (game *Game) relaying() bool {
+	me := game.GetPlayer().ID
+	return game.tiered() && game.relayOf(me) == me
+}
+
+// represents returns whether this node answers for the player, it does for itself and the members it relays
+// Must be called while holding Game.lock
+func (game *Game) represents(id PlayerID) bool {
+	return game.speaksFor(game.GetPlayer().ID, id)
+}
+
+// speaksFor returns whether from answers for the player, as the player itself or as its relay
+// Must be called while holding Game.lock
+func (game *Game) speaksFor(from PlayerID, id PlayerID) bool {
+	return id == from || (game.tiered() && game.relayOf(id) == from)
+}
+
+// cluster returns the relays of the game, this node included if it is one, and the members relayed by this node
+// Must be called while holding Game.lock
+func (game *Game) cluster() (relays []*Player, members []*Player) {
+	me := game.GetPlayer().ID
+	for _, player := range game.state.Players {
+		relay := game.relayOf(player.ID)
+		if relay == player.ID {
+			relays = append(relays, player)
+		} else if relay == me {
+			members = append(members, player)
+		}
+	}
+	return
+}
+
+// tieredPeers returns the players this node talks to in a tiered game
+// A relay talks to the other relays and its members, a member to its relay alone
+// Must be called while holding Game.lock
+func (game *Game) tieredPeers() []*Player {
+	me := game.GetPlayer().ID
+	if relay := game.relayOf(me); relay != me {
+		return []*Player{game.state.getPlayer(relay)}
+	}
+	relays, members := game.cluster()
+	return append(relays, members...)
+}
+
+// swimPeers returns the players this node probes and gossips with, nil if it does with every member
+// In a tiered game it does with its tiered peers alone, so it keeps no connection to the other players
+func (game *Game) swimPeers() map[PlayerID]bool {
+	game.lock.RLock()
+	defer game.lock.RUnlock()
+	if !game.tiered() {
+		return nil
+	}
+	peers := make(map[PlayerID]bool)
+	for _, player := range game.tieredPeers() {
+		peers[player.ID] = true
+	}
+	return peers
+}
+
+// prunePeers stops talking to the players that are no longer tiered peers of this node
+// Their connections, streams and delta queues are dropped, and opened again if they become peers later.
+// Calls in flight when the relays changed may open them again, so the heartbeat prunes them every round.
+func (game *Game) prunePeers() {
+	me := game.GetPlayer().ID
+	var strangers []PlayerID
+	game.lock.RLock()
+	if game.tiered() {
+		for _, player := range game.state.Players {
+			if player.ID != me && !game.isPlayerInteresting(player) {
+				strangers = append(strangers, player.ID)
+			}
+		}
+	}
+	game.lock.RUnlock()
+
+	for _, id := range strangers {
+		game.pool.dropConnection(id)
+		game.streams.drop(id)
+		game.delta.dropQueue(id)
+	}
+}
+
+// lockPeers returns the peers to ask for a tan, a relay asks the other relays alone
+// Must be called while holding Game.lock
+func (game *Game) lockPeers() []*Player {
+	if game.relaying() {
+		relays, _ := game.cluster()
+		return relays
+	}
+	return game.interestingPlayers()
+}
+
+// downstream returns the peers this node pushes its state to, every peer of a host or the members of a relay
+// Must be called while holding Game.lock
+func (game *Game) downstream() []*Player {
+	if game.tiered() {
+		_, members := game.cluster()
+		return members
+	}
+	return game.interestingPlayers()
+}
+
+// relayTan asks the other relays for the tan on behalf of a member
+// The request keeps the lamport time of the member, so relays order it as if the member had sent it
+// Must be called while holding Game.lock, which is released before returning
+func (game *Game) relayTan(tan *Tan, member PlayerID, reqTime lamport.Time) (ok bool, err error) {
+	now := game.clock.Physical()
+	if tan.Player == member && tan.held(now) {
+		game.lock.Unlock()
+		return true, nil
+	}
+	if _, requesting := game.mutex.requests[tan.ID]; requesting || tan.held(now) {
+		log.Printf("[relayTan] Refusing tan ID = %d to %d", tan.ID, member)
+		game.lock.Unlock()
+		return false, nil
+	}
+
+	game.mutex.requests[tan.ID] = reqTime
+	game.mutex.requesters[tan.ID] = member
+	vector := tan.Vector.Copy()
+	peers := game.lockPeers()
+	game.lock.Unlock()
+
+	ok, seen := game.requestTan(tan.ID, member, reqTime, vector, peers, relayLockTimeout)
+
+	game.lock.Lock()
+	delete(game.mutex.requests, tan.ID)
+	delete(game.mutex.requesters, tan.ID)
+	if ok {
+		// The member receives the merged clocks in the reply of the relay
+		tan.Clock.Receive(seen.Time)
+		tan.Vector.Merge(seen.Vector)
+		game.grantLease(tan, member, reqTime, game.clock.Physical())
+	} else {
+		game.replyDeferred(tan)
+	}
+	game.notify()
+	game.lock.Unlock()
+
+	if !ok {
+		game.broadcastUnlock(tan.ID, member)
+	}
+	return
+}
+
+// relayMove forwards the move of a member to the other relays, which push it on to their own members
+// Streaming never blocks, so this is safe under the lock
+// Must be called while holding Game.lock
+func (game *Game) relayMove(move MoveTanRequest) {
+	me := game.GetPlayer().ID
+	if !game.tiered() || move.Player == me || game.relayOf(move.Player) != me {
+		return
+	}
+
+	move.From = me
+	relays, _ := game.cluster()
+	for _, relay := range relays {
+		if relay.ID == me {
+			continue
+		}
+		move.Stamp = game.clock.Now()
+		game.streamMove(relay, move)
+	}
+}
+
+// witnessRelays adopts the plan if it is newer than the one known, and returns whether it did
+// Must be called while holding Game.lock
+func (game *Game) witnessRelays(plan RelayPlan) bool {
+	if !plan.newer(game.state.Relays) {
+		return false
+	}
+	log.Printf("[witnessRelays] Adopting plan version = %d of %d", plan.Version, plan.Planner)
+	game.state.Relays = plan
+	go game.prunePeers()
+	// Relays bring their new members up to date
+	game.delta.touchPlayers()
+	return true
+}
+
+// relayPlan returns the plan known to this node
+func (game *Game) relayPlan() RelayPlan {
+	game.lock.RLock()
+	defer game.lock.RUnlock()
+	return game.state.Relays
+}
+
+// gossipRelays adopts a plan gossiped by a peer
+func (game *Game) gossipRelays(plan RelayPlan) {
+	game.lock.Lock()
+	if game.witnessRelays(plan) {
+		game.notify()
+	}
+	game.lock.Unlock()
+}
+
+// relayLoop makes the planner of a tiered game replan the relays as players come and go
+func (game *Game) relayLoop() {
+	for game.sleep(relayInterval) {
+		game.replan()
+	}
+}
+
+// replan makes a new plan if this node is the planner, the player with the lowest ID
+// The plan is kept while it covers every player, unless the latency matrix shows
+// one that is better by rebalanceMargin, so relays do not change on every measurement
+func (game *Game) replan() {
+	me := game.GetPlayer().ID
+	game.lock.RLock()
+	tiered := game.tiered()
+	current := game.state.Relays
+	var players []PlayerID
+	for _, player := range game.state.Players {
+		players = append(players, player.ID)
+	}
+	game.lock.RUnlock()
+	sort.Ints(players)
+	if !tiered || len(players) == 0 || players[0] != me {
+		return
+	}
+
+	rtt := newLatencies(game.Topology())
+	relays := planRelays(players, rtt)
+	if current.covers(players) && rtt.cost(current.Relays, players)*100 <= rtt.cost(relays, players)*(100+rebalanceMargin) {
+		return
+	}
+
+	game.lock.Lock()
+	if game.state.Relays.Version == current.Version && game.state.Relays.Planner == current.Planner {
+		plan := RelayPlan{me, current.Version + 1, relays}
+		log.Printf("[replan] Planning version = %d, relays = %v", plan.Version, relays)
+		game.witnessRelays(plan)
+		game.notify()
+	}
+	game.lock.Unlock()
+}
+
+// latencies are the round trip times between players, as measured in the latency matrix
+type latencies map[[2]PlayerID]time.Duration
+
+// newLatencies reads the round trip times of the topology
+// A lossy link counts as slower, as a lost message has to be sent again
+func newLatencies(topology Topology) latencies {
+	rtt := make(latencies)
+	for _, link := range topology.Links {
+		if link.RTT == 0 {
+			continue
+		}
+		loss := math.Min(link.Loss, 0.9)
+		rtt[[2]PlayerID{link.From, link.To}] = time.Duration(float64(link.RTT) / (1 - loss))
+	}
+	return rtt
+}
+
+// between returns the round trip time between the players, averaged over both directions
+func (rtt latencies) between(a PlayerID, b PlayerID) time.Duration {
+	if a == b {
+		return 0
+	}
+	if d, ok := rtt.measured(a, b); ok {
+		return d
+	}
+	return unknownRTT
+}
+
+// measured returns the round trip time between the players averaged over both directions,
+// ok is false if neither of them measured it
+func (rtt latencies) measured(a PlayerID, b PlayerID) (d time.Duration, ok bool) {
+	var sum time.Duration
+	var n time.Duration
+	for _, key := range [][2]PlayerID{{a, b}, {b, a}} {
+		if d, ok := rtt[key]; ok {
+			sum += d
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / n, true
+}
+
+// cost returns the total round trip time of the players to their relays
+func (rtt latencies) cost(relays map[PlayerID]PlayerID, players []PlayerID) (cost time.Duration) {
+	for _, id := range players {
+		cost += rtt.between(id, relays[id])
+	}
+	return
+}
+
+// planRelays picks the relays that bring the players closest to their relay, one at a time,
+// and assigns every player to its closest relay
+// About the square root of the number of players are relays, so that neither relays nor
+// members keep more than a few connections
+func planRelays(players []PlayerID, rtt latencies) map[PlayerID]PlayerID {
+	count := int(math.Ceil(math.Sqrt(float64(len(players)))))
+	var relays []PlayerID
+	for len(relays) < count {
+		best := NoPlayer
+		var bestCost time.Duration
+		for _, candidate := range players {
+			if contains(relays, candidate) {
+				continue
+			}
+			candidates := append(relays[:len(relays):len(relays)], candidate)
+			cost := rtt.cost(assignRelays(players, candidates, rtt), players)
+			if best == NoPlayer || cost < bestCost {
+				best, bestCost = candidate, cost
+			}
+		}
+		relays = append(relays, best)
+	}
+	return assignRelays(players, relays, rtt)
+}
+
+// assignRelays assigns every player to its closest relay
+func assignRelays(players []PlayerID, relays []PlayerID, rtt latencies) map[PlayerID]PlayerID {
+	plan := make(map[PlayerID]PlayerID)
+	for _, id := range players {
+		if contains(relays, id) {
+			plan[id] = id
+			continue
+		}
+		best := relays[0]
+		for _, relay := range relays[1:] {
+			if rtt.between(id, relay) < rtt.between(id, best) {
+				best = relay
+			}
+		}
+		plan[id] = best
+	}
+	return plan
+}
+
+func contains(ids []PlayerID, id PlayerID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
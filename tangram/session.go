@@ -0,0 +1,57 @@
+package tangram
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// A session token is a secret a player keeps to prove its identity when it rejoins.
+// Peers only know its hash, Player.Session, so the token cannot be learned from the game state.
+
+func newSessionToken() string {
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(token)
+}
+
+func sessionHash(token string) string {
+	if token == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// GetSession returns the session token of this player
+// Keep it to rejoin the game with the same ID after a crash, see RejoinGameWithTransport
+func (game *Game) GetSession() string {
+	return game.node.token
+}
+
+// RejoinGameWithTransport connects to an existing game at addr through transport,
+// as the player that had the session token.
+// Peers that still list the player replace its stale address, and the player
+// keeps the tans it held as long as their leases have not lapsed.
+func RejoinGameWithTransport(transport Transport, remoteAddr string, addr string, playerID int, token string) (game *Game, err error) {
+	return connectToGame(transport, remoteAddr, addr, playerID, token)
+}
+
+// replacePlayer swaps a player for the same player at a new address
+// Everything tied to the old address is dropped, so it is set up again for the new one
+// Must be called while holding Game.lock
+func (game *Game) replacePlayer(player *Player) {
+	for i, current := range game.state.Players {
+		if current.ID == player.ID {
+			game.state.Players[i] = player
+		}
+	}
+	game.pool.dropConnection(player.ID)
+	game.delta.dropQueue(player.ID)
+	game.streams.drop(player.ID)
+	game.detector.forget(player.ID)
+	game.delta.touchPlayers()
+}
@@ -0,0 +1,515 @@
+package tangram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../lamport"
+	"../simnet"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("TANGRAM_LOG") == "" {
+		log.SetOutput(ioutil.Discard)
+	}
+	os.Exit(m.Run())
+}
+
+// testConfig returns the config real games are played with
+func testConfig(t *testing.T) *GameConfig {
+	data, err := ioutil.ReadFile("../config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := new(GameConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// testNetwork returns a virtual network whose messages are delivered as fast as the nodes send them,
+// in the order of its scheduler
+// The games keep time with the wall clock, so the tests wait for conditions with eventually,
+// and a run is not replayed exactly, see simnet.Network
+func testNetwork(t *testing.T, seed int64) *simnet.Network {
+	network := simnet.NewVirtual(seed)
+	network.SetLatency(5*time.Millisecond, 5*time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if !network.Step() {
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+	t.Cleanup(func() { close(done) })
+	return network
+}
+
+// addr returns the address of the player on a test network
+func addr(id PlayerID) string {
+	return fmt.Sprintf("n%d:1", id)
+}
+
+// startGames starts a game of n players with IDs 1 to n, the first one creates it
+// It returns once every player knows every other
+func startGames(t *testing.T, network *simnet.Network, config *GameConfig, n int) []*Game {
+	var games []*Game
+	for id := 1; id <= n; id++ {
+		var game *Game
+		var err error
+		if id == 1 {
+			game, err = NewGameWithNetwork(network.Host(addr(id)), config, addr(id), id)
+		} else {
+			game, err = ConnectToGameWithNetwork(network.Host(addr(id)), addr(1), addr(id), id)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { game.Close() })
+		games = append(games, game)
+	}
+
+	eventually(t, 10*time.Second, "every player knows every other", func() bool {
+		for _, game := range games {
+			if len(game.GetState().Players) != n {
+				return false
+			}
+		}
+		return true
+	})
+	return games
+}
+
+// eventually fails the test unless the condition holds within timeout
+func eventually(t *testing.T, timeout time.Duration, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %v waiting until %s", timeout, what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// obtainConcurrently makes every game request the tan at once, and returns the games that obtained it
+// in the order they did, and whether two of them held it at once
+// Each winner holds the tan while hold runs, then releases it, so that a request the host queued
+// obtains it next, see arbitrate
+func obtainConcurrently(games []*Game, id TanID, hold func(winner *Game)) (winners []*Game, overlapped bool) {
+	var holding, overlaps int32
+	won := make(chan *Game)
+	release := make(chan bool)
+	var wg sync.WaitGroup
+	for _, game := range games {
+		wg.Add(1)
+		go func(game *Game) {
+			defer wg.Done()
+			if ok, _ := game.ObtainTan(id, false); !ok {
+				return
+			}
+			if atomic.AddInt32(&holding, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			won <- game
+			<-release
+			atomic.AddInt32(&holding, -1)
+			game.ObtainTan(id, true)
+		}(game)
+	}
+	go func() {
+		wg.Wait()
+		close(won)
+	}()
+
+	for winner := range won {
+		winners = append(winners, winner)
+		hold(winner)
+		release <- true
+	}
+	return winners, atomic.LoadInt32(&overlaps) > 0
+}
+
+// holders returns the holder of the tan as seen by every game
+func holders(games []*Game, id TanID) (seen []PlayerID) {
+	for _, game := range games {
+		seen = append(seen, game.GetState().getTan(id).Player)
+	}
+	return
+}
+
+func testObtainTanRace(t *testing.T, config *GameConfig, seed int64) {
+	games := startGames(t, testNetwork(t, seed), config, 3)
+	for _, tan := range config.Tans {
+		winners, overlapped := obtainConcurrently(games, tan.ID, func(winner *Game) {
+			id := winner.GetPlayer().ID
+			eventually(t, 5*time.Second, fmt.Sprintf("every player sees %d hold tan %d, not %v", id, tan.ID, holders(games, tan.ID)), func() bool {
+				for _, holder := range holders(games, tan.ID) {
+					if holder != id {
+						return false
+					}
+				}
+				return true
+			})
+		})
+		if overlapped {
+			t.Fatalf("tan %d obtained by %d players at once", tan.ID, len(winners))
+		}
+	}
+}
+
+func TestObtainTanRace(t *testing.T) {
+	t.Parallel()
+	testObtainTanRace(t, testConfig(t), 1)
+}
+
+func TestHostedObtainTanRace(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	testObtainTanRace(t, config, 2)
+}
+
+func TestElectionAfterHostFailure(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	network := testNetwork(t, 3)
+	games := startGames(t, network, config, 4)
+
+	network.Partition([]string{addr(1)}, []string{addr(2), addr(3), addr(4)})
+	peers := games[1:]
+	eventually(t, 45*time.Second, "the others elect a new host", func() bool {
+		first := peers[0].GetState()
+		if first.Host == 1 || first.Host == NoPlayer || first.Election != ElectionTerminated {
+			return false
+		}
+		for _, game := range peers[1:] {
+			state := game.GetState()
+			if state.Host != first.Host || state.Term != first.Term || state.Election != ElectionTerminated {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// sameTans returns whether the games agree on the location, rotation and holder of every tan
+func sameTans(games []*Game) bool {
+	first := games[0].GetState()
+	for _, game := range games[1:] {
+		state := game.GetState()
+		for i, tan := range state.Tans {
+			other := first.Tans[i]
+			if tan.Location != other.Location || tan.Rotation != other.Rotation || tan.Player != other.Player {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestWitnessStateConvergence(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	network := testNetwork(t, 4)
+	network.SetLatency(5*time.Millisecond, 20*time.Millisecond)
+	games := startGames(t, network, config, 3)
+
+	// Every player drags its own tan, with messages overtaking each other
+	for i, game := range games {
+		if ok, err := game.ObtainTan(config.Tans[i].ID, false); !ok {
+			t.Fatalf("player %d could not obtain tan %d: %v", i+1, config.Tans[i].ID, err)
+		}
+	}
+	for step := int32(0); step < 30; step++ {
+		for i, game := range games {
+			game.MoveTan(config.Tans[i].ID, Point{X: 10 * int32(i), Y: step}, Rotation(step%8))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	eventually(t, 5*time.Second, "every player sees the same tans", func() bool { return sameTans(games) })
+
+	// A player cut off from the host catches up once the partition heals
+	network.Partition([]string{addr(1), addr(2)}, []string{addr(3)})
+	games[1].MoveTan(config.Tans[1].ID, Point{X: 50, Y: 50}, 0)
+	time.Sleep(200 * time.Millisecond)
+	network.Heal()
+	games[1].MoveTan(config.Tans[1].ID, Point{X: 60, Y: 60}, 0)
+	eventually(t, 10*time.Second, "the partitioned player catches up", func() bool { return sameTans(games) })
+}
+
+func TestTieredPeers(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Tiered = true
+	games := startGames(t, testNetwork(t, 11), config, 5)
+
+	// samePlan returns the plan the games agree on, once it covers every player
+	samePlan := func() (plan RelayPlan, ok bool) {
+		plan = games[0].relayPlan()
+		players := []PlayerID{1, 2, 3, 4, 5}
+		for _, game := range games[1:] {
+			other := game.relayPlan()
+			if other.Version != plan.Version || other.Planner != plan.Planner {
+				return plan, false
+			}
+		}
+		return plan, plan.covers(players)
+	}
+	eventually(t, 3*relayInterval, "every player adopts a plan", func() bool {
+		_, ok := samePlan()
+		return ok
+	})
+
+	// Every player keeps connections to its relay, or to the other relays and its members, alone
+	eventually(t, 10*time.Second, "the players drop the connections to the others", func() bool {
+		plan, ok := samePlan()
+		if !ok {
+			return false
+		}
+		for _, game := range games {
+			me := game.GetPlayer().ID
+			game.pool.mutex.Lock()
+			for id := range game.pool.connections {
+				relay, other := plan.Relays[me], plan.Relays[id]
+				if relay == me && (other == id || other == me) || id == relay {
+					continue
+				}
+				game.pool.mutex.Unlock()
+				return false
+			}
+			game.pool.mutex.Unlock()
+		}
+		return true
+	})
+}
+
+func TestLeaveHandsOffToTheBestCandidate(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	config.PinnedHosts = []PlayerID{1, 3}
+	games := startGames(t, testNetwork(t, 12), config, 3)
+	eventually(t, 5*time.Second, "the first pinned host hosts the game", func() bool {
+		for _, game := range games {
+			if game.GetState().Host != 1 {
+				return false
+			}
+		}
+		return true
+	})
+
+	// The policy ranks the other pinned host first, however close the other player is
+	if err := games[0].Leave(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, 10*time.Second, "the next pinned host hosts the game", func() bool {
+		for _, game := range games[1:] {
+			if game.GetState().Host != 3 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestReleaseOfAnotherPlayersTan(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	network := testNetwork(t, 13)
+	games := startGames(t, network, config, 2)
+	id := config.Tans[0].ID
+	if ok, err := games[0].ObtainTan(id, false); !ok {
+		t.Fatalf("could not obtain tan %d: %v", id, err)
+	}
+	eventually(t, 5*time.Second, "the other player sees the tan held", func() bool {
+		return games[1].GetState().getTan(id).Player == 1
+	})
+
+	// The other player cannot release it through its own game
+	ok, err := games[1].ObtainTan(id, true)
+	if tanErr, isTanErr := err.(*TanError); ok || !isTanErr || tanErr.Reason != ReasonHeld || tanErr.Player != 1 {
+		t.Fatalf("released the tan of another player: ok = %t, err = %v", ok, err)
+	}
+
+	// Nor by sending a release on behalf of the holder
+	client, err := network.Host(addr(2)).Dial(addr(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	req := UnlockTanRequest{Tan: id, Player: 1, From: 2}
+	if err := client.Call("Node.UnlockTan", req, &ok); err == nil {
+		t.Fatal("a release sent on behalf of the holder was accepted")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for _, holder := range holders(games, id) {
+		if holder != 1 {
+			t.Fatalf("tan %d is held by %d after the refused releases", id, holder)
+		}
+	}
+}
+
+func TestHostQueuesUntilTheLeaseLapses(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	network := testNetwork(t, 14)
+	games := startGames(t, network, config, 3)
+	id := config.Tans[0].ID
+	if ok, err := games[1].ObtainTan(id, false); !ok {
+		t.Fatalf("could not obtain tan %d: %v", id, err)
+	}
+
+	// Player 3 asks the host for the tan before it learns that player 2 holds it
+	client, err := network.Host(addr(3)).Dial(addr(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	replies := make(chan bool, 1)
+	go func() {
+		var res LockTanResponse
+		req := LockTanRequest{Tan: id, Player: 3, Time: 1 << 40}
+		if err := client.Call("Node.LockTan", req, &res); err != nil {
+			t.Error(err)
+		}
+		replies <- res.Ok
+	}()
+
+	// It is still queued once lockTimeout is over, and obtains the tan when player 2 lets go of it
+	select {
+	case ok := <-replies:
+		t.Fatalf("the host replied %t while the lease was running", ok)
+	case <-time.After(lockTimeout + time.Second):
+	}
+	if ok, err := games[1].ObtainTan(id, true); !ok {
+		t.Fatalf("could not release tan %d: %v", id, err)
+	}
+	select {
+	case ok := <-replies:
+		if !ok {
+			t.Fatal("the host refused the queued request")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the host did not reply once the tan was released")
+	}
+}
+
+func TestReplacedHostRefusesItsQueue(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	network := testNetwork(t, 16)
+	games := startGames(t, network, config, 3)
+	id := config.Tans[0].ID
+	if ok, err := games[1].ObtainTan(id, false); !ok {
+		t.Fatalf("could not obtain tan %d: %v", id, err)
+	}
+
+	// Player 3 is queued at the host while player 2 holds the tan
+	client, err := network.Host(addr(3)).Dial(addr(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	replies := make(chan bool, 1)
+	go func() {
+		var res LockTanResponse
+		req := LockTanRequest{Tan: id, Player: 3, Time: 1 << 40}
+		if err := client.Call("Node.LockTan", req, &res); err != nil {
+			t.Error(err)
+		}
+		replies <- res.Ok
+	}()
+	time.Sleep(time.Second)
+
+	// Once hosting moves to player 2, the old host refuses the queue rather than grant the tan later
+	if !games[0].acceptCoordinator(2, games[0].election.current()+1) {
+		t.Fatal("the old host did not accept the new host")
+	}
+	select {
+	case ok := <-replies:
+		if ok {
+			t.Fatal("the old host granted the queued request")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the old host kept the request queued")
+	}
+
+	games[0].lock.Lock()
+	defer games[0].lock.Unlock()
+	tan := games[0].state.getTan(id)
+	games[0].revokeLease(tan)
+	games[0].mutex.deferred[id] = []*deferredReply{{3, 1 << 40, make(chan bool, 1)}}
+	games[0].grantQueued(tan)
+	if tan.Player != NoPlayer {
+		t.Fatalf("the old host granted tan %d to %d", id, tan.Player)
+	}
+}
+
+func TestWitnessElectionMovesForward(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	config.Host = true
+	game := startGames(t, testNetwork(t, 15), config, 1)[0]
+
+	game.lock.Lock()
+	defer game.lock.Unlock()
+	game.state.Host, game.state.Term, game.state.Election = 1, 3, ElectionTerminated
+
+	// A running state of the same term arrives after the coordinator, it is ignored
+	game.witnessElection(&GameState{Host: NoPlayer, Term: 3, Election: ElectionRunning})
+	if game.state.Host != 1 || game.state.Election != ElectionTerminated {
+		t.Fatalf("host = %d, election = %q after a late running state", game.state.Host, game.state.Election)
+	}
+
+	// The next term starts a new election
+	game.witnessElection(&GameState{Host: NoPlayer, Term: 4, Election: ElectionRunning})
+	if game.state.Term != 4 || game.state.Election != ElectionRunning {
+		t.Fatalf("term = %d, election = %q after the next term", game.state.Term, game.state.Election)
+	}
+	game.witnessElection(&GameState{Host: 1, Term: 4, Election: ElectionTerminated})
+	if game.state.Host != 1 || game.state.Election != ElectionTerminated {
+		t.Fatalf("host = %d, election = %q after the coordinator", game.state.Host, game.state.Election)
+	}
+}
+
+func TestForwardedMovesCreditTheSender(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	game := startGames(t, testNetwork(t, 18), config, 1)[0]
+	id := config.Tans[0].ID
+
+	// Relay 6 forwards a move of member 5, only the relay is heard from
+	var ok bool
+	req := MoveTanRequest{Tan: id, Player: 5, From: 6, Time: 1, Vector: lamport.VectorClock{5: 1}}
+	if err := game.node.MoveTan(req, &ok); err != nil || !ok {
+		t.Fatalf("the move was not applied: %v", err)
+	}
+	game.detector.mutex.Lock()
+	defer game.detector.mutex.Unlock()
+	if _, ok := game.detector.peers[6]; !ok {
+		t.Fatal("the relay that sent the move got no heartbeat")
+	}
+	if _, ok := game.detector.peers[5]; ok {
+		t.Fatal("the member whose move was forwarded got a heartbeat")
+	}
+}
@@ -0,0 +1,212 @@
+package tangram
+
+import (
+	"log"
+	"sync"
+
+	"../lamport"
+)
+
+// MoveBatchRequest is request argument for Node.MoveTans
+// - Player: The player sending the moves
+// - Moves: The latest move of each tan, in the order they were first made
+type MoveBatchRequest struct {
+	Player PlayerID
+	Moves  []MoveTanRequest
+	Stamp  lamport.Timestamp
+}
+
+// MoveBatchResponse is response argument for Node.MoveTans
+// - Moved: Whether each move of the batch was applied, a move is refused alone
+// if it is stale or its tan unknown, the others are applied all the same
+type MoveBatchResponse struct {
+	Moved []bool
+}
+
+// streamRetry is how long a stream waits before sending a batch again after it failed
+// Moves made meanwhile are coalesced with the failed ones
+const streamRetry = backoffMin
+
+// moveStream carries the moves of this node to a single peer.
+// Pending moves are coalesced so that only the latest move of each tan is kept,
+// and they are sent in batches one at a time, so they are delivered in order.
+// A slow peer gets fewer, larger batches instead of a growing pile of calls,
+// and at most one pending move per tan is ever held for it.
+//
+// The stream is a Node.MoveTans call per batch over the pooled connection to
+// the peer, rather than a transport level bidirectional stream. The connection
+// is long-lived, the pool keeps it open, and with a single batch in flight the
+// reply of the peer is the backpressure: moves made meanwhile only coalesce.
+// The peer has nothing to send back but whether each move was applied, so a
+// unary call carries the stream as well, on every Transport, simnet included.
+type moveStream struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	pending map[TanID]MoveTanRequest
+	order   []TanID
+	closed  bool
+}
+
+func newMoveStream() *moveStream {
+	s := &moveStream{pending: make(map[TanID]MoveTanRequest)}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// push queues the move, replacing any pending move of the same tan
+func (s *moveStream) push(move MoveTanRequest) {
+	s.mutex.Lock()
+	if _, ok := s.pending[move.Tan]; !ok {
+		s.order = append(s.order, move.Tan)
+	}
+	s.pending[move.Tan] = move
+	s.cond.Signal()
+	s.mutex.Unlock()
+}
+
+// next blocks until moves are pending and takes all of them
+// ok is false once the stream is closed
+func (s *moveStream) next() (moves []MoveTanRequest, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for len(s.order) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return nil, false
+	}
+
+	moves = make([]MoveTanRequest, len(s.order))
+	for i, id := range s.order {
+		moves[i] = s.pending[id]
+	}
+	s.pending = make(map[TanID]MoveTanRequest)
+	s.order = nil
+	return moves, true
+}
+
+// requeue puts back the moves of a batch that failed, ahead of the moves pushed since
+// A move is dropped if a newer move of the same tan is already pending, it supersedes it
+func (s *moveStream) requeue(moves []MoveTanRequest) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var order []TanID
+	for _, move := range moves {
+		if _, ok := s.pending[move.Tan]; ok {
+			continue
+		}
+		s.pending[move.Tan] = move
+		order = append(order, move.Tan)
+	}
+	s.order = append(order, s.order...)
+}
+
+func (s *moveStream) close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+// moveStreams holds the stream to every peer
+type moveStreams struct {
+	mutex   sync.Mutex
+	streams map[PlayerID]*moveStream
+	closed  bool
+}
+
+func newMoveStreams() *moveStreams {
+	return &moveStreams{streams: make(map[PlayerID]*moveStream)}
+}
+
+// drop closes the stream to the player
+func (m *moveStreams) drop(id PlayerID) {
+	m.mutex.Lock()
+	stream, ok := m.streams[id]
+	if ok {
+		stream.close()
+		delete(m.streams, id)
+	}
+	m.mutex.Unlock()
+}
+
+// close closes the stream to every peer, no stream is opened after it
+func (m *moveStreams) close() {
+	m.mutex.Lock()
+	m.closed = true
+	for id, stream := range m.streams {
+		stream.close()
+		delete(m.streams, id)
+	}
+	m.mutex.Unlock()
+}
+
+// streamMove queues the move to be sent to the player
+func (game *Game) streamMove(player *Player, move MoveTanRequest) {
+	game.streams.mutex.Lock()
+	if game.streams.closed {
+		game.streams.mutex.Unlock()
+		return
+	}
+	stream, ok := game.streams.streams[player.ID]
+	if !ok {
+		stream = newMoveStream()
+		game.streams.streams[player.ID] = stream
+		go game.streamLoop(player, stream)
+	}
+	game.streams.mutex.Unlock()
+
+	stream.push(move)
+}
+
+// streamLoop sends the batches of the stream to the player until the stream is closed
+// A batch that does not get through is sent again after streamRetry, less the moves superseded meanwhile
+// A move the player refused is not, it would be refused again
+func (game *Game) streamLoop(player *Player, stream *moveStream) {
+	for {
+		moves, open := stream.next()
+		if !open {
+			return
+		}
+
+		client, err := game.pool.getConnection(player)
+		if err == nil {
+			var res MoveBatchResponse
+			req := MoveBatchRequest{game.GetPlayer().ID, moves, game.clock.Now()}
+			err = client.Call("Node.MoveTans", req, &res)
+			if err == nil {
+				logRefusedMoves(player.ID, moves, res.Moved)
+				continue
+			}
+		}
+		log.Println(err.Error())
+
+		stream.requeue(moves)
+		if !game.sleep(streamRetry) {
+			return
+		}
+	}
+}
+
+// logRefusedMoves logs the moves of a batch the player did not apply
+func logRefusedMoves(id PlayerID, moves []MoveTanRequest, moved []bool) {
+	for i, move := range moves {
+		if i < len(moved) && !moved[i] {
+			log.Printf("[streamLoop] %d refused the move of tan ID = %d", id, move.Tan)
+		}
+	}
+}
+
+// moveTans applies a batch of moves in order, and returns whether each of them was applied
+// A move that is refused does not keep the later moves of the batch from being applied
+func (game *Game) moveTans(moves []MoveTanRequest) (moved []bool) {
+	moved = make([]bool, len(moves))
+	for i, move := range moves {
+		ok, err := game.moveTan(move.Tan, move.Player, move.Location, move.Rotation, move.Time, move.Vector, move.Expiry)
+		if err != nil {
+			log.Println(err.Error())
+		}
+		moved[i] = ok
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package tangram
+
+import (
+	"reflect"
+	"testing"
+
+	"../lamport"
+)
+
+func TestRequeueKeepsNewerMoves(t *testing.T) {
+	s := newMoveStream()
+	s.push(MoveTanRequest{Tan: 1, Location: Point{X: 1}})
+	s.push(MoveTanRequest{Tan: 2, Location: Point{X: 2}})
+	failed, _ := s.next()
+
+	// Tan 2 moved again while the batch was failing, tan 3 moved for the first time
+	s.push(MoveTanRequest{Tan: 3, Location: Point{X: 3}})
+	s.push(MoveTanRequest{Tan: 2, Location: Point{X: 20}})
+	s.requeue(failed)
+
+	moves, ok := s.next()
+	if !ok {
+		t.Fatal("the stream is closed")
+	}
+	want := []MoveTanRequest{
+		{Tan: 1, Location: Point{X: 1}},
+		{Tan: 3, Location: Point{X: 3}},
+		{Tan: 2, Location: Point{X: 20}},
+	}
+	if !reflect.DeepEqual(moves, want) {
+		t.Fatalf("moves = %v, want %v", moves, want)
+	}
+}
+
+func TestBatchAppliesEveryMove(t *testing.T) {
+	t.Parallel()
+	config := testConfig(t)
+	game := startGames(t, testNetwork(t, 17), config, 1)[0]
+	id := config.Tans[0].ID
+
+	// The move of an unknown tan is refused, the move after it is applied all the same
+	moved := game.moveTans([]MoveTanRequest{
+		{Tan: 1 << 20, Player: 2, Time: 1, Vector: lamport.VectorClock{2: 1}},
+		{Tan: id, Player: 2, Location: Point{X: 7, Y: 8}, Time: 1, Vector: lamport.VectorClock{2: 1}},
+	})
+	if want := []bool{false, true}; !reflect.DeepEqual(moved, want) {
+		t.Fatalf("moved = %v, want %v", moved, want)
+	}
+	if location := game.GetState().getTan(id).Location; location != (Point{X: 7, Y: 8}) {
+		t.Fatalf("tan %d is at %v", id, location)
+	}
+}
@@ -0,0 +1,48 @@
+package tangram
+
+import "sync"
+
+// subscriberSet is the set of channels notified of state updates
+// Subscribers come and go with WebSocket connections while the game notifies them,
+// so every access goes through its mutex
+type subscriberSet struct {
+	mutex    sync.Mutex
+	channels map[chan bool]struct{}
+}
+
+func newSubscriberSet() *subscriberSet {
+	return &subscriberSet{channels: make(map[chan bool]struct{})}
+}
+
+func (s *subscriberSet) add() chan bool {
+	channel := make(chan bool, 1)
+	s.mutex.Lock()
+	s.channels[channel] = struct{}{}
+	s.mutex.Unlock()
+	return channel
+}
+
+// remove closes the channel, it returns false if the channel is not in the set
+func (s *subscriberSet) remove(channel chan bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.channels[channel]; !ok {
+		return false
+	}
+	delete(s.channels, channel)
+	close(channel)
+	return true
+}
+
+// signal wakes every subscriber without blocking
+// A subscriber that has not consumed the last signal yet will see the update anyway
+func (s *subscriberSet) signal() {
+	s.mutex.Lock()
+	for channel := range s.channels {
+		select {
+		case channel <- true:
+		default:
+		}
+	}
+	s.mutex.Unlock()
+}
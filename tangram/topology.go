@@ -0,0 +1,223 @@
+package tangram
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// linkWindow is how many round trips are kept per peer to measure the link to it
+const linkWindow = 20
+
+// reportInterval is how often a node makes a new report of its links
+// A report is gossiped as many times as a membership update, so a report that did not change is not sent again
+const reportInterval = 5 * time.Second
+
+// Link is what a node measured of its link to a peer
+// - RTT: The mean round trip time of the answered pings and probes
+// - Jitter: The mean difference between consecutive round trip times
+// - Loss: The fraction of pings and probes that were not answered in time
+type Link struct {
+	From   PlayerID      `json:"from"`
+	To     PlayerID      `json:"to"`
+	RTT    time.Duration `json:"rtt"`
+	Jitter time.Duration `json:"jitter"`
+	Loss   float64       `json:"loss"`
+}
+
+// LinkReport is the row of the latency matrix measured by a player
+// - Version: When the report was made, a later report of the player replaces it
+type LinkReport struct {
+	Player  PlayerID
+	Version uint64
+	Links   []Link
+}
+
+// Topology is the pairwise latency matrix of the game, as gossiped between the nodes
+// - Players: The players in the game, in order of ID
+// - Links: Every link measured by a player to another, links nobody measured are missing
+type Topology struct {
+	Players []PlayerID `json:"players"`
+	Links   []Link     `json:"links"`
+}
+
+// roundTrip is a ping or probe of a peer, rtt is only set if it was answered
+type roundTrip struct {
+	rtt  time.Duration
+	lost bool
+}
+
+// linkMonitor measures the links of this node to its peers, and keeps the reports of every player
+// The reports are spread with the gossip of the membership protocol
+// - transmits: How many times the known report of each player was gossiped
+type linkMonitor struct {
+	mutex     sync.Mutex
+	me        PlayerID
+	trips     map[PlayerID][]roundTrip
+	reports   map[PlayerID]LinkReport
+	transmits map[PlayerID]int
+}
+
+func newLinkMonitor(me PlayerID) *linkMonitor {
+	return &linkMonitor{
+		me:        me,
+		trips:     make(map[PlayerID][]roundTrip),
+		reports:   make(map[PlayerID]LinkReport),
+		transmits: make(map[PlayerID]int),
+	}
+}
+
+// sample records a round trip to the peer that was answered
+func (m *linkMonitor) sample(id PlayerID, rtt time.Duration) {
+	m.add(id, roundTrip{rtt: rtt})
+}
+
+// lost records a round trip to the peer that was not answered
+func (m *linkMonitor) lost(id PlayerID) {
+	m.add(id, roundTrip{lost: true})
+}
+
+func (m *linkMonitor) add(id PlayerID, trip roundTrip) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	trips := append(m.trips[id], trip)
+	if len(trips) > linkWindow {
+		trips = trips[len(trips)-linkWindow:]
+	}
+	m.trips[id] = trips
+}
+
+// forget drops the links to and the report of the player
+func (m *linkMonitor) forget(id PlayerID) {
+	m.mutex.Lock()
+	delete(m.trips, id)
+	delete(m.reports, id)
+	delete(m.transmits, id)
+	m.mutex.Unlock()
+}
+
+// merge keeps the reports that are later than the ones known, this node measures its own links
+func (m *linkMonitor) merge(reports []LinkReport) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, report := range reports {
+		if report.Player == m.me {
+			continue
+		}
+		if known, ok := m.reports[report.Player]; ok && known.Version >= report.Version {
+			continue
+		}
+		m.reports[report.Player] = report
+		m.transmits[report.Player] = 0
+	}
+}
+
+// gossip returns the reports to piggyback on the next message, the ones that changed lately
+// A report is dropped from gossip once it was sent retransmitMult * log2(players) times, as membership updates are.
+// This node makes a new report every reportInterval, so its links are gossiped again as they change.
+func (m *linkMonitor) gossip() (reports []LinkReport) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if own, ok := m.reports[m.me]; !ok || time.Since(time.Unix(0, int64(own.Version))) >= reportInterval {
+		m.reports[m.me] = m.report()
+		m.transmits[m.me] = 0
+	}
+	limit := retransmitMult * int(math.Ceil(math.Log2(float64(len(m.reports)+1))))
+	for id, report := range m.reports {
+		if m.transmits[id] < limit {
+			reports = append(reports, report)
+			m.transmits[id]++
+		}
+	}
+	return
+}
+
+// all returns the report of this node, measured now, along with every other report known
+func (m *linkMonitor) all() []LinkReport {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	reports := []LinkReport{m.report()}
+	for id, report := range m.reports {
+		if id != m.me {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// report measures the links of this node from the round trips kept
+func (m *linkMonitor) report() LinkReport {
+	report := LinkReport{Player: m.me, Version: uint64(time.Now().UnixNano())}
+	for id, trips := range m.trips {
+		link := Link{From: m.me, To: id}
+		var answered, lost int
+		var sum, variation time.Duration
+		var previous time.Duration
+		for _, trip := range trips {
+			if trip.lost {
+				lost++
+				continue
+			}
+			if answered > 0 {
+				variation += abs(trip.rtt - previous)
+			}
+			sum += trip.rtt
+			previous = trip.rtt
+			answered++
+		}
+		if answered > 0 {
+			link.RTT = sum / time.Duration(answered)
+		}
+		if answered > 1 {
+			link.Jitter = variation / time.Duration(answered-1)
+		}
+		link.Loss = float64(lost) / float64(len(trips))
+		report.Links = append(report.Links, link)
+	}
+	return report
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// Topology returns the latency matrix of the players in the game
+// Each node measures its links to its peers with pings and membership probes, and gossips them
+func (game *Game) Topology() Topology {
+	game.lock.RLock()
+	present := make(map[PlayerID]bool)
+	var players []PlayerID
+	for _, player := range game.state.Players {
+		present[player.ID] = true
+		players = append(players, player.ID)
+	}
+	game.lock.RUnlock()
+	sort.Ints(players)
+
+	var links []Link
+	for _, report := range game.links.all() {
+		if !present[report.Player] {
+			continue
+		}
+		for _, link := range report.Links {
+			if present[link.To] {
+				links = append(links, link)
+			}
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].From != links[j].From {
+			return links[i].From < links[j].From
+		}
+		return links[i].To < links[j].To
+	})
+	return Topology{players, links}
+}
@@ -0,0 +1,69 @@
+package tangram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGossipSendsChangedReports(t *testing.T) {
+	m := newLinkMonitor(1)
+	m.sample(2, 10*time.Millisecond)
+
+	// rounds gossips until nothing is left to send, and returns how many times each report was sent
+	rounds := func() map[PlayerID]int {
+		sent := make(map[PlayerID]int)
+		for i := 0; i < 100; i++ {
+			reports := m.gossip()
+			if len(reports) == 0 {
+				return sent
+			}
+			for _, report := range reports {
+				sent[report.Player]++
+			}
+		}
+		t.Fatal("reports are gossiped forever")
+		return nil
+	}
+
+	report := LinkReport{Player: 2, Version: 1, Links: []Link{{From: 2, To: 1, RTT: 30 * time.Millisecond}}}
+	m.merge([]LinkReport{report})
+	if sent := rounds(); sent[1] == 0 || sent[2] == 0 {
+		t.Fatalf("sent %v, want the reports of 1 and 2", sent)
+	}
+
+	// The same report again is not gossiped, a newer one is
+	m.merge([]LinkReport{report})
+	if sent := rounds(); len(sent) != 0 {
+		t.Fatalf("sent %v for a report that did not change", sent)
+	}
+	report.Version = 2
+	m.merge([]LinkReport{report})
+	if sent := rounds(); sent[2] == 0 || len(sent) != 1 {
+		t.Fatalf("sent %v, want the report of 2 alone", sent)
+	}
+
+	// The matrix still holds every report
+	if reports := m.all(); len(reports) != 2 {
+		t.Fatalf("%d reports known, want 2", len(reports))
+	}
+}
+
+func TestAverageLatencyReadsTheMatrix(t *testing.T) {
+	topology := Topology{
+		Players: []PlayerID{1, 2, 3, 4},
+		Links: []Link{
+			{From: 1, To: 2, RTT: 10 * time.Millisecond},
+			{From: 2, To: 1, RTT: 20 * time.Millisecond},
+			// 1 did not measure its link to 3, 3 did
+			{From: 3, To: 1, RTT: 30 * time.Millisecond},
+			// Nobody measured the link between 1 and 4
+			{From: 2, To: 4, RTT: time.Second},
+		},
+	}
+	if avg := averageLatency(topology, 1); avg != 22500*time.Microsecond {
+		t.Fatalf("average latency = %v, want 22.5ms", avg)
+	}
+	if avg := averageLatency(Topology{Players: []PlayerID{1}}, 1); avg != 0 {
+		t.Fatalf("average latency = %v alone, want 0", avg)
+	}
+}
@@ -0,0 +1,81 @@
+package tangram
+
+import (
+	"fmt"
+	"io"
+	"net/rpc"
+	"reflect"
+	"time"
+)
+
+// Transport carries the RPCs between nodes
+// - Listen: Serves the RPC methods of node at addr until the returned Closer is closed
+// - Dial: Connects to the node at addr
+type Transport interface {
+	Listen(addr string, node *Node) (io.Closer, error)
+	Dial(addr string) (Conn, error)
+}
+
+// Conn is a connection to a peer node
+// - Call: Invokes the named method of the peer, such as "Node.Ping", and waits for the reply
+// - Close: Closes the connection
+// A *rpc.Client is a Conn
+type Conn interface {
+	Call(method string, args interface{}, reply interface{}) error
+	Close() error
+}
+
+// TCPTransport is the net/rpc over TCP transport real games are played on
+var TCPTransport = NewRPCTransport(TCPNetwork)
+
+// NewRPCTransport creates a Transport serving net/rpc over the network
+func NewRPCTransport(network Network) Transport {
+	return rpcTransport{network}
+}
+
+type rpcTransport struct {
+	network Network
+}
+
+func (t rpcTransport) Listen(addr string, node *Node) (io.Closer, error) {
+	server := rpc.NewServer()
+	err := server.Register(node)
+	if err != nil {
+		return nil, err
+	}
+	return t.network.Listen(addr, server)
+}
+
+func (t rpcTransport) Dial(addr string) (Conn, error) {
+	client, err := t.network.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// callTimeout calls the RPC method and gives up waiting for the reply after timeout
+// A call that timed out keeps running, so it decodes into a reply of its own,
+// which is only copied to reply if the call finished in time
+func callTimeout(client Conn, method string, args interface{}, reply interface{}, timeout time.Duration) error {
+	// A pooled connection gives up after rpcTimeout by itself, unless it is given longer
+	if peer, ok := client.(*peerConn); ok && timeout > rpcTimeout {
+		return peer.call(method, args, reply, timeout)
+	}
+
+	fresh := reflect.New(reflect.TypeOf(reply).Elem())
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Call(method, args, fresh.Interface())
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			reflect.ValueOf(reply).Elem().Set(fresh.Elem())
+		}
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("%s timed out after %v", method, timeout)
+	}
+}
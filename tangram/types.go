@@ -12,17 +12,29 @@ import (
 // - Timer: The time when the game started.
 // - Players: It holds the players currently in the game.
 // - Host: The player that is hosting the game.
+// - Election: Whether the last host election is running or terminated, empty if none was held.
+// - Term: The term of the last host election, see Game.Election.
+// - Relays: The relay of each player in a tiered game, see RelayPlan.
 type GameState struct {
-	Tans    []*Tan `json:"tans"`
-	Timer   time.Time
-	Players []*Player
-	Host    PlayerID `json:"host"`
-	Solved  bool
+	Tans     []*Tan `json:"tans"`
+	Timer    time.Time
+	Players  []*Player
+	Host     PlayerID       `json:"host"`
+	Election ElectionStatus `json:"election"`
+	Term     uint64         `json:"term"`
+	Relays   RelayPlan      `json:"relays"`
+	Solved   bool
 }
 
 // GameConfig is the starting configuration of a game
 // - Tans: Tans position when the game begins
 // - Target: The shape players are trying to form with tans.
+// - EvictionThreshold: The suspicion at which a peer is evicted, 8 if unset. See failureDetector
+// - Replicas: The players replicating the events of a hosted game with Raft, the leader among them is host. See raft
+// - HostPolicy: How host elections rank candidates: latency, uptime or capacity. Latency if unset. See ElectionPolicy
+// - PinnedHosts: The players that win host elections whenever they are candidates, in order of preference
+// - Tiered: Whether players of a game that is not hosted cluster under relays instead of meshing. See RelayPlan
+// - RaftDir: The directory where replicas persist their raft state, one file per replica. Kept in memory if unset
 type GameConfig struct {
 	Size    Point
 	Offset  Point
@@ -30,6 +42,13 @@ type GameConfig struct {
 	Tans    []*Tan
 	Targets []*TargetTan `json:"targets"`
 	Host    bool
+
+	EvictionThreshold float64
+	Replicas          []PlayerID
+	HostPolicy        string
+	PinnedHosts       []PlayerID
+	Tiered            bool
+	RaftDir           string
 }
 
 // Tan is a struct that holds the following information:
@@ -39,14 +58,20 @@ type GameConfig struct {
 // - Location: The location of the tan on a canvas
 // - Rotation: Alignment of tan in increments of 5 degrees
 // - Clock: A logical clock for this tan
+// - Vector: A vector clock for this tan, used to detect concurrent operations
+// - Claimed: The lamport time at which Player requested the tan
+// - Expiry: The time at which the Player's lease on the tan lapses, measured by the hybrid clock
 type Tan struct {
-	ID        TanID         `json:"id"`
-	Shape     *Shape        `json:"shape"`
-	ShapeType ShapeType     `json:"type"`
-	Player    PlayerID      `json:"player"`
-	Location  Point         `json:"location"`
-	Rotation  Rotation      `json:"rotation"`
-	Clock     lamport.Clock `json:"clock"`
+	ID        TanID               `json:"id"`
+	Shape     *Shape              `json:"shape"`
+	ShapeType ShapeType           `json:"type"`
+	Player    PlayerID            `json:"player"`
+	Location  Point               `json:"location"`
+	Rotation  Rotation            `json:"rotation"`
+	Clock     lamport.Clock       `json:"clock"`
+	Vector    lamport.VectorClock `json:"vector"`
+	Claimed   lamport.Time        `json:"claimed"`
+	Expiry    time.Time           `json:"expiry"`
 	Matched   bool
 }
 
@@ -82,10 +107,12 @@ type Point struct {
 }
 
 // Player is a struct that holds player information.
+// - Session: The hash of the session token of the player, see RejoinGameWithTransport
 type Player struct {
-	ID   PlayerID
-	Name string
-	Addr string
+	ID      PlayerID
+	Name    string
+	Addr    string
+	Session string
 }
 
 // PlayerID is the ID of a Player
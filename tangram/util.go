@@ -24,6 +24,8 @@ func (state *GameState) getPlayer(id PlayerID) *Player {
 	return nil
 }
 
+// dropPlayer removes the player from the game
+// Must be called while holding Game.lock
 func (game *Game) dropPlayer(id PlayerID) error {
 	for i, player := range game.state.Players {
 		if player.ID == id {
@@ -31,6 +33,11 @@ func (game *Game) dropPlayer(id PlayerID) error {
 			game.state.Players[len(game.state.Players)-1] = nil
 			game.state.Players = game.state.Players[:len(game.state.Players)-1]
 			game.pool.dropConnection(id)
+			game.delta.dropQueue(id)
+			game.streams.drop(id)
+			game.detector.forget(id)
+			game.links.forget(id)
+			game.delta.touchPlayers()
 			game.notify()
 
 			return nil
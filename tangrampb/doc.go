@@ -0,0 +1,4 @@
+// Package tangrampb is the protobuf schema and gRPC service of the protocol between nodes
+package tangrampb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tangram.proto
@@ -0,0 +1,3555 @@
+// Wire protocol between the nodes of a game of tangram.
+//
+// The service mirrors the RPC methods of tangram.Node, so that clients and bots
+// written in any language can join a game as first class peers.
+// Fields may be added to messages, but never renumbered or repurposed.
+// Breaking changes go into a new package version.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: tangram.proto
+
+package tangrampb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MemberStatus int32
+
+const (
+	MemberStatus_MEMBER_ALIVE   MemberStatus = 0
+	MemberStatus_MEMBER_SUSPECT MemberStatus = 1
+	MemberStatus_MEMBER_DEAD    MemberStatus = 2
+	MemberStatus_MEMBER_LEFT    MemberStatus = 3
+)
+
+// Enum value maps for MemberStatus.
+var (
+	MemberStatus_name = map[int32]string{
+		0: "MEMBER_ALIVE",
+		1: "MEMBER_SUSPECT",
+		2: "MEMBER_DEAD",
+		3: "MEMBER_LEFT",
+	}
+	MemberStatus_value = map[string]int32{
+		"MEMBER_ALIVE":   0,
+		"MEMBER_SUSPECT": 1,
+		"MEMBER_DEAD":    2,
+		"MEMBER_LEFT":    3,
+	}
+)
+
+func (x MemberStatus) Enum() *MemberStatus {
+	p := new(MemberStatus)
+	*p = x
+	return p
+}
+
+func (x MemberStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MemberStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_tangram_proto_enumTypes[0].Descriptor()
+}
+
+func (MemberStatus) Type() protoreflect.EnumType {
+	return &file_tangram_proto_enumTypes[0]
+}
+
+func (x MemberStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MemberStatus.Descriptor instead.
+func (MemberStatus) EnumDescriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{0}
+}
+
+// Timestamp is a reading of a hybrid logical clock
+type Timestamp struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Nanoseconds since the unix epoch
+	Wall          int64  `protobuf:"varint,1,opt,name=wall,proto3" json:"wall,omitempty"`
+	Logical       uint64 `protobuf:"varint,2,opt,name=logical,proto3" json:"logical,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Timestamp) Reset() {
+	*x = Timestamp{}
+	mi := &file_tangram_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Timestamp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Timestamp) ProtoMessage() {}
+
+func (x *Timestamp) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Timestamp.ProtoReflect.Descriptor instead.
+func (*Timestamp) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Timestamp) GetWall() int64 {
+	if x != nil {
+		return x.Wall
+	}
+	return 0
+}
+
+func (x *Timestamp) GetLogical() uint64 {
+	if x != nil {
+		return x.Logical
+	}
+	return 0
+}
+
+type Point struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_tangram_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Point) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Point) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+type Shape struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Points        []*Point               `protobuf:"bytes,1,rep,name=points,proto3" json:"points,omitempty"`
+	Fill          string                 `protobuf:"bytes,2,opt,name=fill,proto3" json:"fill,omitempty"`
+	Stroke        string                 `protobuf:"bytes,3,opt,name=stroke,proto3" json:"stroke,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shape) Reset() {
+	*x = Shape{}
+	mi := &file_tangram_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shape) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shape) ProtoMessage() {}
+
+func (x *Shape) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shape.ProtoReflect.Descriptor instead.
+func (*Shape) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Shape) GetPoints() []*Point {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+func (x *Shape) GetFill() string {
+	if x != nil {
+		return x.Fill
+	}
+	return ""
+}
+
+func (x *Shape) GetStroke() string {
+	if x != nil {
+		return x.Stroke
+	}
+	return ""
+}
+
+type Tan struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Shape     *Shape                 `protobuf:"bytes,2,opt,name=shape,proto3" json:"shape,omitempty"`
+	ShapeType string                 `protobuf:"bytes,3,opt,name=shape_type,json=shapeType,proto3" json:"shape_type,omitempty"`
+	// The player holding the tan, -1 if nobody does
+	Player   int64  `protobuf:"varint,4,opt,name=player,proto3" json:"player,omitempty"`
+	Location *Point `protobuf:"bytes,5,opt,name=location,proto3" json:"location,omitempty"`
+	// Degrees
+	Rotation uint32 `protobuf:"varint,6,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	// Lamport time of the tan
+	Clock uint64 `protobuf:"varint,7,opt,name=clock,proto3" json:"clock,omitempty"`
+	// Vector clock of the tan, keyed by player
+	Vector map[int64]uint64 `protobuf:"bytes,8,rep,name=vector,proto3" json:"vector,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Lamport time at which player requested the tan
+	Claimed uint64 `protobuf:"varint,9,opt,name=claimed,proto3" json:"claimed,omitempty"`
+	// Nanoseconds since the unix epoch at which the lease of player lapses
+	Expiry        int64 `protobuf:"varint,10,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	Matched       bool  `protobuf:"varint,11,opt,name=matched,proto3" json:"matched,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tan) Reset() {
+	*x = Tan{}
+	mi := &file_tangram_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tan) ProtoMessage() {}
+
+func (x *Tan) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tan.ProtoReflect.Descriptor instead.
+func (*Tan) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Tan) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Tan) GetShape() *Shape {
+	if x != nil {
+		return x.Shape
+	}
+	return nil
+}
+
+func (x *Tan) GetShapeType() string {
+	if x != nil {
+		return x.ShapeType
+	}
+	return ""
+}
+
+func (x *Tan) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *Tan) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Tan) GetRotation() uint32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+func (x *Tan) GetClock() uint64 {
+	if x != nil {
+		return x.Clock
+	}
+	return 0
+}
+
+func (x *Tan) GetVector() map[int64]uint64 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+func (x *Tan) GetClaimed() uint64 {
+	if x != nil {
+		return x.Claimed
+	}
+	return 0
+}
+
+func (x *Tan) GetExpiry() int64 {
+	if x != nil {
+		return x.Expiry
+	}
+	return 0
+}
+
+func (x *Tan) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+type TargetTan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shape         *Shape                 `protobuf:"bytes,1,opt,name=shape,proto3" json:"shape,omitempty"`
+	ShapeType     string                 `protobuf:"bytes,2,opt,name=shape_type,json=shapeType,proto3" json:"shape_type,omitempty"`
+	Location      *Point                 `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Rotation      uint32                 `protobuf:"varint,4,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TargetTan) Reset() {
+	*x = TargetTan{}
+	mi := &file_tangram_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TargetTan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TargetTan) ProtoMessage() {}
+
+func (x *TargetTan) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TargetTan.ProtoReflect.Descriptor instead.
+func (*TargetTan) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TargetTan) GetShape() *Shape {
+	if x != nil {
+		return x.Shape
+	}
+	return nil
+}
+
+func (x *TargetTan) GetShapeType() string {
+	if x != nil {
+		return x.ShapeType
+	}
+	return ""
+}
+
+func (x *TargetTan) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *TargetTan) GetRotation() uint32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+type Player struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Addr  string                 `protobuf:"bytes,3,opt,name=addr,proto3" json:"addr,omitempty"`
+	// Hash of the session token of the player
+	Session       string `protobuf:"bytes,4,opt,name=session,proto3" json:"session,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Player) Reset() {
+	*x = Player{}
+	mi := &file_tangram_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Player) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Player) ProtoMessage() {}
+
+func (x *Player) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Player.ProtoReflect.Descriptor instead.
+func (*Player) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Player) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Player) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Player) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Player) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+type GameState struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tans  []*Tan                 `protobuf:"bytes,1,rep,name=tans,proto3" json:"tans,omitempty"`
+	// Nanoseconds since the unix epoch at which the game started
+	Timer   int64     `protobuf:"varint,2,opt,name=timer,proto3" json:"timer,omitempty"`
+	Players []*Player `protobuf:"bytes,3,rep,name=players,proto3" json:"players,omitempty"`
+	// The host of the game, -1 if the game is not hosted
+	Host   int64 `protobuf:"varint,4,opt,name=host,proto3" json:"host,omitempty"`
+	Solved bool  `protobuf:"varint,5,opt,name=solved,proto3" json:"solved,omitempty"`
+	// Progress of the last host election: running, terminated, or empty if none was held
+	Election string `protobuf:"bytes,6,opt,name=election,proto3" json:"election,omitempty"`
+	// Term of the last host election
+	Term uint64 `protobuf:"varint,7,opt,name=term,proto3" json:"term,omitempty"`
+	// Relay of each player in a tiered game
+	Relays        *RelayPlan `protobuf:"bytes,8,opt,name=relays,proto3" json:"relays,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GameState) Reset() {
+	*x = GameState{}
+	mi := &file_tangram_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameState) ProtoMessage() {}
+
+func (x *GameState) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameState.ProtoReflect.Descriptor instead.
+func (*GameState) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GameState) GetTans() []*Tan {
+	if x != nil {
+		return x.Tans
+	}
+	return nil
+}
+
+func (x *GameState) GetTimer() int64 {
+	if x != nil {
+		return x.Timer
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayers() []*Player {
+	if x != nil {
+		return x.Players
+	}
+	return nil
+}
+
+func (x *GameState) GetHost() int64 {
+	if x != nil {
+		return x.Host
+	}
+	return 0
+}
+
+func (x *GameState) GetSolved() bool {
+	if x != nil {
+		return x.Solved
+	}
+	return false
+}
+
+func (x *GameState) GetElection() string {
+	if x != nil {
+		return x.Election
+	}
+	return ""
+}
+
+func (x *GameState) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *GameState) GetRelays() *RelayPlan {
+	if x != nil {
+		return x.Relays
+	}
+	return nil
+}
+
+type RelayPlan struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Planner int64                  `protobuf:"varint,1,opt,name=planner,proto3" json:"planner,omitempty"`
+	Version uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	// Relay of each player, a relay is its own relay
+	Relays        map[int64]int64 `protobuf:"bytes,3,rep,name=relays,proto3" json:"relays,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelayPlan) Reset() {
+	*x = RelayPlan{}
+	mi := &file_tangram_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayPlan) ProtoMessage() {}
+
+func (x *RelayPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayPlan.ProtoReflect.Descriptor instead.
+func (*RelayPlan) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RelayPlan) GetPlanner() int64 {
+	if x != nil {
+		return x.Planner
+	}
+	return 0
+}
+
+func (x *RelayPlan) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RelayPlan) GetRelays() map[int64]int64 {
+	if x != nil {
+		return x.Relays
+	}
+	return nil
+}
+
+type GameConfig struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Size    *Point                 `protobuf:"bytes,1,opt,name=size,proto3" json:"size,omitempty"`
+	Offset  *Point                 `protobuf:"bytes,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Margin  int32                  `protobuf:"varint,3,opt,name=margin,proto3" json:"margin,omitempty"`
+	Tans    []*Tan                 `protobuf:"bytes,4,rep,name=tans,proto3" json:"tans,omitempty"`
+	Targets []*TargetTan           `protobuf:"bytes,5,rep,name=targets,proto3" json:"targets,omitempty"`
+	Host    bool                   `protobuf:"varint,6,opt,name=host,proto3" json:"host,omitempty"`
+	// Suspicion at which a peer is evicted, 0 for the default
+	EvictionThreshold float64 `protobuf:"fixed64,7,opt,name=eviction_threshold,json=evictionThreshold,proto3" json:"eviction_threshold,omitempty"`
+	// Players replicating the events of a hosted game with Raft
+	Replicas []int64 `protobuf:"varint,8,rep,packed,name=replicas,proto3" json:"replicas,omitempty"`
+	// How host elections rank candidates: latency, uptime or capacity
+	HostPolicy string `protobuf:"bytes,9,opt,name=host_policy,json=hostPolicy,proto3" json:"host_policy,omitempty"`
+	// Players that win host elections whenever they are candidates, in order of preference
+	PinnedHosts []int64 `protobuf:"varint,10,rep,packed,name=pinned_hosts,json=pinnedHosts,proto3" json:"pinned_hosts,omitempty"`
+	// Whether players cluster under relays instead of meshing
+	Tiered bool `protobuf:"varint,11,opt,name=tiered,proto3" json:"tiered,omitempty"`
+	// Directory where replicas persist their raft state, in memory if empty
+	RaftDir       string `protobuf:"bytes,12,opt,name=raft_dir,json=raftDir,proto3" json:"raft_dir,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GameConfig) Reset() {
+	*x = GameConfig{}
+	mi := &file_tangram_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameConfig) ProtoMessage() {}
+
+func (x *GameConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameConfig.ProtoReflect.Descriptor instead.
+func (*GameConfig) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GameConfig) GetSize() *Point {
+	if x != nil {
+		return x.Size
+	}
+	return nil
+}
+
+func (x *GameConfig) GetOffset() *Point {
+	if x != nil {
+		return x.Offset
+	}
+	return nil
+}
+
+func (x *GameConfig) GetMargin() int32 {
+	if x != nil {
+		return x.Margin
+	}
+	return 0
+}
+
+func (x *GameConfig) GetTans() []*Tan {
+	if x != nil {
+		return x.Tans
+	}
+	return nil
+}
+
+func (x *GameConfig) GetTargets() []*TargetTan {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *GameConfig) GetHost() bool {
+	if x != nil {
+		return x.Host
+	}
+	return false
+}
+
+func (x *GameConfig) GetEvictionThreshold() float64 {
+	if x != nil {
+		return x.EvictionThreshold
+	}
+	return 0
+}
+
+func (x *GameConfig) GetReplicas() []int64 {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+func (x *GameConfig) GetHostPolicy() string {
+	if x != nil {
+		return x.HostPolicy
+	}
+	return ""
+}
+
+func (x *GameConfig) GetPinnedHosts() []int64 {
+	if x != nil {
+		return x.PinnedHosts
+	}
+	return nil
+}
+
+func (x *GameConfig) GetTiered() bool {
+	if x != nil {
+		return x.Tiered
+	}
+	return false
+}
+
+func (x *GameConfig) GetRaftDir() string {
+	if x != nil {
+		return x.RaftDir
+	}
+	return ""
+}
+
+type ConnectRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Player *Player                `protobuf:"bytes,1,opt,name=player,proto3" json:"player,omitempty"`
+	Stamp  *Timestamp             `protobuf:"bytes,2,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	// Session token of the player, which lets it rejoin with the same id
+	Token string `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	// Incarnation of the player in the membership protocol
+	Incarnation   uint64 `protobuf:"varint,4,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_tangram_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ConnectRequest) GetPlayer() *Player {
+	if x != nil {
+		return x.Player
+	}
+	return nil
+}
+
+func (x *ConnectRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *ConnectRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+type ConnectResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	State  *GameState             `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	Config *GameConfig            `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	Player *Player                `protobuf:"bytes,3,opt,name=player,proto3" json:"player,omitempty"`
+	Stamp  *Timestamp             `protobuf:"bytes,4,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	// Incarnation the node knows for the connecting player, which a rejoining player outlives
+	Incarnation   uint64 `protobuf:"varint,5,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectResponse) Reset() {
+	*x = ConnectResponse{}
+	mi := &file_tangram_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectResponse) ProtoMessage() {}
+
+func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ConnectResponse) GetState() *GameState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *ConnectResponse) GetConfig() *GameConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *ConnectResponse) GetPlayer() *Player {
+	if x != nil {
+		return x.Player
+	}
+	return nil
+}
+
+func (x *ConnectResponse) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *ConnectResponse) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+type LockTanRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tan    uint32                 `protobuf:"varint,1,opt,name=tan,proto3" json:"tan,omitempty"`
+	Player int64                  `protobuf:"varint,2,opt,name=player,proto3" json:"player,omitempty"`
+	Time   uint64                 `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Vector map[int64]uint64       `protobuf:"bytes,4,rep,name=vector,proto3" json:"vector,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Stamp  *Timestamp             `protobuf:"bytes,5,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	// from is the player sending the request, the player itself or its relay
+	From          int64 `protobuf:"varint,6,opt,name=from,proto3" json:"from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockTanRequest) Reset() {
+	*x = LockTanRequest{}
+	mi := &file_tangram_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockTanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockTanRequest) ProtoMessage() {}
+
+func (x *LockTanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockTanRequest.ProtoReflect.Descriptor instead.
+func (*LockTanRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LockTanRequest) GetTan() uint32 {
+	if x != nil {
+		return x.Tan
+	}
+	return 0
+}
+
+func (x *LockTanRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *LockTanRequest) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *LockTanRequest) GetVector() map[int64]uint64 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+func (x *LockTanRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *LockTanRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+type LockTanResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ok    bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	// The clocks of the tan when the reply was sent
+	Time          uint64           `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	Vector        map[int64]uint64 `protobuf:"bytes,3,rep,name=vector,proto3" json:"vector,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockTanResponse) Reset() {
+	*x = LockTanResponse{}
+	mi := &file_tangram_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockTanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockTanResponse) ProtoMessage() {}
+
+func (x *LockTanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockTanResponse.ProtoReflect.Descriptor instead.
+func (*LockTanResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LockTanResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *LockTanResponse) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *LockTanResponse) GetVector() map[int64]uint64 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+type MoveTanRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Tan      uint32                 `protobuf:"varint,1,opt,name=tan,proto3" json:"tan,omitempty"`
+	Player   int64                  `protobuf:"varint,2,opt,name=player,proto3" json:"player,omitempty"`
+	Location *Point                 `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Rotation uint32                 `protobuf:"varint,4,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	Time     uint64                 `protobuf:"varint,5,opt,name=time,proto3" json:"time,omitempty"`
+	Vector   map[int64]uint64       `protobuf:"bytes,6,rep,name=vector,proto3" json:"vector,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Nanoseconds since the unix epoch at which the renewed lease lapses
+	Expiry int64      `protobuf:"varint,7,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	Stamp  *Timestamp `protobuf:"bytes,8,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	// from is the player sending the move, the player itself or a relay forwarding it
+	From          int64 `protobuf:"varint,9,opt,name=from,proto3" json:"from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveTanRequest) Reset() {
+	*x = MoveTanRequest{}
+	mi := &file_tangram_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveTanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveTanRequest) ProtoMessage() {}
+
+func (x *MoveTanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveTanRequest.ProtoReflect.Descriptor instead.
+func (*MoveTanRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MoveTanRequest) GetTan() uint32 {
+	if x != nil {
+		return x.Tan
+	}
+	return 0
+}
+
+func (x *MoveTanRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *MoveTanRequest) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *MoveTanRequest) GetRotation() uint32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+func (x *MoveTanRequest) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *MoveTanRequest) GetVector() map[int64]uint64 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+func (x *MoveTanRequest) GetExpiry() int64 {
+	if x != nil {
+		return x.Expiry
+	}
+	return 0
+}
+
+func (x *MoveTanRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *MoveTanRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+type MoveBatchRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Player int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	// The latest move of each tan, in the order they were first made
+	Moves         []*MoveTanRequest `protobuf:"bytes,2,rep,name=moves,proto3" json:"moves,omitempty"`
+	Stamp         *Timestamp        `protobuf:"bytes,3,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveBatchRequest) Reset() {
+	*x = MoveBatchRequest{}
+	mi := &file_tangram_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveBatchRequest) ProtoMessage() {}
+
+func (x *MoveBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveBatchRequest.ProtoReflect.Descriptor instead.
+func (*MoveBatchRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MoveBatchRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *MoveBatchRequest) GetMoves() []*MoveTanRequest {
+	if x != nil {
+		return x.Moves
+	}
+	return nil
+}
+
+func (x *MoveBatchRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type MoveBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether each move of the batch was applied
+	Moved         []bool `protobuf:"varint,1,rep,packed,name=moved,proto3" json:"moved,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveBatchResponse) Reset() {
+	*x = MoveBatchResponse{}
+	mi := &file_tangram_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveBatchResponse) ProtoMessage() {}
+
+func (x *MoveBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveBatchResponse.ProtoReflect.Descriptor instead.
+func (*MoveBatchResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *MoveBatchResponse) GetMoved() []bool {
+	if x != nil {
+		return x.Moved
+	}
+	return nil
+}
+
+type UpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	State         *GameState             `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Stamp         *Timestamp             `protobuf:"bytes,4,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_tangram_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *UpdateRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetState() *GameState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type DeltaRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Player  int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Base    uint64                 `protobuf:"varint,2,opt,name=base,proto3" json:"base,omitempty"`
+	Version uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// Only the changed tans, and the players if they changed
+	Delta         *GameState `protobuf:"bytes,4,opt,name=delta,proto3" json:"delta,omitempty"`
+	Stamp         *Timestamp `protobuf:"bytes,5,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeltaRequest) Reset() {
+	*x = DeltaRequest{}
+	mi := &file_tangram_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeltaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeltaRequest) ProtoMessage() {}
+
+func (x *DeltaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeltaRequest.ProtoReflect.Descriptor instead.
+func (*DeltaRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DeltaRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *DeltaRequest) GetBase() uint64 {
+	if x != nil {
+		return x.Base
+	}
+	return 0
+}
+
+func (x *DeltaRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *DeltaRequest) GetDelta() *GameState {
+	if x != nil {
+		return x.Delta
+	}
+	return nil
+}
+
+func (x *DeltaRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Stamp         *Timestamp             `protobuf:"bytes,2,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_tangram_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PingRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *PingRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type PingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stamp         *Timestamp             `protobuf:"bytes,1,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_tangram_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingResponse) ProtoMessage() {}
+
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PingResponse) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type GetLatencyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatencyRequest) Reset() {
+	*x = GetLatencyRequest{}
+	mi := &file_tangram_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatencyRequest) ProtoMessage() {}
+
+func (x *GetLatencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatencyRequest.ProtoReflect.Descriptor instead.
+func (*GetLatencyRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{20}
+}
+
+type GetLatencyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Nanoseconds
+	Latency       int64 `protobuf:"varint,1,opt,name=latency,proto3" json:"latency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatencyResponse) Reset() {
+	*x = GetLatencyResponse{}
+	mi := &file_tangram_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatencyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatencyResponse) ProtoMessage() {}
+
+func (x *GetLatencyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatencyResponse.ProtoReflect.Descriptor instead.
+func (*GetLatencyResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetLatencyResponse) GetLatency() int64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+type HostElectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostElectionRequest) Reset() {
+	*x = HostElectionRequest{}
+	mi := &file_tangram_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostElectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostElectionRequest) ProtoMessage() {}
+
+func (x *HostElectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostElectionRequest.ProtoReflect.Descriptor instead.
+func (*HostElectionRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{22}
+}
+
+type ConnectToMeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          int64                  `protobuf:"varint,1,opt,name=host,proto3" json:"host,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectToMeRequest) Reset() {
+	*x = ConnectToMeRequest{}
+	mi := &file_tangram_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectToMeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectToMeRequest) ProtoMessage() {}
+
+func (x *ConnectToMeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectToMeRequest.ProtoReflect.Descriptor instead.
+func (*ConnectToMeRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ConnectToMeRequest) GetHost() int64 {
+	if x != nil {
+		return x.Host
+	}
+	return 0
+}
+
+type GetCandidateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCandidateRequest) Reset() {
+	*x = GetCandidateRequest{}
+	mi := &file_tangram_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCandidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCandidateRequest) ProtoMessage() {}
+
+func (x *GetCandidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCandidateRequest.ProtoReflect.Descriptor instead.
+func (*GetCandidateRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{24}
+}
+
+type Candidate struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Player int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	// Average latency of the node to its peers in nanoseconds
+	Latency int64 `protobuf:"varint,2,opt,name=latency,proto3" json:"latency,omitempty"`
+	// Nanoseconds since the node joined the game
+	Uptime int64 `protobuf:"varint,3,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	// Spare bandwidth in kbit/s
+	Capacity      int64 `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Candidate) Reset() {
+	*x = Candidate{}
+	mi := &file_tangram_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Candidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Candidate) ProtoMessage() {}
+
+func (x *Candidate) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Candidate.ProtoReflect.Descriptor instead.
+func (*Candidate) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Candidate) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *Candidate) GetLatency() int64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+func (x *Candidate) GetUptime() int64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *Candidate) GetCapacity() int64 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+type ElectionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The candidate holding the election
+	Player int64  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Term   uint64 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	// Average latency of the candidate in nanoseconds
+	Latency int64      `protobuf:"varint,3,opt,name=latency,proto3" json:"latency,omitempty"`
+	Stamp   *Timestamp `protobuf:"bytes,4,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	// Nanoseconds since the candidate joined the game
+	Uptime int64 `protobuf:"varint,5,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	// Spare bandwidth of the candidate in kbit/s
+	Capacity      int64 `protobuf:"varint,6,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ElectionRequest) Reset() {
+	*x = ElectionRequest{}
+	mi := &file_tangram_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ElectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ElectionRequest) ProtoMessage() {}
+
+func (x *ElectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ElectionRequest.ProtoReflect.Descriptor instead.
+func (*ElectionRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ElectionRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *ElectionRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *ElectionRequest) GetLatency() int64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+func (x *ElectionRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *ElectionRequest) GetUptime() int64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *ElectionRequest) GetCapacity() int64 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+type ElectionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the node is a better candidate and takes over the election
+	Answer        bool       `protobuf:"varint,1,opt,name=answer,proto3" json:"answer,omitempty"`
+	Stamp         *Timestamp `protobuf:"bytes,2,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ElectionResponse) Reset() {
+	*x = ElectionResponse{}
+	mi := &file_tangram_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ElectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ElectionResponse) ProtoMessage() {}
+
+func (x *ElectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ElectionResponse.ProtoReflect.Descriptor instead.
+func (*ElectionResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ElectionResponse) GetAnswer() bool {
+	if x != nil {
+		return x.Answer
+	}
+	return false
+}
+
+func (x *ElectionResponse) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type CoordinatorRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The winner of the election
+	Player        int64      `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Term          uint64     `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	Stamp         *Timestamp `protobuf:"bytes,3,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoordinatorRequest) Reset() {
+	*x = CoordinatorRequest{}
+	mi := &file_tangram_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoordinatorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoordinatorRequest) ProtoMessage() {}
+
+func (x *CoordinatorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoordinatorRequest.ProtoReflect.Descriptor instead.
+func (*CoordinatorRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *CoordinatorRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *CoordinatorRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *CoordinatorRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type MemberUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        *Player                `protobuf:"bytes,1,opt,name=player,proto3" json:"player,omitempty"`
+	Status        MemberStatus           `protobuf:"varint,2,opt,name=status,proto3,enum=tangram.v1.MemberStatus" json:"status,omitempty"`
+	Incarnation   uint64                 `protobuf:"varint,3,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemberUpdate) Reset() {
+	*x = MemberUpdate{}
+	mi := &file_tangram_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberUpdate) ProtoMessage() {}
+
+func (x *MemberUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberUpdate.ProtoReflect.Descriptor instead.
+func (*MemberUpdate) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *MemberUpdate) GetPlayer() *Player {
+	if x != nil {
+		return x.Player
+	}
+	return nil
+}
+
+func (x *MemberUpdate) GetStatus() MemberStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MemberStatus_MEMBER_ALIVE
+}
+
+func (x *MemberUpdate) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+type Link struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  int64                  `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To    int64                  `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	// Mean round trip time in nanoseconds
+	Rtt int64 `protobuf:"varint,3,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	// Mean difference between consecutive round trip times in nanoseconds
+	Jitter int64 `protobuf:"varint,4,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	// Fraction of round trips that were not answered
+	Loss          float64 `protobuf:"fixed64,5,opt,name=loss,proto3" json:"loss,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_tangram_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *Link) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *Link) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *Link) GetRtt() int64 {
+	if x != nil {
+		return x.Rtt
+	}
+	return 0
+}
+
+func (x *Link) GetJitter() int64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+func (x *Link) GetLoss() float64 {
+	if x != nil {
+		return x.Loss
+	}
+	return 0
+}
+
+type LinkReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Links         []*Link                `protobuf:"bytes,3,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkReport) Reset() {
+	*x = LinkReport{}
+	mi := &file_tangram_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkReport) ProtoMessage() {}
+
+func (x *LinkReport) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkReport.ProtoReflect.Descriptor instead.
+func (*LinkReport) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *LinkReport) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *LinkReport) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *LinkReport) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type GossipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Updates       []*MemberUpdate        `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	Stamp         *Timestamp             `protobuf:"bytes,3,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	Links         []*LinkReport          `protobuf:"bytes,4,rep,name=links,proto3" json:"links,omitempty"`
+	Relays        *RelayPlan             `protobuf:"bytes,5,opt,name=relays,proto3" json:"relays,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GossipRequest) Reset() {
+	*x = GossipRequest{}
+	mi := &file_tangram_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GossipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipRequest) ProtoMessage() {}
+
+func (x *GossipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipRequest.ProtoReflect.Descriptor instead.
+func (*GossipRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GossipRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *GossipRequest) GetUpdates() []*MemberUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+func (x *GossipRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *GossipRequest) GetLinks() []*LinkReport {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *GossipRequest) GetRelays() *RelayPlan {
+	if x != nil {
+		return x.Relays
+	}
+	return nil
+}
+
+type GossipResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Updates       []*MemberUpdate        `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"`
+	Stamp         *Timestamp             `protobuf:"bytes,2,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	Links         []*LinkReport          `protobuf:"bytes,3,rep,name=links,proto3" json:"links,omitempty"`
+	Relays        *RelayPlan             `protobuf:"bytes,4,opt,name=relays,proto3" json:"relays,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GossipResponse) Reset() {
+	*x = GossipResponse{}
+	mi := &file_tangram_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GossipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipResponse) ProtoMessage() {}
+
+func (x *GossipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipResponse.ProtoReflect.Descriptor instead.
+func (*GossipResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *GossipResponse) GetUpdates() []*MemberUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+func (x *GossipResponse) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *GossipResponse) GetLinks() []*LinkReport {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *GossipResponse) GetRelays() *RelayPlan {
+	if x != nil {
+		return x.Relays
+	}
+	return nil
+}
+
+type ProbeRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Player int64                  `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	// The member to probe on behalf of player
+	Target        int64           `protobuf:"varint,2,opt,name=target,proto3" json:"target,omitempty"`
+	Updates       []*MemberUpdate `protobuf:"bytes,3,rep,name=updates,proto3" json:"updates,omitempty"`
+	Stamp         *Timestamp      `protobuf:"bytes,4,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeRequest) Reset() {
+	*x = ProbeRequest{}
+	mi := &file_tangram_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeRequest) ProtoMessage() {}
+
+func (x *ProbeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeRequest.ProtoReflect.Descriptor instead.
+func (*ProbeRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ProbeRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *ProbeRequest) GetTarget() int64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+func (x *ProbeRequest) GetUpdates() []*MemberUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+func (x *ProbeRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type LeaveRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Player *Player                `protobuf:"bytes,1,opt,name=player,proto3" json:"player,omitempty"`
+	// Incarnation of the player in the membership protocol
+	Incarnation   uint64     `protobuf:"varint,2,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	Stamp         *Timestamp `protobuf:"bytes,3,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveRequest) Reset() {
+	*x = LeaveRequest{}
+	mi := &file_tangram_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveRequest) ProtoMessage() {}
+
+func (x *LeaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveRequest.ProtoReflect.Descriptor instead.
+func (*LeaveRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *LeaveRequest) GetPlayer() *Player {
+	if x != nil {
+		return x.Player
+	}
+	return nil
+}
+
+func (x *LeaveRequest) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+func (x *LeaveRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+// Event is an operation on a tan
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// grab, move, rotate or release, empty for a no-op
+	Kind   string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Tan    uint32 `protobuf:"varint,2,opt,name=tan,proto3" json:"tan,omitempty"`
+	Player int64  `protobuf:"varint,3,opt,name=player,proto3" json:"player,omitempty"`
+	// Lamport time of the tan
+	Time     uint64 `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	Location *Point `protobuf:"bytes,5,opt,name=location,proto3" json:"location,omitempty"`
+	Rotation uint32 `protobuf:"varint,6,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	Claimed  uint64 `protobuf:"varint,7,opt,name=claimed,proto3" json:"claimed,omitempty"`
+	// Nanoseconds since the unix epoch
+	Expiry        int64 `protobuf:"varint,8,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_tangram_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Event) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Event) GetTan() uint32 {
+	if x != nil {
+		return x.Tan
+	}
+	return 0
+}
+
+func (x *Event) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *Event) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Event) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Event) GetRotation() uint32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+func (x *Event) GetClaimed() uint64 {
+	if x != nil {
+		return x.Claimed
+	}
+	return 0
+}
+
+func (x *Event) GetExpiry() int64 {
+	if x != nil {
+		return x.Expiry
+	}
+	return 0
+}
+
+type LogEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Raft term of the leader that appended the entry
+	Term          uint64 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Event         *Event `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_tangram_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *LogEntry) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *LogEntry) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type VoteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The replica standing for election
+	Player        int64      `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Term          uint64     `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	LastIndex     uint64     `protobuf:"varint,3,opt,name=last_index,json=lastIndex,proto3" json:"last_index,omitempty"`
+	LastTerm      uint64     `protobuf:"varint,4,opt,name=last_term,json=lastTerm,proto3" json:"last_term,omitempty"`
+	Stamp         *Timestamp `protobuf:"bytes,5,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoteRequest) Reset() {
+	*x = VoteRequest{}
+	mi := &file_tangram_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteRequest) ProtoMessage() {}
+
+func (x *VoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteRequest.ProtoReflect.Descriptor instead.
+func (*VoteRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *VoteRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetLastIndex() uint64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetLastTerm() uint64 {
+	if x != nil {
+		return x.LastTerm
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type VoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Granted       bool                   `protobuf:"varint,2,opt,name=granted,proto3" json:"granted,omitempty"`
+	Stamp         *Timestamp             `protobuf:"bytes,3,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoteResponse) Reset() {
+	*x = VoteResponse{}
+	mi := &file_tangram_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteResponse) ProtoMessage() {}
+
+func (x *VoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteResponse.ProtoReflect.Descriptor instead.
+func (*VoteResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *VoteResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *VoteResponse) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+func (x *VoteResponse) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type RaftSnapshot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The last entry compacted
+	Index uint64 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Term  uint64 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	// The fewest events that fold into the same tans as the compacted entries
+	Events        []*Event `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftSnapshot) Reset() {
+	*x = RaftSnapshot{}
+	mi := &file_tangram_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftSnapshot) ProtoMessage() {}
+
+func (x *RaftSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftSnapshot.ProtoReflect.Descriptor instead.
+func (*RaftSnapshot) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *RaftSnapshot) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RaftSnapshot) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftSnapshot) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type AppendRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The leader
+	Player    int64       `protobuf:"varint,1,opt,name=player,proto3" json:"player,omitempty"`
+	Term      uint64      `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	PrevIndex uint64      `protobuf:"varint,3,opt,name=prev_index,json=prevIndex,proto3" json:"prev_index,omitempty"`
+	PrevTerm  uint64      `protobuf:"varint,4,opt,name=prev_term,json=prevTerm,proto3" json:"prev_term,omitempty"`
+	Entries   []*LogEntry `protobuf:"bytes,5,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Index of the last entry the leader knows to be committed
+	Commit uint64     `protobuf:"varint,6,opt,name=commit,proto3" json:"commit,omitempty"`
+	Stamp  *Timestamp `protobuf:"bytes,7,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	// Sent to a replica that misses entries the leader compacted
+	Snapshot      *RaftSnapshot `protobuf:"bytes,8,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	mi := &file_tangram_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *AppendRequest) GetPlayer() int64 {
+	if x != nil {
+		return x.Player
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetPrevIndex() uint64 {
+	if x != nil {
+		return x.PrevIndex
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetPrevTerm() uint64 {
+	if x != nil {
+		return x.PrevTerm
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetEntries() []*LogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AppendRequest) GetCommit() uint64 {
+	if x != nil {
+		return x.Commit
+	}
+	return 0
+}
+
+func (x *AppendRequest) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+func (x *AppendRequest) GetSnapshot() *RaftSnapshot {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+type AppendResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Term    uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	// Last index known to match the log of the leader
+	Match         uint64     `protobuf:"varint,3,opt,name=match,proto3" json:"match,omitempty"`
+	Stamp         *Timestamp `protobuf:"bytes,4,opt,name=stamp,proto3" json:"stamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendResponse) Reset() {
+	*x = AppendResponse{}
+	mi := &file_tangram_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendResponse) ProtoMessage() {}
+
+func (x *AppendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendResponse.ProtoReflect.Descriptor instead.
+func (*AppendResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *AppendResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AppendResponse) GetMatch() uint64 {
+	if x != nil {
+		return x.Match
+	}
+	return 0
+}
+
+func (x *AppendResponse) GetStamp() *Timestamp {
+	if x != nil {
+		return x.Stamp
+	}
+	return nil
+}
+
+type OkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OkResponse) Reset() {
+	*x = OkResponse{}
+	mi := &file_tangram_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OkResponse) ProtoMessage() {}
+
+func (x *OkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tangram_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OkResponse.ProtoReflect.Descriptor instead.
+func (*OkResponse) Descriptor() ([]byte, []int) {
+	return file_tangram_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *OkResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+var File_tangram_proto protoreflect.FileDescriptor
+
+const file_tangram_proto_rawDesc = "" +
+	"\n" +
+	"\rtangram.proto\x12\n" +
+	"tangram.v1\"9\n" +
+	"\tTimestamp\x12\x12\n" +
+	"\x04wall\x18\x01 \x01(\x03R\x04wall\x12\x18\n" +
+	"\alogical\x18\x02 \x01(\x04R\alogical\"#\n" +
+	"\x05Point\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\"^\n" +
+	"\x05Shape\x12)\n" +
+	"\x06points\x18\x01 \x03(\v2\x11.tangram.v1.PointR\x06points\x12\x12\n" +
+	"\x04fill\x18\x02 \x01(\tR\x04fill\x12\x16\n" +
+	"\x06stroke\x18\x03 \x01(\tR\x06stroke\"\x92\x03\n" +
+	"\x03Tan\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12'\n" +
+	"\x05shape\x18\x02 \x01(\v2\x11.tangram.v1.ShapeR\x05shape\x12\x1d\n" +
+	"\n" +
+	"shape_type\x18\x03 \x01(\tR\tshapeType\x12\x16\n" +
+	"\x06player\x18\x04 \x01(\x03R\x06player\x12-\n" +
+	"\blocation\x18\x05 \x01(\v2\x11.tangram.v1.PointR\blocation\x12\x1a\n" +
+	"\brotation\x18\x06 \x01(\rR\brotation\x12\x14\n" +
+	"\x05clock\x18\a \x01(\x04R\x05clock\x123\n" +
+	"\x06vector\x18\b \x03(\v2\x1b.tangram.v1.Tan.VectorEntryR\x06vector\x12\x18\n" +
+	"\aclaimed\x18\t \x01(\x04R\aclaimed\x12\x16\n" +
+	"\x06expiry\x18\n" +
+	" \x01(\x03R\x06expiry\x12\x18\n" +
+	"\amatched\x18\v \x01(\bR\amatched\x1a9\n" +
+	"\vVectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\x9e\x01\n" +
+	"\tTargetTan\x12'\n" +
+	"\x05shape\x18\x01 \x01(\v2\x11.tangram.v1.ShapeR\x05shape\x12\x1d\n" +
+	"\n" +
+	"shape_type\x18\x02 \x01(\tR\tshapeType\x12-\n" +
+	"\blocation\x18\x03 \x01(\v2\x11.tangram.v1.PointR\blocation\x12\x1a\n" +
+	"\brotation\x18\x04 \x01(\rR\brotation\"Z\n" +
+	"\x06Player\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04addr\x18\x03 \x01(\tR\x04addr\x12\x18\n" +
+	"\asession\x18\x04 \x01(\tR\asession\"\xff\x01\n" +
+	"\tGameState\x12#\n" +
+	"\x04tans\x18\x01 \x03(\v2\x0f.tangram.v1.TanR\x04tans\x12\x14\n" +
+	"\x05timer\x18\x02 \x01(\x03R\x05timer\x12,\n" +
+	"\aplayers\x18\x03 \x03(\v2\x12.tangram.v1.PlayerR\aplayers\x12\x12\n" +
+	"\x04host\x18\x04 \x01(\x03R\x04host\x12\x16\n" +
+	"\x06solved\x18\x05 \x01(\bR\x06solved\x12\x1a\n" +
+	"\belection\x18\x06 \x01(\tR\belection\x12\x12\n" +
+	"\x04term\x18\a \x01(\x04R\x04term\x12-\n" +
+	"\x06relays\x18\b \x01(\v2\x15.tangram.v1.RelayPlanR\x06relays\"\xb5\x01\n" +
+	"\tRelayPlan\x12\x18\n" +
+	"\aplanner\x18\x01 \x01(\x03R\aplanner\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x129\n" +
+	"\x06relays\x18\x03 \x03(\v2!.tangram.v1.RelayPlan.RelaysEntryR\x06relays\x1a9\n" +
+	"\vRelaysEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\xa2\x03\n" +
+	"\n" +
+	"GameConfig\x12%\n" +
+	"\x04size\x18\x01 \x01(\v2\x11.tangram.v1.PointR\x04size\x12)\n" +
+	"\x06offset\x18\x02 \x01(\v2\x11.tangram.v1.PointR\x06offset\x12\x16\n" +
+	"\x06margin\x18\x03 \x01(\x05R\x06margin\x12#\n" +
+	"\x04tans\x18\x04 \x03(\v2\x0f.tangram.v1.TanR\x04tans\x12/\n" +
+	"\atargets\x18\x05 \x03(\v2\x15.tangram.v1.TargetTanR\atargets\x12\x12\n" +
+	"\x04host\x18\x06 \x01(\bR\x04host\x12-\n" +
+	"\x12eviction_threshold\x18\a \x01(\x01R\x11evictionThreshold\x12\x1a\n" +
+	"\breplicas\x18\b \x03(\x03R\breplicas\x12\x1f\n" +
+	"\vhost_policy\x18\t \x01(\tR\n" +
+	"hostPolicy\x12!\n" +
+	"\fpinned_hosts\x18\n" +
+	" \x03(\x03R\vpinnedHosts\x12\x16\n" +
+	"\x06tiered\x18\v \x01(\bR\x06tiered\x12\x19\n" +
+	"\braft_dir\x18\f \x01(\tR\araftDir\"\xa1\x01\n" +
+	"\x0eConnectRequest\x12*\n" +
+	"\x06player\x18\x01 \x01(\v2\x12.tangram.v1.PlayerR\x06player\x12+\n" +
+	"\x05stamp\x18\x02 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x12 \n" +
+	"\vincarnation\x18\x04 \x01(\x04R\vincarnation\"\xe9\x01\n" +
+	"\x0fConnectResponse\x12+\n" +
+	"\x05state\x18\x01 \x01(\v2\x15.tangram.v1.GameStateR\x05state\x12.\n" +
+	"\x06config\x18\x02 \x01(\v2\x16.tangram.v1.GameConfigR\x06config\x12*\n" +
+	"\x06player\x18\x03 \x01(\v2\x12.tangram.v1.PlayerR\x06player\x12+\n" +
+	"\x05stamp\x18\x04 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12 \n" +
+	"\vincarnation\x18\x05 \x01(\x04R\vincarnation\"\x8a\x02\n" +
+	"\x0eLockTanRequest\x12\x10\n" +
+	"\x03tan\x18\x01 \x01(\rR\x03tan\x12\x16\n" +
+	"\x06player\x18\x02 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\x04R\x04time\x12>\n" +
+	"\x06vector\x18\x04 \x03(\v2&.tangram.v1.LockTanRequest.VectorEntryR\x06vector\x12+\n" +
+	"\x05stamp\x18\x05 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12\x12\n" +
+	"\x04from\x18\x06 \x01(\x03R\x04from\x1a9\n" +
+	"\vVectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\xb1\x01\n" +
+	"\x0fLockTanResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\x04R\x04time\x12?\n" +
+	"\x06vector\x18\x03 \x03(\v2'.tangram.v1.LockTanResponse.VectorEntryR\x06vector\x1a9\n" +
+	"\vVectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\xed\x02\n" +
+	"\x0eMoveTanRequest\x12\x10\n" +
+	"\x03tan\x18\x01 \x01(\rR\x03tan\x12\x16\n" +
+	"\x06player\x18\x02 \x01(\x03R\x06player\x12-\n" +
+	"\blocation\x18\x03 \x01(\v2\x11.tangram.v1.PointR\blocation\x12\x1a\n" +
+	"\brotation\x18\x04 \x01(\rR\brotation\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\x04R\x04time\x12>\n" +
+	"\x06vector\x18\x06 \x03(\v2&.tangram.v1.MoveTanRequest.VectorEntryR\x06vector\x12\x16\n" +
+	"\x06expiry\x18\a \x01(\x03R\x06expiry\x12+\n" +
+	"\x05stamp\x18\b \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12\x12\n" +
+	"\x04from\x18\t \x01(\x03R\x04from\x1a9\n" +
+	"\vVectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\x89\x01\n" +
+	"\x10MoveBatchRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x120\n" +
+	"\x05moves\x18\x02 \x03(\v2\x1a.tangram.v1.MoveTanRequestR\x05moves\x12+\n" +
+	"\x05stamp\x18\x03 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\")\n" +
+	"\x11MoveBatchResponse\x12\x14\n" +
+	"\x05moved\x18\x01 \x03(\bR\x05moved\"\x9b\x01\n" +
+	"\rUpdateRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12+\n" +
+	"\x05state\x18\x03 \x01(\v2\x15.tangram.v1.GameStateR\x05state\x12+\n" +
+	"\x05stamp\x18\x04 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"\xae\x01\n" +
+	"\fDeltaRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04base\x18\x02 \x01(\x04R\x04base\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\x12+\n" +
+	"\x05delta\x18\x04 \x01(\v2\x15.tangram.v1.GameStateR\x05delta\x12+\n" +
+	"\x05stamp\x18\x05 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"R\n" +
+	"\vPingRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12+\n" +
+	"\x05stamp\x18\x02 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\";\n" +
+	"\fPingResponse\x12+\n" +
+	"\x05stamp\x18\x01 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"\x13\n" +
+	"\x11GetLatencyRequest\".\n" +
+	"\x12GetLatencyResponse\x12\x18\n" +
+	"\alatency\x18\x01 \x01(\x03R\alatency\"\x15\n" +
+	"\x13HostElectionRequest\"(\n" +
+	"\x12ConnectToMeRequest\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\x03R\x04host\"\x15\n" +
+	"\x13GetCandidateRequest\"q\n" +
+	"\tCandidate\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x18\n" +
+	"\alatency\x18\x02 \x01(\x03R\alatency\x12\x16\n" +
+	"\x06uptime\x18\x03 \x01(\x03R\x06uptime\x12\x1a\n" +
+	"\bcapacity\x18\x04 \x01(\x03R\bcapacity\"\xb8\x01\n" +
+	"\x0fElectionRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x18\n" +
+	"\alatency\x18\x03 \x01(\x03R\alatency\x12+\n" +
+	"\x05stamp\x18\x04 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12\x16\n" +
+	"\x06uptime\x18\x05 \x01(\x03R\x06uptime\x12\x1a\n" +
+	"\bcapacity\x18\x06 \x01(\x03R\bcapacity\"W\n" +
+	"\x10ElectionResponse\x12\x16\n" +
+	"\x06answer\x18\x01 \x01(\bR\x06answer\x12+\n" +
+	"\x05stamp\x18\x02 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"m\n" +
+	"\x12CoordinatorRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12+\n" +
+	"\x05stamp\x18\x03 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"\x8e\x01\n" +
+	"\fMemberUpdate\x12*\n" +
+	"\x06player\x18\x01 \x01(\v2\x12.tangram.v1.PlayerR\x06player\x120\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x18.tangram.v1.MemberStatusR\x06status\x12 \n" +
+	"\vincarnation\x18\x03 \x01(\x04R\vincarnation\"h\n" +
+	"\x04Link\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\x03R\x02to\x12\x10\n" +
+	"\x03rtt\x18\x03 \x01(\x03R\x03rtt\x12\x16\n" +
+	"\x06jitter\x18\x04 \x01(\x03R\x06jitter\x12\x12\n" +
+	"\x04loss\x18\x05 \x01(\x01R\x04loss\"f\n" +
+	"\n" +
+	"LinkReport\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12&\n" +
+	"\x05links\x18\x03 \x03(\v2\x10.tangram.v1.LinkR\x05links\"\xe5\x01\n" +
+	"\rGossipRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x122\n" +
+	"\aupdates\x18\x02 \x03(\v2\x18.tangram.v1.MemberUpdateR\aupdates\x12+\n" +
+	"\x05stamp\x18\x03 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12,\n" +
+	"\x05links\x18\x04 \x03(\v2\x16.tangram.v1.LinkReportR\x05links\x12-\n" +
+	"\x06relays\x18\x05 \x01(\v2\x15.tangram.v1.RelayPlanR\x06relays\"\xce\x01\n" +
+	"\x0eGossipResponse\x122\n" +
+	"\aupdates\x18\x01 \x03(\v2\x18.tangram.v1.MemberUpdateR\aupdates\x12+\n" +
+	"\x05stamp\x18\x02 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x12,\n" +
+	"\x05links\x18\x03 \x03(\v2\x16.tangram.v1.LinkReportR\x05links\x12-\n" +
+	"\x06relays\x18\x04 \x01(\v2\x15.tangram.v1.RelayPlanR\x06relays\"\x9f\x01\n" +
+	"\fProbeRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\x03R\x06target\x122\n" +
+	"\aupdates\x18\x03 \x03(\v2\x18.tangram.v1.MemberUpdateR\aupdates\x12+\n" +
+	"\x05stamp\x18\x04 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"\x89\x01\n" +
+	"\fLeaveRequest\x12*\n" +
+	"\x06player\x18\x01 \x01(\v2\x12.tangram.v1.PlayerR\x06player\x12 \n" +
+	"\vincarnation\x18\x02 \x01(\x04R\vincarnation\x12+\n" +
+	"\x05stamp\x18\x03 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"\xd6\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x10\n" +
+	"\x03tan\x18\x02 \x01(\rR\x03tan\x12\x16\n" +
+	"\x06player\x18\x03 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04time\x18\x04 \x01(\x04R\x04time\x12-\n" +
+	"\blocation\x18\x05 \x01(\v2\x11.tangram.v1.PointR\blocation\x12\x1a\n" +
+	"\brotation\x18\x06 \x01(\rR\brotation\x12\x18\n" +
+	"\aclaimed\x18\a \x01(\x04R\aclaimed\x12\x16\n" +
+	"\x06expiry\x18\b \x01(\x03R\x06expiry\"G\n" +
+	"\bLogEntry\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12'\n" +
+	"\x05event\x18\x02 \x01(\v2\x11.tangram.v1.EventR\x05event\"\xa2\x01\n" +
+	"\vVoteRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x1d\n" +
+	"\n" +
+	"last_index\x18\x03 \x01(\x04R\tlastIndex\x12\x1b\n" +
+	"\tlast_term\x18\x04 \x01(\x04R\blastTerm\x12+\n" +
+	"\x05stamp\x18\x05 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"i\n" +
+	"\fVoteResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\agranted\x18\x02 \x01(\bR\agranted\x12+\n" +
+	"\x05stamp\x18\x03 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"c\n" +
+	"\fRaftSnapshot\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x04R\x05index\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12)\n" +
+	"\x06events\x18\x03 \x03(\v2\x11.tangram.v1.EventR\x06events\"\xa2\x02\n" +
+	"\rAppendRequest\x12\x16\n" +
+	"\x06player\x18\x01 \x01(\x03R\x06player\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x1d\n" +
+	"\n" +
+	"prev_index\x18\x03 \x01(\x04R\tprevIndex\x12\x1b\n" +
+	"\tprev_term\x18\x04 \x01(\x04R\bprevTerm\x12.\n" +
+	"\aentries\x18\x05 \x03(\v2\x14.tangram.v1.LogEntryR\aentries\x12\x16\n" +
+	"\x06commit\x18\x06 \x01(\x04R\x06commit\x12+\n" +
+	"\x05stamp\x18\a \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\x124\n" +
+	"\bsnapshot\x18\b \x01(\v2\x18.tangram.v1.RaftSnapshotR\bsnapshot\"\x81\x01\n" +
+	"\x0eAppendResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05match\x18\x03 \x01(\x04R\x05match\x12+\n" +
+	"\x05stamp\x18\x04 \x01(\v2\x15.tangram.v1.TimestampR\x05stamp\"\x1c\n" +
+	"\n" +
+	"OkResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok*V\n" +
+	"\fMemberStatus\x12\x10\n" +
+	"\fMEMBER_ALIVE\x10\x00\x12\x12\n" +
+	"\x0eMEMBER_SUSPECT\x10\x01\x12\x0f\n" +
+	"\vMEMBER_DEAD\x10\x02\x12\x0f\n" +
+	"\vMEMBER_LEFT\x10\x032\x8c\n" +
+	"\n" +
+	"\x04Node\x12B\n" +
+	"\aConnect\x12\x1a.tangram.v1.ConnectRequest\x1a\x1b.tangram.v1.ConnectResponse\x12B\n" +
+	"\aLockTan\x12\x1a.tangram.v1.LockTanRequest\x1a\x1b.tangram.v1.LockTanResponse\x12?\n" +
+	"\tUnlockTan\x12\x1a.tangram.v1.LockTanRequest\x1a\x16.tangram.v1.OkResponse\x12=\n" +
+	"\aMoveTan\x12\x1a.tangram.v1.MoveTanRequest\x1a\x16.tangram.v1.OkResponse\x12G\n" +
+	"\bMoveTans\x12\x1c.tangram.v1.MoveBatchRequest\x1a\x1d.tangram.v1.MoveBatchResponse\x12?\n" +
+	"\n" +
+	"PushUpdate\x12\x19.tangram.v1.UpdateRequest\x1a\x16.tangram.v1.OkResponse\x12=\n" +
+	"\tPushDelta\x12\x18.tangram.v1.DeltaRequest\x1a\x16.tangram.v1.OkResponse\x129\n" +
+	"\x04Ping\x12\x17.tangram.v1.PingRequest\x1a\x18.tangram.v1.PingResponse\x12K\n" +
+	"\n" +
+	"GetLatency\x12\x1d.tangram.v1.GetLatencyRequest\x1a\x1e.tangram.v1.GetLatencyResponse\x12F\n" +
+	"\fGetCandidate\x12\x1f.tangram.v1.GetCandidateRequest\x1a\x15.tangram.v1.Candidate\x12G\n" +
+	"\fHostElection\x12\x1f.tangram.v1.HostElectionRequest\x1a\x16.tangram.v1.OkResponse\x12E\n" +
+	"\vConnectToMe\x12\x1e.tangram.v1.ConnectToMeRequest\x1a\x16.tangram.v1.OkResponse\x12E\n" +
+	"\bElection\x12\x1b.tangram.v1.ElectionRequest\x1a\x1c.tangram.v1.ElectionResponse\x12E\n" +
+	"\vCoordinator\x12\x1e.tangram.v1.CoordinatorRequest\x1a\x16.tangram.v1.OkResponse\x12@\n" +
+	"\vRequestVote\x12\x17.tangram.v1.VoteRequest\x1a\x18.tangram.v1.VoteResponse\x12F\n" +
+	"\rAppendEntries\x12\x19.tangram.v1.AppendRequest\x1a\x1a.tangram.v1.AppendResponse\x12?\n" +
+	"\x06Gossip\x12\x19.tangram.v1.GossipRequest\x1a\x1a.tangram.v1.GossipResponse\x12?\n" +
+	"\vProbeMember\x12\x18.tangram.v1.ProbeRequest\x1a\x16.tangram.v1.OkResponse\x129\n" +
+	"\x05Leave\x12\x18.tangram.v1.LeaveRequest\x1a\x16.tangram.v1.OkResponseB2Z0github.com/MCAxiaz/Distributed-Tangram/tangrampbb\x06proto3"
+
+var (
+	file_tangram_proto_rawDescOnce sync.Once
+	file_tangram_proto_rawDescData []byte
+)
+
+func file_tangram_proto_rawDescGZIP() []byte {
+	file_tangram_proto_rawDescOnce.Do(func() {
+		file_tangram_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tangram_proto_rawDesc), len(file_tangram_proto_rawDesc)))
+	})
+	return file_tangram_proto_rawDescData
+}
+
+var file_tangram_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_tangram_proto_msgTypes = make([]protoimpl.MessageInfo, 49)
+var file_tangram_proto_goTypes = []any{
+	(MemberStatus)(0),           // 0: tangram.v1.MemberStatus
+	(*Timestamp)(nil),           // 1: tangram.v1.Timestamp
+	(*Point)(nil),               // 2: tangram.v1.Point
+	(*Shape)(nil),               // 3: tangram.v1.Shape
+	(*Tan)(nil),                 // 4: tangram.v1.Tan
+	(*TargetTan)(nil),           // 5: tangram.v1.TargetTan
+	(*Player)(nil),              // 6: tangram.v1.Player
+	(*GameState)(nil),           // 7: tangram.v1.GameState
+	(*RelayPlan)(nil),           // 8: tangram.v1.RelayPlan
+	(*GameConfig)(nil),          // 9: tangram.v1.GameConfig
+	(*ConnectRequest)(nil),      // 10: tangram.v1.ConnectRequest
+	(*ConnectResponse)(nil),     // 11: tangram.v1.ConnectResponse
+	(*LockTanRequest)(nil),      // 12: tangram.v1.LockTanRequest
+	(*LockTanResponse)(nil),     // 13: tangram.v1.LockTanResponse
+	(*MoveTanRequest)(nil),      // 14: tangram.v1.MoveTanRequest
+	(*MoveBatchRequest)(nil),    // 15: tangram.v1.MoveBatchRequest
+	(*MoveBatchResponse)(nil),   // 16: tangram.v1.MoveBatchResponse
+	(*UpdateRequest)(nil),       // 17: tangram.v1.UpdateRequest
+	(*DeltaRequest)(nil),        // 18: tangram.v1.DeltaRequest
+	(*PingRequest)(nil),         // 19: tangram.v1.PingRequest
+	(*PingResponse)(nil),        // 20: tangram.v1.PingResponse
+	(*GetLatencyRequest)(nil),   // 21: tangram.v1.GetLatencyRequest
+	(*GetLatencyResponse)(nil),  // 22: tangram.v1.GetLatencyResponse
+	(*HostElectionRequest)(nil), // 23: tangram.v1.HostElectionRequest
+	(*ConnectToMeRequest)(nil),  // 24: tangram.v1.ConnectToMeRequest
+	(*GetCandidateRequest)(nil), // 25: tangram.v1.GetCandidateRequest
+	(*Candidate)(nil),           // 26: tangram.v1.Candidate
+	(*ElectionRequest)(nil),     // 27: tangram.v1.ElectionRequest
+	(*ElectionResponse)(nil),    // 28: tangram.v1.ElectionResponse
+	(*CoordinatorRequest)(nil),  // 29: tangram.v1.CoordinatorRequest
+	(*MemberUpdate)(nil),        // 30: tangram.v1.MemberUpdate
+	(*Link)(nil),                // 31: tangram.v1.Link
+	(*LinkReport)(nil),          // 32: tangram.v1.LinkReport
+	(*GossipRequest)(nil),       // 33: tangram.v1.GossipRequest
+	(*GossipResponse)(nil),      // 34: tangram.v1.GossipResponse
+	(*ProbeRequest)(nil),        // 35: tangram.v1.ProbeRequest
+	(*LeaveRequest)(nil),        // 36: tangram.v1.LeaveRequest
+	(*Event)(nil),               // 37: tangram.v1.Event
+	(*LogEntry)(nil),            // 38: tangram.v1.LogEntry
+	(*VoteRequest)(nil),         // 39: tangram.v1.VoteRequest
+	(*VoteResponse)(nil),        // 40: tangram.v1.VoteResponse
+	(*RaftSnapshot)(nil),        // 41: tangram.v1.RaftSnapshot
+	(*AppendRequest)(nil),       // 42: tangram.v1.AppendRequest
+	(*AppendResponse)(nil),      // 43: tangram.v1.AppendResponse
+	(*OkResponse)(nil),          // 44: tangram.v1.OkResponse
+	nil,                         // 45: tangram.v1.Tan.VectorEntry
+	nil,                         // 46: tangram.v1.RelayPlan.RelaysEntry
+	nil,                         // 47: tangram.v1.LockTanRequest.VectorEntry
+	nil,                         // 48: tangram.v1.LockTanResponse.VectorEntry
+	nil,                         // 49: tangram.v1.MoveTanRequest.VectorEntry
+}
+var file_tangram_proto_depIdxs = []int32{
+	2,  // 0: tangram.v1.Shape.points:type_name -> tangram.v1.Point
+	3,  // 1: tangram.v1.Tan.shape:type_name -> tangram.v1.Shape
+	2,  // 2: tangram.v1.Tan.location:type_name -> tangram.v1.Point
+	45, // 3: tangram.v1.Tan.vector:type_name -> tangram.v1.Tan.VectorEntry
+	3,  // 4: tangram.v1.TargetTan.shape:type_name -> tangram.v1.Shape
+	2,  // 5: tangram.v1.TargetTan.location:type_name -> tangram.v1.Point
+	4,  // 6: tangram.v1.GameState.tans:type_name -> tangram.v1.Tan
+	6,  // 7: tangram.v1.GameState.players:type_name -> tangram.v1.Player
+	8,  // 8: tangram.v1.GameState.relays:type_name -> tangram.v1.RelayPlan
+	46, // 9: tangram.v1.RelayPlan.relays:type_name -> tangram.v1.RelayPlan.RelaysEntry
+	2,  // 10: tangram.v1.GameConfig.size:type_name -> tangram.v1.Point
+	2,  // 11: tangram.v1.GameConfig.offset:type_name -> tangram.v1.Point
+	4,  // 12: tangram.v1.GameConfig.tans:type_name -> tangram.v1.Tan
+	5,  // 13: tangram.v1.GameConfig.targets:type_name -> tangram.v1.TargetTan
+	6,  // 14: tangram.v1.ConnectRequest.player:type_name -> tangram.v1.Player
+	1,  // 15: tangram.v1.ConnectRequest.stamp:type_name -> tangram.v1.Timestamp
+	7,  // 16: tangram.v1.ConnectResponse.state:type_name -> tangram.v1.GameState
+	9,  // 17: tangram.v1.ConnectResponse.config:type_name -> tangram.v1.GameConfig
+	6,  // 18: tangram.v1.ConnectResponse.player:type_name -> tangram.v1.Player
+	1,  // 19: tangram.v1.ConnectResponse.stamp:type_name -> tangram.v1.Timestamp
+	47, // 20: tangram.v1.LockTanRequest.vector:type_name -> tangram.v1.LockTanRequest.VectorEntry
+	1,  // 21: tangram.v1.LockTanRequest.stamp:type_name -> tangram.v1.Timestamp
+	48, // 22: tangram.v1.LockTanResponse.vector:type_name -> tangram.v1.LockTanResponse.VectorEntry
+	2,  // 23: tangram.v1.MoveTanRequest.location:type_name -> tangram.v1.Point
+	49, // 24: tangram.v1.MoveTanRequest.vector:type_name -> tangram.v1.MoveTanRequest.VectorEntry
+	1,  // 25: tangram.v1.MoveTanRequest.stamp:type_name -> tangram.v1.Timestamp
+	14, // 26: tangram.v1.MoveBatchRequest.moves:type_name -> tangram.v1.MoveTanRequest
+	1,  // 27: tangram.v1.MoveBatchRequest.stamp:type_name -> tangram.v1.Timestamp
+	7,  // 28: tangram.v1.UpdateRequest.state:type_name -> tangram.v1.GameState
+	1,  // 29: tangram.v1.UpdateRequest.stamp:type_name -> tangram.v1.Timestamp
+	7,  // 30: tangram.v1.DeltaRequest.delta:type_name -> tangram.v1.GameState
+	1,  // 31: tangram.v1.DeltaRequest.stamp:type_name -> tangram.v1.Timestamp
+	1,  // 32: tangram.v1.PingRequest.stamp:type_name -> tangram.v1.Timestamp
+	1,  // 33: tangram.v1.PingResponse.stamp:type_name -> tangram.v1.Timestamp
+	1,  // 34: tangram.v1.ElectionRequest.stamp:type_name -> tangram.v1.Timestamp
+	1,  // 35: tangram.v1.ElectionResponse.stamp:type_name -> tangram.v1.Timestamp
+	1,  // 36: tangram.v1.CoordinatorRequest.stamp:type_name -> tangram.v1.Timestamp
+	6,  // 37: tangram.v1.MemberUpdate.player:type_name -> tangram.v1.Player
+	0,  // 38: tangram.v1.MemberUpdate.status:type_name -> tangram.v1.MemberStatus
+	31, // 39: tangram.v1.LinkReport.links:type_name -> tangram.v1.Link
+	30, // 40: tangram.v1.GossipRequest.updates:type_name -> tangram.v1.MemberUpdate
+	1,  // 41: tangram.v1.GossipRequest.stamp:type_name -> tangram.v1.Timestamp
+	32, // 42: tangram.v1.GossipRequest.links:type_name -> tangram.v1.LinkReport
+	8,  // 43: tangram.v1.GossipRequest.relays:type_name -> tangram.v1.RelayPlan
+	30, // 44: tangram.v1.GossipResponse.updates:type_name -> tangram.v1.MemberUpdate
+	1,  // 45: tangram.v1.GossipResponse.stamp:type_name -> tangram.v1.Timestamp
+	32, // 46: tangram.v1.GossipResponse.links:type_name -> tangram.v1.LinkReport
+	8,  // 47: tangram.v1.GossipResponse.relays:type_name -> tangram.v1.RelayPlan
+	30, // 48: tangram.v1.ProbeRequest.updates:type_name -> tangram.v1.MemberUpdate
+	1,  // 49: tangram.v1.ProbeRequest.stamp:type_name -> tangram.v1.Timestamp
+	6,  // 50: tangram.v1.LeaveRequest.player:type_name -> tangram.v1.Player
+	1,  // 51: tangram.v1.LeaveRequest.stamp:type_name -> tangram.v1.Timestamp
+	2,  // 52: tangram.v1.Event.location:type_name -> tangram.v1.Point
+	37, // 53: tangram.v1.LogEntry.event:type_name -> tangram.v1.Event
+	1,  // 54: tangram.v1.VoteRequest.stamp:type_name -> tangram.v1.Timestamp
+	1,  // 55: tangram.v1.VoteResponse.stamp:type_name -> tangram.v1.Timestamp
+	37, // 56: tangram.v1.RaftSnapshot.events:type_name -> tangram.v1.Event
+	38, // 57: tangram.v1.AppendRequest.entries:type_name -> tangram.v1.LogEntry
+	1,  // 58: tangram.v1.AppendRequest.stamp:type_name -> tangram.v1.Timestamp
+	41, // 59: tangram.v1.AppendRequest.snapshot:type_name -> tangram.v1.RaftSnapshot
+	1,  // 60: tangram.v1.AppendResponse.stamp:type_name -> tangram.v1.Timestamp
+	10, // 61: tangram.v1.Node.Connect:input_type -> tangram.v1.ConnectRequest
+	12, // 62: tangram.v1.Node.LockTan:input_type -> tangram.v1.LockTanRequest
+	12, // 63: tangram.v1.Node.UnlockTan:input_type -> tangram.v1.LockTanRequest
+	14, // 64: tangram.v1.Node.MoveTan:input_type -> tangram.v1.MoveTanRequest
+	15, // 65: tangram.v1.Node.MoveTans:input_type -> tangram.v1.MoveBatchRequest
+	17, // 66: tangram.v1.Node.PushUpdate:input_type -> tangram.v1.UpdateRequest
+	18, // 67: tangram.v1.Node.PushDelta:input_type -> tangram.v1.DeltaRequest
+	19, // 68: tangram.v1.Node.Ping:input_type -> tangram.v1.PingRequest
+	21, // 69: tangram.v1.Node.GetLatency:input_type -> tangram.v1.GetLatencyRequest
+	25, // 70: tangram.v1.Node.GetCandidate:input_type -> tangram.v1.GetCandidateRequest
+	23, // 71: tangram.v1.Node.HostElection:input_type -> tangram.v1.HostElectionRequest
+	24, // 72: tangram.v1.Node.ConnectToMe:input_type -> tangram.v1.ConnectToMeRequest
+	27, // 73: tangram.v1.Node.Election:input_type -> tangram.v1.ElectionRequest
+	29, // 74: tangram.v1.Node.Coordinator:input_type -> tangram.v1.CoordinatorRequest
+	39, // 75: tangram.v1.Node.RequestVote:input_type -> tangram.v1.VoteRequest
+	42, // 76: tangram.v1.Node.AppendEntries:input_type -> tangram.v1.AppendRequest
+	33, // 77: tangram.v1.Node.Gossip:input_type -> tangram.v1.GossipRequest
+	35, // 78: tangram.v1.Node.ProbeMember:input_type -> tangram.v1.ProbeRequest
+	36, // 79: tangram.v1.Node.Leave:input_type -> tangram.v1.LeaveRequest
+	11, // 80: tangram.v1.Node.Connect:output_type -> tangram.v1.ConnectResponse
+	13, // 81: tangram.v1.Node.LockTan:output_type -> tangram.v1.LockTanResponse
+	44, // 82: tangram.v1.Node.UnlockTan:output_type -> tangram.v1.OkResponse
+	44, // 83: tangram.v1.Node.MoveTan:output_type -> tangram.v1.OkResponse
+	16, // 84: tangram.v1.Node.MoveTans:output_type -> tangram.v1.MoveBatchResponse
+	44, // 85: tangram.v1.Node.PushUpdate:output_type -> tangram.v1.OkResponse
+	44, // 86: tangram.v1.Node.PushDelta:output_type -> tangram.v1.OkResponse
+	20, // 87: tangram.v1.Node.Ping:output_type -> tangram.v1.PingResponse
+	22, // 88: tangram.v1.Node.GetLatency:output_type -> tangram.v1.GetLatencyResponse
+	26, // 89: tangram.v1.Node.GetCandidate:output_type -> tangram.v1.Candidate
+	44, // 90: tangram.v1.Node.HostElection:output_type -> tangram.v1.OkResponse
+	44, // 91: tangram.v1.Node.ConnectToMe:output_type -> tangram.v1.OkResponse
+	28, // 92: tangram.v1.Node.Election:output_type -> tangram.v1.ElectionResponse
+	44, // 93: tangram.v1.Node.Coordinator:output_type -> tangram.v1.OkResponse
+	40, // 94: tangram.v1.Node.RequestVote:output_type -> tangram.v1.VoteResponse
+	43, // 95: tangram.v1.Node.AppendEntries:output_type -> tangram.v1.AppendResponse
+	34, // 96: tangram.v1.Node.Gossip:output_type -> tangram.v1.GossipResponse
+	44, // 97: tangram.v1.Node.ProbeMember:output_type -> tangram.v1.OkResponse
+	44, // 98: tangram.v1.Node.Leave:output_type -> tangram.v1.OkResponse
+	80, // [80:99] is the sub-list for method output_type
+	61, // [61:80] is the sub-list for method input_type
+	61, // [61:61] is the sub-list for extension type_name
+	61, // [61:61] is the sub-list for extension extendee
+	0,  // [0:61] is the sub-list for field type_name
+}
+
+func init() { file_tangram_proto_init() }
+func file_tangram_proto_init() {
+	if File_tangram_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tangram_proto_rawDesc), len(file_tangram_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   49,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tangram_proto_goTypes,
+		DependencyIndexes: file_tangram_proto_depIdxs,
+		EnumInfos:         file_tangram_proto_enumTypes,
+		MessageInfos:      file_tangram_proto_msgTypes,
+	}.Build()
+	File_tangram_proto = out.File
+	file_tangram_proto_goTypes = nil
+	file_tangram_proto_depIdxs = nil
+}
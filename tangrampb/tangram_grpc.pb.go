@@ -0,0 +1,858 @@
+// Wire protocol between the nodes of a game of tangram.
+//
+// The service mirrors the RPC methods of tangram.Node, so that clients and bots
+// written in any language can join a game as first class peers.
+// Fields may be added to messages, but never renumbered or repurposed.
+// Breaking changes go into a new package version.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: tangram.proto
+
+package tangrampb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Node_Connect_FullMethodName       = "/tangram.v1.Node/Connect"
+	Node_LockTan_FullMethodName       = "/tangram.v1.Node/LockTan"
+	Node_UnlockTan_FullMethodName     = "/tangram.v1.Node/UnlockTan"
+	Node_MoveTan_FullMethodName       = "/tangram.v1.Node/MoveTan"
+	Node_MoveTans_FullMethodName      = "/tangram.v1.Node/MoveTans"
+	Node_PushUpdate_FullMethodName    = "/tangram.v1.Node/PushUpdate"
+	Node_PushDelta_FullMethodName     = "/tangram.v1.Node/PushDelta"
+	Node_Ping_FullMethodName          = "/tangram.v1.Node/Ping"
+	Node_GetLatency_FullMethodName    = "/tangram.v1.Node/GetLatency"
+	Node_GetCandidate_FullMethodName  = "/tangram.v1.Node/GetCandidate"
+	Node_HostElection_FullMethodName  = "/tangram.v1.Node/HostElection"
+	Node_ConnectToMe_FullMethodName   = "/tangram.v1.Node/ConnectToMe"
+	Node_Election_FullMethodName      = "/tangram.v1.Node/Election"
+	Node_Coordinator_FullMethodName   = "/tangram.v1.Node/Coordinator"
+	Node_RequestVote_FullMethodName   = "/tangram.v1.Node/RequestVote"
+	Node_AppendEntries_FullMethodName = "/tangram.v1.Node/AppendEntries"
+	Node_Gossip_FullMethodName        = "/tangram.v1.Node/Gossip"
+	Node_ProbeMember_FullMethodName   = "/tangram.v1.Node/ProbeMember"
+	Node_Leave_FullMethodName         = "/tangram.v1.Node/Leave"
+)
+
+// NodeClient is the client API for Node service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Node is the RPC interface of a tangram node
+type NodeClient interface {
+	// Connect joins the game of the node with a new player
+	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error)
+	// LockTan requests a tan, the reply may be deferred while the node holds it
+	LockTan(ctx context.Context, in *LockTanRequest, opts ...grpc.CallOption) (*LockTanResponse, error)
+	// UnlockTan releases a tan, or withdraws a request for it
+	UnlockTan(ctx context.Context, in *LockTanRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// MoveTan moves a tan held by the player
+	MoveTan(ctx context.Context, in *MoveTanRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// MoveTans moves tans in the order of the batch, peers stream their moves through it
+	// One batch is in flight per peer at a time over the long-lived connection,
+	// so the reply paces the sender while its later moves coalesce
+	MoveTans(ctx context.Context, in *MoveBatchRequest, opts ...grpc.CallOption) (*MoveBatchResponse, error)
+	// PushUpdate witnesses the full state pushed by the host
+	PushUpdate(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// PushDelta witnesses the changes pushed by the host
+	PushDelta(ctx context.Context, in *DeltaRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// Ping confirms that the connection is good and synchronises hybrid clocks
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	// GetLatency returns the average latency of the node to its peers
+	GetLatency(ctx context.Context, in *GetLatencyRequest, opts ...grpc.CallOption) (*GetLatencyResponse, error)
+	// GetCandidate returns what the node brings to a host election
+	GetCandidate(ctx context.Context, in *GetCandidateRequest, opts ...grpc.CallOption) (*Candidate, error)
+	// HostElection makes the node hold its own host election
+	HostElection(ctx context.Context, in *HostElectionRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// ConnectToMe announces a new host
+	ConnectToMe(ctx context.Context, in *ConnectToMeRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// Election is the election message of a candidate, the answer tells whether the node takes over
+	Election(ctx context.Context, in *ElectionRequest, opts ...grpc.CallOption) (*ElectionResponse, error)
+	// Coordinator announces the winner of a host election
+	Coordinator(ctx context.Context, in *CoordinatorRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// RequestVote asks a replica to vote for a candidate to lead the replicated log
+	RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error)
+	// AppendEntries stores the entries of the replicated log sent by the leader
+	AppendEntries(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendResponse, error)
+	// Gossip is the direct probe of the membership protocol, both ends exchange membership updates
+	Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error)
+	// ProbeMember probes a member on behalf of a member that could not reach it
+	ProbeMember(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*OkResponse, error)
+	// Leave drops a player that is leaving the game
+	Leave(ctx context.Context, in *LeaveRequest, opts ...grpc.CallOption) (*OkResponse, error)
+}
+
+type nodeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNodeClient(cc grpc.ClientConnInterface) NodeClient {
+	return &nodeClient{cc}
+}
+
+func (c *nodeClient) Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectResponse)
+	err := c.cc.Invoke(ctx, Node_Connect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) LockTan(ctx context.Context, in *LockTanRequest, opts ...grpc.CallOption) (*LockTanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockTanResponse)
+	err := c.cc.Invoke(ctx, Node_LockTan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) UnlockTan(ctx context.Context, in *LockTanRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_UnlockTan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) MoveTan(ctx context.Context, in *MoveTanRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_MoveTan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) MoveTans(ctx context.Context, in *MoveBatchRequest, opts ...grpc.CallOption) (*MoveBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveBatchResponse)
+	err := c.cc.Invoke(ctx, Node_MoveTans_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) PushUpdate(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_PushUpdate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) PushDelta(ctx context.Context, in *DeltaRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_PushDelta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, Node_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetLatency(ctx context.Context, in *GetLatencyRequest, opts ...grpc.CallOption) (*GetLatencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLatencyResponse)
+	err := c.cc.Invoke(ctx, Node_GetLatency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetCandidate(ctx context.Context, in *GetCandidateRequest, opts ...grpc.CallOption) (*Candidate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Candidate)
+	err := c.cc.Invoke(ctx, Node_GetCandidate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) HostElection(ctx context.Context, in *HostElectionRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_HostElection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) ConnectToMe(ctx context.Context, in *ConnectToMeRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_ConnectToMe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Election(ctx context.Context, in *ElectionRequest, opts ...grpc.CallOption) (*ElectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ElectionResponse)
+	err := c.cc.Invoke(ctx, Node_Election_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Coordinator(ctx context.Context, in *CoordinatorRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_Coordinator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VoteResponse)
+	err := c.cc.Invoke(ctx, Node_RequestVote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) AppendEntries(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendResponse)
+	err := c.cc.Invoke(ctx, Node_AppendEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GossipResponse)
+	err := c.cc.Invoke(ctx, Node_Gossip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) ProbeMember(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_ProbeMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Leave(ctx context.Context, in *LeaveRequest, opts ...grpc.CallOption) (*OkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OkResponse)
+	err := c.cc.Invoke(ctx, Node_Leave_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NodeServer is the server API for Node service.
+// All implementations must embed UnimplementedNodeServer
+// for forward compatibility.
+//
+// Node is the RPC interface of a tangram node
+type NodeServer interface {
+	// Connect joins the game of the node with a new player
+	Connect(context.Context, *ConnectRequest) (*ConnectResponse, error)
+	// LockTan requests a tan, the reply may be deferred while the node holds it
+	LockTan(context.Context, *LockTanRequest) (*LockTanResponse, error)
+	// UnlockTan releases a tan, or withdraws a request for it
+	UnlockTan(context.Context, *LockTanRequest) (*OkResponse, error)
+	// MoveTan moves a tan held by the player
+	MoveTan(context.Context, *MoveTanRequest) (*OkResponse, error)
+	// MoveTans moves tans in the order of the batch, peers stream their moves through it
+	// One batch is in flight per peer at a time over the long-lived connection,
+	// so the reply paces the sender while its later moves coalesce
+	MoveTans(context.Context, *MoveBatchRequest) (*MoveBatchResponse, error)
+	// PushUpdate witnesses the full state pushed by the host
+	PushUpdate(context.Context, *UpdateRequest) (*OkResponse, error)
+	// PushDelta witnesses the changes pushed by the host
+	PushDelta(context.Context, *DeltaRequest) (*OkResponse, error)
+	// Ping confirms that the connection is good and synchronises hybrid clocks
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	// GetLatency returns the average latency of the node to its peers
+	GetLatency(context.Context, *GetLatencyRequest) (*GetLatencyResponse, error)
+	// GetCandidate returns what the node brings to a host election
+	GetCandidate(context.Context, *GetCandidateRequest) (*Candidate, error)
+	// HostElection makes the node hold its own host election
+	HostElection(context.Context, *HostElectionRequest) (*OkResponse, error)
+	// ConnectToMe announces a new host
+	ConnectToMe(context.Context, *ConnectToMeRequest) (*OkResponse, error)
+	// Election is the election message of a candidate, the answer tells whether the node takes over
+	Election(context.Context, *ElectionRequest) (*ElectionResponse, error)
+	// Coordinator announces the winner of a host election
+	Coordinator(context.Context, *CoordinatorRequest) (*OkResponse, error)
+	// RequestVote asks a replica to vote for a candidate to lead the replicated log
+	RequestVote(context.Context, *VoteRequest) (*VoteResponse, error)
+	// AppendEntries stores the entries of the replicated log sent by the leader
+	AppendEntries(context.Context, *AppendRequest) (*AppendResponse, error)
+	// Gossip is the direct probe of the membership protocol, both ends exchange membership updates
+	Gossip(context.Context, *GossipRequest) (*GossipResponse, error)
+	// ProbeMember probes a member on behalf of a member that could not reach it
+	ProbeMember(context.Context, *ProbeRequest) (*OkResponse, error)
+	// Leave drops a player that is leaving the game
+	Leave(context.Context, *LeaveRequest) (*OkResponse, error)
+	mustEmbedUnimplementedNodeServer()
+}
+
+// UnimplementedNodeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNodeServer struct{}
+
+func (UnimplementedNodeServer) Connect(context.Context, *ConnectRequest) (*ConnectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedNodeServer) LockTan(context.Context, *LockTanRequest) (*LockTanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockTan not implemented")
+}
+func (UnimplementedNodeServer) UnlockTan(context.Context, *LockTanRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockTan not implemented")
+}
+func (UnimplementedNodeServer) MoveTan(context.Context, *MoveTanRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveTan not implemented")
+}
+func (UnimplementedNodeServer) MoveTans(context.Context, *MoveBatchRequest) (*MoveBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveTans not implemented")
+}
+func (UnimplementedNodeServer) PushUpdate(context.Context, *UpdateRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushUpdate not implemented")
+}
+func (UnimplementedNodeServer) PushDelta(context.Context, *DeltaRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushDelta not implemented")
+}
+func (UnimplementedNodeServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedNodeServer) GetLatency(context.Context, *GetLatencyRequest) (*GetLatencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatency not implemented")
+}
+func (UnimplementedNodeServer) GetCandidate(context.Context, *GetCandidateRequest) (*Candidate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCandidate not implemented")
+}
+func (UnimplementedNodeServer) HostElection(context.Context, *HostElectionRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HostElection not implemented")
+}
+func (UnimplementedNodeServer) ConnectToMe(context.Context, *ConnectToMeRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConnectToMe not implemented")
+}
+func (UnimplementedNodeServer) Election(context.Context, *ElectionRequest) (*ElectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Election not implemented")
+}
+func (UnimplementedNodeServer) Coordinator(context.Context, *CoordinatorRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Coordinator not implemented")
+}
+func (UnimplementedNodeServer) RequestVote(context.Context, *VoteRequest) (*VoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedNodeServer) AppendEntries(context.Context, *AppendRequest) (*AppendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedNodeServer) Gossip(context.Context, *GossipRequest) (*GossipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Gossip not implemented")
+}
+func (UnimplementedNodeServer) ProbeMember(context.Context, *ProbeRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeMember not implemented")
+}
+func (UnimplementedNodeServer) Leave(context.Context, *LeaveRequest) (*OkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Leave not implemented")
+}
+func (UnimplementedNodeServer) mustEmbedUnimplementedNodeServer() {}
+func (UnimplementedNodeServer) testEmbeddedByValue()              {}
+
+// UnsafeNodeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NodeServer will
+// result in compilation errors.
+type UnsafeNodeServer interface {
+	mustEmbedUnimplementedNodeServer()
+}
+
+func RegisterNodeServer(s grpc.ServiceRegistrar, srv NodeServer) {
+	// If the following call pancis, it indicates UnimplementedNodeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Node_ServiceDesc, srv)
+}
+
+func _Node_Connect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Connect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Connect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Connect(ctx, req.(*ConnectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_LockTan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockTanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).LockTan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_LockTan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).LockTan(ctx, req.(*LockTanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_UnlockTan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockTanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).UnlockTan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_UnlockTan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).UnlockTan(ctx, req.(*LockTanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_MoveTan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveTanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).MoveTan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_MoveTan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).MoveTan(ctx, req.(*MoveTanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_MoveTans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).MoveTans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_MoveTans_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).MoveTans(ctx, req.(*MoveBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_PushUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).PushUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_PushUpdate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).PushUpdate(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_PushDelta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeltaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).PushDelta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_PushDelta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).PushDelta(ctx, req.(*DeltaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetLatency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetLatency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_GetLatency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetLatency(ctx, req.(*GetLatencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetCandidate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCandidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetCandidate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_GetCandidate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetCandidate(ctx, req.(*GetCandidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_HostElection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HostElectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).HostElection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_HostElection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).HostElection(ctx, req.(*HostElectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_ConnectToMe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectToMeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).ConnectToMe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_ConnectToMe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).ConnectToMe(ctx, req.(*ConnectToMeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Election_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ElectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Election(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Election_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Election(ctx, req.(*ElectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Coordinator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CoordinatorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Coordinator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Coordinator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Coordinator(ctx, req.(*CoordinatorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_RequestVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).RequestVote(ctx, req.(*VoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_AppendEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).AppendEntries(ctx, req.(*AppendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Gossip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GossipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Gossip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Gossip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Gossip(ctx, req.(*GossipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_ProbeMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).ProbeMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_ProbeMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).ProbeMember(ctx, req.(*ProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Leave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Leave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Leave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Leave(ctx, req.(*LeaveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Node_ServiceDesc is the grpc.ServiceDesc for Node service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Node_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tangram.v1.Node",
+	HandlerType: (*NodeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Connect",
+			Handler:    _Node_Connect_Handler,
+		},
+		{
+			MethodName: "LockTan",
+			Handler:    _Node_LockTan_Handler,
+		},
+		{
+			MethodName: "UnlockTan",
+			Handler:    _Node_UnlockTan_Handler,
+		},
+		{
+			MethodName: "MoveTan",
+			Handler:    _Node_MoveTan_Handler,
+		},
+		{
+			MethodName: "MoveTans",
+			Handler:    _Node_MoveTans_Handler,
+		},
+		{
+			MethodName: "PushUpdate",
+			Handler:    _Node_PushUpdate_Handler,
+		},
+		{
+			MethodName: "PushDelta",
+			Handler:    _Node_PushDelta_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _Node_Ping_Handler,
+		},
+		{
+			MethodName: "GetLatency",
+			Handler:    _Node_GetLatency_Handler,
+		},
+		{
+			MethodName: "GetCandidate",
+			Handler:    _Node_GetCandidate_Handler,
+		},
+		{
+			MethodName: "HostElection",
+			Handler:    _Node_HostElection_Handler,
+		},
+		{
+			MethodName: "ConnectToMe",
+			Handler:    _Node_ConnectToMe_Handler,
+		},
+		{
+			MethodName: "Election",
+			Handler:    _Node_Election_Handler,
+		},
+		{
+			MethodName: "Coordinator",
+			Handler:    _Node_Coordinator_Handler,
+		},
+		{
+			MethodName: "RequestVote",
+			Handler:    _Node_RequestVote_Handler,
+		},
+		{
+			MethodName: "AppendEntries",
+			Handler:    _Node_AppendEntries_Handler,
+		},
+		{
+			MethodName: "Gossip",
+			Handler:    _Node_Gossip_Handler,
+		},
+		{
+			MethodName: "ProbeMember",
+			Handler:    _Node_ProbeMember_Handler,
+		},
+		{
+			MethodName: "Leave",
+			Handler:    _Node_Leave_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tangram.proto",
+}
@@ -1,391 +1,514 @@
-function openSocket() {
-    var socket = new WebSocket(`ws://${location.host}/ws`);
-    socket.addEventListener("open", function (e) {
-        console.log(`[Socket] Connected to ${socket.url}`);
-    });
-    socket.addEventListener("message", function (e) {
-        console.log("[Socket] Message\n", e.data);
-    });
-    socket.addEventListener("error", function (e) {
-        console.error(e);
-    });
-    return socket;
-}
-
-const NO_PLAYER = -1;
-
-function renderTan(model, path, txtPath) {
-    var transform = `translate(${model.location.x}, ${model.location.y}) rotate(${model.rotation})`;
-    var d = "";
-    model.shape.points.forEach(function (point, i) {
-        var command = i == 0 ? "M" : "L";
-        d += `${command} ${point.x} ${point.y} `;
-    });
-    d += "Z";
-
-    path.setAttribute('fill', model.shape.fill);
-    if (model.Matched) {
-        path.setAttribute('stroke', 'green');
-    } else {
-        path.setAttribute('stroke', model.shape.stroke);
-    }
-    path.setAttribute('transform', transform);
-    path.setAttribute('d', d);
-
-    if (model.player !== NO_PLAYER) {
-        // Render player ID to tan
-        path.classList.add("locked")
-        txtPath.innerHTML = model.player;
-    } else {
-        path.classList.remove("locked")
-        txtPath.innerHTML = "";
-    }
-    return path;
-}
-
-// Displays Solution text when solved
-function createSolutionText(solved) {
-    // Display player name on tan
-    var txt = document.getElementById(`solutiontxt`);
-    if (!txt) {
-      var svg = document.getElementById("g-text");
-
-      txt = document.createElementNS(view.namespaceURI, "text");
-      txt.setAttribute("font-family", "Garamond");
-      txt.setAttribute("font-size", "25");
-      txt.setAttribute("x", config.Size.x * 7 / 10 );
-      txt.setAttribute("y", config.Size.y / 10);
-      txt.setAttribute("style", "fill: green; font-weight: bold");
-      txt.setAttribute("pointer-events", "none")
-      txt.id = `solutiontxt`;
-      svg.appendChild(txt);
-    }
-
-    if (solved) {
-      txt.innerHTML = "SOLVED!";
-    } else {
-      txt.innerHTML = "";
-    }
-}
-
-function renderTargetTan(model, offset, node) {
-    var transform = `translate(${model.location.x + offset.x}, ${model.location.y + offset.y}) rotate(${model.rotation})`;
-    var d = "";
-    model.shape.points.forEach(function (point, i) {
-        var command = i == 0 ? "M" : "L";
-        d += `${command} ${point.x} ${point.y} `;
-    });
-    d += "Z";
-
-    node.setAttribute('fill', 'grey');
-    node.setAttribute('stroke', 'grey');
-    node.setAttribute('stroke-width', 2);
-    node.setAttribute('stroke-linejoin', 'round');
-    node.setAttribute('transform', transform);
-    node.setAttribute('d', d);
-    return node
-}
-
-var socket;
-var config;
-var state;
-var player;
-document.addEventListener("DOMContentLoaded", function(e) {
-    var view = document.getElementById("view");
-    var gPath = document.getElementById("g-path");
-    var gText = document.getElementById("g-text");
-    var timer = document.getElementById("timer");
-    var dump = document.getElementById("dump");
-    
-    function getTan(id) {
-        var model = state.tans.find(function (tan) {
-            return tan.id == id
-        });
-
-        if (!model) {
-            // The tan we are trying to find does not exist
-            return null
-        }
-
-        var path = view.getElementById(`tan-${id}`);
-        var text = view.getElementById(`txtPath-${id}`);
-        if (!path) {
-            var result = initializeTan(id);
-            path = result.path;
-            text = result.text;
-        }
-
-        return {model, path, text};
-    }
-
-    function render(state) {
-        for (let tan of state.tans) {
-            let {model, path, text} = getTan(tan.id);
-            renderTan(model, path, text);
-        }
-        createSolutionText(state.Solved)
-    }
-
-    function adjustPlayers(state) {
-        for (let tan of state.tans) {
-            if (tan.player == -1) {
-                continue;
-            }
-
-            var valid = false;
-            for (let p of state.Players) {
-                if (tan.player == p.ID) {
-                    valid = true;
-                    break;
-                }
-            }
-
-            if (!valid) {
-                unlockTan(tan.id);
-            }
-        }
-
-        var players = document.getElementById("current-players");
-        players.innerHTML = '';
-        var i = 0;
-        for (let player of state.Players) {
-                p = document.createElement("p");
-                n = document.createElement("span");
-                var str;
-                if (i == 0)
-                    str = "My ID: ";
-                else
-                    str = "ID: ";
-                i++;
-                n.innerHTML = str + player.ID
-                p.appendChild(n);
-                players.append(p);
-        }
-    }
-
-    // lockTan objectives
-    // - set player name on tan
-    // - highlight the tan to indicate someone has possession of it
-    // returns true if tan is successfully locked, false if not
-    function lockTan(tanID) {
-        var {model, path, text} = getTan(tanID)
-
-        if (model.player !== NO_PLAYER && model.player !== player.ID) {
-            console.log(`Another player ${model.player} is already holding onto the tan.`);
-            return false;
-        }
-
-        model.player = player.ID;
-        renderTan(model, path, text);
-
-        socket.send(JSON.stringify({
-            type: "ObtainTan",
-            tan: tanID,
-            release: false
-        }));
-
-        console.log(`[Lock tan] Tan ${tanID}: I am possessed by ${player.ID}.`);
-        return true;
-    }
-
-    function unlockTan(tanID) {
-        var {model, path, text} = getTan(tanID)
-
-        //if (model.player !== player.ID) {
-        //    console.log(`Another player ${model.player} is already holding onto the tan.`);
-        //    return false;
-        //}
-
-        model.player = NO_PLAYER;
-        renderTan(model, path, text);
-
-        socket.send(JSON.stringify({
-            type: "ObtainTan",
-            tan: tanID,
-            release: true
-        }));
-
-        console.log(`[Unlock tan] ${tanID}`);
-        return true;
-
-    }
-
-    function renderTarget(config) {
-        for (let ttan of config.targets) {
-            let node = document.createElementNS(view.namespaceURI, "path");
-            var gTarget = document.getElementById("g-target");
-            renderTargetTan(ttan, config.Offset, node)
-            gTarget.appendChild(node);
-        }
-    }
-
-    function renderGroups() {
-        var view = document.getElementById("view");
-    }
-
-    socket = openSocket();
-    socket.addEventListener("message", function (e) {
-        dump.innerHTML = e.data
-        var message = JSON.parse(e.data)
-        switch (message.type) {
-            case "state":
-                state = message.data
-                adjustPlayers(state);
-                render(state);
-                var hostInfo = document.getElementById("host-info");
-                hostInfo.innerHTML = state.host
-                break;
-            case "config":
-                config = message.data;
-                view.setAttribute("width", config.Size.x)
-                view.setAttribute("height", config.Size.y)
-                renderGroups();
-                renderTarget(config);
-                break;
-            case "player":
-                player = message.data;
-                break;
-        }
-    });
-    socket.addEventListener("open", function (e) {
-        socket.send(JSON.stringify({
-            type: "GetState"
-        }));
-    })
-
-    setInterval(function () {
-        if (state) {
-            var d = Date.now() - new Date(state.Timer).getTime()
-            timer.innerHTML = Math.round(d / 1000)
-        }
-    }, 100)
-
-    function mouseMoveListener(tan, startTanPos, startMousePos) {
-        return (e) => {
-            tan.location.x = Math.round(clamp(startTanPos.x + (e.clientX - startMousePos.x), 0, config.Size.x));
-            tan.location.y = Math.round(clamp(startTanPos.y + (e.clientY - startMousePos.y), 0, config.Size.y));
-            var {path, text} = getTan(tan.id)
-            renderTan(tan, path, text);
-            socket.send(JSON.stringify({
-                type: "MoveTan",
-                tan: tan.id,
-                location: tan.location,
-                rotation: tan.rotation
-            }));
-        }
-    };
-
-    // Rotate tan clockwise or counter-clockwise
-    function rotateListener (tan) {
-        return (e) => {
-            const key = e.which;
-            let d = 0;
-            switch (key) {
-                case 88:
-                d = 1;
-                break;
-                case 90:
-                d = -1
-                break;
-            }
-
-            if (d) {
-                console.log(`[rotate] ${key}`);
-                tan.rotation = rotate(tan.rotation, d);
-                var {path, text} = getTan(tan.id);
-                renderTan(tan, path, text);
-
-                socket.send(JSON.stringify({
-                    type: "MoveTan",
-                    tan: tan.id,
-                    location: tan.location,
-                    rotation: tan.rotation
-                }));
-            }
-        }
-    }
-
-    // Creates DOM nodes necessary to display a tan
-    function initializeTan(tanID) {
-        var path = document.createElementNS(view.namespaceURI, "path");
-        path.id = `tan-${tanID}`;
-        path.addEventListener("pointerdown", onMouseDown);
-        
-        var txt = document.createElementNS(view.namespaceURI, "text");
-        txt.setAttribute("font-family", "Verdana");
-        txt.setAttribute("font-size", "12");
-
-        var txtPath = document.createElementNS(view.namespaceURI, "textPath");
-        txtPath.setAttribute("href", `#${path.id}`);
-
-        txtPath.id = `txtPath-${tanID}`;
-        txtPath.innerHTML = "";
-
-        txt.appendChild(txtPath);
-        gPath.appendChild(path);
-        gText.appendChild(txt);
-
-        return {path, text: txtPath}
-    }
-
-    function onMouseDown(e) {
-        var persistent = e.ctrlKey
-        var path = e.target;
-        var id = parseInt(path.id.match(/tan-(\d+)/)[1]);
-        var tan = state.tans.find(function (tan) {
-            return tan.id == id
-        });
-
-        var held = release = tan.player === player.ID;
-        if (persistent) {
-            // Explicit locking
-            if (held) {
-                unlockTan(id);
-            } else {
-                lockTan(id);
-            }
-        } else {
-            // Drag and drop
-            if (!held) {
-                var ok = lockTan(id)
-                if (!ok) {
-                    return
-                }
-            }
-
-            const startTanPos = {
-                x: tan.location.x,
-                y: tan.location.y,
-                r: tan.rotation
-            };
-
-            const startMousePos = {
-                x: e.clientX,
-                y: e.clientY
-            };
-
-            var moveHandler = mouseMoveListener(tan, startTanPos, startMousePos);
-            var rotateHandler = rotateListener(tan);
-            var mouseUpHandler = function(e) {
-                if (!held) {
-                    unlockTan(id)
-                }
-                document.removeEventListener("pointermove", moveHandler);
-                document.removeEventListener("keydown", rotateHandler);
-                document.removeEventListener("pointerup", mouseUpHandler);
-            };
-
-            document.addEventListener("pointermove", moveHandler);
-            document.addEventListener("keydown", rotateHandler);
-            document.addEventListener("pointerup", mouseUpHandler);
-        }
-    }
-});
-
-function rotate(r, d) {
-    return (r + d * 15 + 720) % 360;
-}
-
-function clamp(x, min, max) {
-    return Math.max(min, Math.min(x, max))
+function openSocket() {
+    var socket = new WebSocket(`ws://${location.host}/ws`);
+    socket.addEventListener("open", function (e) {
+        console.log(`[Socket] Connected to ${socket.url}`);
+    });
+    socket.addEventListener("message", function (e) {
+        console.log("[Socket] Message\n", e.data);
+    });
+    socket.addEventListener("error", function (e) {
+        console.error(e);
+    });
+    return socket;
+}
+
+const NO_PLAYER = -1;
+
+function renderTan(model, path, txtPath) {
+    var transform = `translate(${model.location.x}, ${model.location.y}) rotate(${model.rotation})`;
+    var d = "";
+    model.shape.points.forEach(function (point, i) {
+        var command = i == 0 ? "M" : "L";
+        d += `${command} ${point.x} ${point.y} `;
+    });
+    d += "Z";
+
+    path.setAttribute('fill', model.shape.fill);
+    if (model.Matched) {
+        path.setAttribute('stroke', 'green');
+    } else {
+        path.setAttribute('stroke', model.shape.stroke);
+    }
+    path.setAttribute('transform', transform);
+    path.setAttribute('d', d);
+
+    if (model.player !== NO_PLAYER) {
+        // Render player ID to tan
+        path.classList.add("locked")
+        txtPath.innerHTML = model.player;
+    } else {
+        path.classList.remove("locked")
+        txtPath.innerHTML = "";
+    }
+    return path;
+}
+
+// Displays Solution text when solved
+function createSolutionText(solved) {
+    // Display player name on tan
+    var txt = document.getElementById(`solutiontxt`);
+    if (!txt) {
+      var svg = document.getElementById("g-text");
+
+      txt = document.createElementNS(view.namespaceURI, "text");
+      txt.setAttribute("font-family", "Garamond");
+      txt.setAttribute("font-size", "25");
+      txt.setAttribute("x", config.Size.x * 7 / 10 );
+      txt.setAttribute("y", config.Size.y / 10);
+      txt.setAttribute("style", "fill: green; font-weight: bold");
+      txt.setAttribute("pointer-events", "none")
+      txt.id = `solutiontxt`;
+      svg.appendChild(txt);
+    }
+
+    if (solved) {
+      txt.innerHTML = "SOLVED!";
+    } else {
+      txt.innerHTML = "";
+    }
+}
+
+function renderTargetTan(model, offset, node) {
+    var transform = `translate(${model.location.x + offset.x}, ${model.location.y + offset.y}) rotate(${model.rotation})`;
+    var d = "";
+    model.shape.points.forEach(function (point, i) {
+        var command = i == 0 ? "M" : "L";
+        d += `${command} ${point.x} ${point.y} `;
+    });
+    d += "Z";
+
+    node.setAttribute('fill', 'grey');
+    node.setAttribute('stroke', 'grey');
+    node.setAttribute('stroke-width', 2);
+    node.setAttribute('stroke-linejoin', 'round');
+    node.setAttribute('transform', transform);
+    node.setAttribute('d', d);
+    return node
+}
+
+var socket;
+var config;
+var state;
+var player;
+
+// Requests waiting for an ack or nack, keyed by request ID
+// Each remembers where the tan was, so it can snap back if the request is refused
+var pending = {};
+var nextRequestID = 1;
+// Stops the current drag, if any
+var cancelDrag = null;
+
+function sendRequest(msg, tan) {
+    msg.id = nextRequestID++;
+    pending[msg.id] = {
+        tan: tan.id,
+        location: {x: tan.location.x, y: tan.location.y},
+        rotation: tan.rotation
+    };
+    socket.send(JSON.stringify(msg));
+}
+document.addEventListener("DOMContentLoaded", function(e) {
+    var view = document.getElementById("view");
+    var gPath = document.getElementById("g-path");
+    var gText = document.getElementById("g-text");
+    var timer = document.getElementById("timer");
+    var dump = document.getElementById("dump");
+    var notice = document.getElementById("notice");
+    
+    function getTan(id) {
+        var model = state.tans.find(function (tan) {
+            return tan.id == id
+        });
+
+        if (!model) {
+            // The tan we are trying to find does not exist
+            return null
+        }
+
+        var path = view.getElementById(`tan-${id}`);
+        var text = view.getElementById(`txtPath-${id}`);
+        if (!path) {
+            var result = initializeTan(id);
+            path = result.path;
+            text = result.text;
+        }
+
+        return {model, path, text};
+    }
+
+    function render(state) {
+        for (let tan of state.tans) {
+            let {model, path, text} = getTan(tan.id);
+            renderTan(model, path, text);
+        }
+        createSolutionText(state.Solved)
+    }
+
+    function adjustPlayers(state) {
+        for (let tan of state.tans) {
+            if (tan.player == -1) {
+                continue;
+            }
+
+            var valid = false;
+            for (let p of state.Players) {
+                if (tan.player == p.ID) {
+                    valid = true;
+                    break;
+                }
+            }
+
+            if (!valid) {
+                unlockTan(tan.id);
+            }
+        }
+
+        var players = document.getElementById("current-players");
+        players.innerHTML = '';
+        var i = 0;
+        for (let player of state.Players) {
+                p = document.createElement("p");
+                n = document.createElement("span");
+                var str;
+                if (i == 0)
+                    str = "My ID: ";
+                else
+                    str = "ID: ";
+                i++;
+                var relay = state.relays.relays ? state.relays.relays[player.ID] : undefined;
+                if (relay === player.ID)
+                    str += player.ID + " (relay)";
+                else if (relay !== undefined)
+                    str += player.ID + " (via " + relay + ")";
+                else
+                    str += player.ID;
+                n.innerHTML = str
+                p.appendChild(n);
+                players.append(p);
+        }
+    }
+
+    // lockTan objectives
+    // - set player name on tan
+    // - highlight the tan to indicate someone has possession of it
+    // returns true if tan is successfully locked, false if not
+    function lockTan(tanID) {
+        var {model, path, text} = getTan(tanID)
+
+        if (model.player !== NO_PLAYER && model.player !== player.ID) {
+            console.log(`Another player ${model.player} is already holding onto the tan.`);
+            return false;
+        }
+
+        model.player = player.ID;
+        renderTan(model, path, text);
+
+        sendRequest({
+            type: "ObtainTan",
+            tan: tanID,
+            release: false
+        }, model);
+
+        console.log(`[Lock tan] Tan ${tanID}: I am possessed by ${player.ID}.`);
+        return true;
+    }
+
+    function unlockTan(tanID) {
+        var {model, path, text} = getTan(tanID)
+
+        //if (model.player !== player.ID) {
+        //    console.log(`Another player ${model.player} is already holding onto the tan.`);
+        //    return false;
+        //}
+
+        model.player = NO_PLAYER;
+        renderTan(model, path, text);
+
+        sendRequest({
+            type: "ObtainTan",
+            tan: tanID,
+            release: true
+        }, model);
+
+        console.log(`[Unlock tan] ${tanID}`);
+        return true;
+
+    }
+
+    // Snaps the tan back to where it was before the refused request
+    // and shows who holds it
+    function rejectRequest(nack) {
+        var request = pending[nack.id];
+        delete pending[nack.id];
+        console.log(`[Nack] Request ${nack.id} on tan ${nack.tan} refused: ${nack.reason}`);
+
+        var tan = getTan(nack.tan);
+        if (!tan) {
+            return;
+        }
+
+        var {model, path, text} = tan;
+        if (request) {
+            model.location = request.location;
+            model.rotation = request.rotation;
+        }
+        model.player = nack.player;
+        renderTan(model, path, text);
+
+        if (cancelDrag && cancelDrag.tan == nack.tan) {
+            cancelDrag();
+        }
+
+        if (nack.reason == "held") {
+            notice.innerHTML = `Tan ${nack.tan} is held by player ${nack.player}`;
+        } else {
+            notice.innerHTML = `Could not take tan ${nack.tan}: ${nack.reason}`;
+        }
+    }
+
+    function renderTarget(config) {
+        for (let ttan of config.targets) {
+            let node = document.createElementNS(view.namespaceURI, "path");
+            var gTarget = document.getElementById("g-target");
+            renderTargetTan(ttan, config.Offset, node)
+            gTarget.appendChild(node);
+        }
+    }
+
+    function renderTopology(topology) {
+        var table = document.getElementById("topology-info");
+        table.innerHTML = '';
+        var players = topology.players || [];
+        var links = {};
+        for (let link of topology.links || []) {
+            links[`${link.from}-${link.to}`] = link;
+        }
+
+        var header = document.createElement("tr");
+        header.appendChild(document.createElement("th"));
+        for (let to of players) {
+            var th = document.createElement("th");
+            th.innerHTML = to;
+            header.appendChild(th);
+        }
+        table.appendChild(header);
+
+        for (let from of players) {
+            var row = document.createElement("tr");
+            var th = document.createElement("th");
+            th.innerHTML = from;
+            row.appendChild(th);
+            for (let to of players) {
+                var td = document.createElement("td");
+                var link = links[`${from}-${to}`];
+                if (link) {
+                    var rtt = (link.rtt / 1e6).toFixed(1);
+                    var jitter = (link.jitter / 1e6).toFixed(1);
+                    var loss = Math.round(link.loss * 100);
+                    td.innerHTML = `${rtt} ±${jitter} (${loss}%)`;
+                } else {
+                    td.innerHTML = from == to ? "" : "-";
+                }
+                row.appendChild(td);
+            }
+            table.appendChild(row);
+        }
+    }
+
+    function renderGroups() {
+        var view = document.getElementById("view");
+    }
+
+    socket = openSocket();
+    socket.addEventListener("message", function (e) {
+        dump.innerHTML = e.data
+        var message = JSON.parse(e.data)
+        switch (message.type) {
+            case "state":
+                state = message.data
+                adjustPlayers(state);
+                render(state);
+                var hostInfo = document.getElementById("host-info");
+                hostInfo.innerHTML = state.host
+                var electionInfo = document.getElementById("election-info");
+                electionInfo.innerHTML = state.election ? `${state.election} (term ${state.term})` : "none"
+                break;
+            case "config":
+                config = message.data;
+                view.setAttribute("width", config.Size.x)
+                view.setAttribute("height", config.Size.y)
+                renderGroups();
+                renderTarget(config);
+                break;
+            case "player":
+                player = message.data;
+                break;
+            case "ack":
+                delete pending[message.data.id];
+                break;
+            case "nack":
+                rejectRequest(message.data);
+                break;
+            case "topology":
+                renderTopology(message.data);
+                break;
+        }
+    });
+    socket.addEventListener("open", function (e) {
+        socket.send(JSON.stringify({
+            type: "GetState"
+        }));
+    })
+
+    setInterval(function () {
+        if (state) {
+            var d = Date.now() - new Date(state.Timer).getTime()
+            timer.innerHTML = Math.round(d / 1000)
+        }
+    }, 100)
+
+    setInterval(function () {
+        if (socket.readyState == WebSocket.OPEN) {
+            socket.send(JSON.stringify({
+                type: "GetTopology"
+            }));
+        }
+    }, 2000)
+
+    function mouseMoveListener(tan, startTanPos, startMousePos) {
+        return (e) => {
+            var origin = {id: tan.id, location: {x: tan.location.x, y: tan.location.y}, rotation: tan.rotation};
+            tan.location.x = Math.round(clamp(startTanPos.x + (e.clientX - startMousePos.x), 0, config.Size.x));
+            tan.location.y = Math.round(clamp(startTanPos.y + (e.clientY - startMousePos.y), 0, config.Size.y));
+            var {path, text} = getTan(tan.id)
+            renderTan(tan, path, text);
+            sendRequest({
+                type: "MoveTan",
+                tan: tan.id,
+                location: tan.location,
+                rotation: tan.rotation
+            }, origin);
+        }
+    };
+
+    // Rotate tan clockwise or counter-clockwise
+    function rotateListener (tan) {
+        return (e) => {
+            const key = e.which;
+            let d = 0;
+            switch (key) {
+                case 88:
+                d = 1;
+                break;
+                case 90:
+                d = -1
+                break;
+            }
+
+            if (d) {
+                console.log(`[rotate] ${key}`);
+                var origin = {id: tan.id, location: {x: tan.location.x, y: tan.location.y}, rotation: tan.rotation};
+                tan.rotation = rotate(tan.rotation, d);
+                var {path, text} = getTan(tan.id);
+                renderTan(tan, path, text);
+
+                sendRequest({
+                    type: "MoveTan",
+                    tan: tan.id,
+                    location: tan.location,
+                    rotation: tan.rotation
+                }, origin);
+            }
+        }
+    }
+
+    // Creates DOM nodes necessary to display a tan
+    function initializeTan(tanID) {
+        var path = document.createElementNS(view.namespaceURI, "path");
+        path.id = `tan-${tanID}`;
+        path.addEventListener("pointerdown", onMouseDown);
+        
+        var txt = document.createElementNS(view.namespaceURI, "text");
+        txt.setAttribute("font-family", "Verdana");
+        txt.setAttribute("font-size", "12");
+
+        var txtPath = document.createElementNS(view.namespaceURI, "textPath");
+        txtPath.setAttribute("href", `#${path.id}`);
+
+        txtPath.id = `txtPath-${tanID}`;
+        txtPath.innerHTML = "";
+
+        txt.appendChild(txtPath);
+        gPath.appendChild(path);
+        gText.appendChild(txt);
+
+        return {path, text: txtPath}
+    }
+
+    function onMouseDown(e) {
+        var persistent = e.ctrlKey
+        var path = e.target;
+        var id = parseInt(path.id.match(/tan-(\d+)/)[1]);
+        var tan = state.tans.find(function (tan) {
+            return tan.id == id
+        });
+
+        var held = release = tan.player === player.ID;
+        if (persistent) {
+            // Explicit locking
+            if (held) {
+                unlockTan(id);
+            } else {
+                lockTan(id);
+            }
+        } else {
+            // Drag and drop
+            if (!held) {
+                var ok = lockTan(id)
+                if (!ok) {
+                    return
+                }
+            }
+
+            const startTanPos = {
+                x: tan.location.x,
+                y: tan.location.y,
+                r: tan.rotation
+            };
+
+            const startMousePos = {
+                x: e.clientX,
+                y: e.clientY
+            };
+
+            var moveHandler = mouseMoveListener(tan, startTanPos, startMousePos);
+            var rotateHandler = rotateListener(tan);
+            var stopDrag = function() {
+                cancelDrag = null;
+                document.removeEventListener("pointermove", moveHandler);
+                document.removeEventListener("keydown", rotateHandler);
+                document.removeEventListener("pointerup", mouseUpHandler);
+            };
+            var mouseUpHandler = function(e) {
+                if (!held) {
+                    unlockTan(id)
+                }
+                stopDrag();
+            };
+            cancelDrag = stopDrag;
+            cancelDrag.tan = id;
+
+            document.addEventListener("pointermove", moveHandler);
+            document.addEventListener("keydown", rotateHandler);
+            document.addEventListener("pointerup", mouseUpHandler);
+        }
+    }
+});
+
+function rotate(r, d) {
+    return (r + d * 15 + 720) % 360;
+}
+
+function clamp(x, min, max) {
+    return Math.max(min, Math.min(x, max))
 }
\ No newline at end of file
@@ -1,147 +1,257 @@
-package webserver
-
-import (
-	"encoding/json"
-	"fmt"
-	"log"
-
-	"../tangram"
-	"github.com/gorilla/websocket"
-)
-
-type Message struct {
-	MsgType string `json:"type"`
-}
-
-type OutputMessage struct {
-	MsgType string      `json:"type"`
-	Data    interface{} `json:"data"`
-}
-
-type Handler struct {
-	game *tangram.Game
-}
-
-func NewHandler(game *tangram.Game) *Handler {
-	return &Handler{game}
-}
-
-func (handler *Handler) Handle(conn *websocket.Conn) (err error) {
-	changeChan := handler.game.Subscribe()
-	defer handler.game.Unsubscribe(changeChan)
-
-	msgChan := make(chan []byte, 10)
-	go func() {
-		for {
-			_, msg, err := conn.ReadMessage()
-			if err != nil {
-				close(msgChan)
-				return
-			}
-			log.Printf("[Handle] Inbound Message %s", msg)
-			msgChan <- msg
-		}
-	}()
-
-	conn.WriteJSON(OutputMessage{"player", handler.game.GetPlayer()})
-	conn.WriteJSON(OutputMessage{"config", handler.game.GetConfig()})
-
-	for {
-		select {
-		// Handle change
-		case _, ok := <-changeChan:
-			if !ok {
-				log.Println("[Handle] Change Channel closed")
-				return
-			}
-			handler.handleChange(conn)
-		// Handle msg
-		case msg, ok := <-msgChan:
-			if !ok {
-				log.Println("[Handle] Message Channel closed")
-				return
-			}
-			err = handler.handleMessage(conn, msg)
-			if err != nil {
-				log.Printf("[Handle] Error: %s", err.Error())
-			}
-		}
-	}
-}
-
-func (handler *Handler) handleChange(conn *websocket.Conn) {
-	state := handler.game.GetState()
-	conn.WriteJSON(OutputMessage{"state", state})
-}
-
-func (handler *Handler) handleMessage(conn *websocket.Conn, data []byte) (err error) {
-	var msg Message
-	err = json.Unmarshal(data, &msg)
-	if err != nil {
-		return
-	}
-
-	switch msg.MsgType {
-	case "GetState":
-		err = handler.handleGetState(conn, data)
-	case "ObtainTan":
-		err = handler.handleObtainTan(conn, data)
-	case "MoveTan":
-		err = handler.handleMoveTan(conn, data)
-	default:
-		err = fmt.Errorf("Unsupported Message %s", msg.MsgType)
-	}
-	return
-}
-
-func (handler *Handler) handleGetState(conn *websocket.Conn, data []byte) (err error) {
-	state := handler.game.GetState()
-	err = conn.WriteJSON(OutputMessage{"state", state})
-	return
-}
-
-type ObtainTanMessage struct {
-	Tan     tangram.TanID `json:"tan"`
-	Release bool          `json:"release"`
-}
-
-func (handler *Handler) handleObtainTan(conn *websocket.Conn, data []byte) (err error) {
-	var msg ObtainTanMessage
-	err = json.Unmarshal(data, &msg)
-	if err != nil {
-		return
-	}
-	_, err = handler.game.ObtainTan(msg.Tan, msg.Release)
-	if err != nil {
-		return
-	}
-	// Do something with it?
-	// TODO signal failure
-	// log.Println(ok)
-	return
-}
-
-type MoveTanMessage struct {
-	Tan      tangram.TanID    `json:"tan"`
-	Location tangram.Point    `json:"location"`
-	Rotation tangram.Rotation `json:"rotation"`
-}
-
-func (handler *Handler) handleMoveTan(conn *websocket.Conn, data []byte) (err error) {
-	var msg MoveTanMessage
-	err = json.Unmarshal(data, &msg)
-	if err != nil {
-		return
-	}
-	_, err = handler.game.MoveTan(msg.Tan, msg.Location, msg.Rotation)
-	// Do something with it?
-	// TODO signal failure
-	// log.Println(ok)
-	return
-}
-
-func handleError(err error) {
-	if err != nil {
-		log.Println(err)
-	}
-}
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"../tangram"
+	"github.com/gorilla/websocket"
+)
+
+type Message struct {
+	MsgType string        `json:"type"`
+	Tan     tangram.TanID `json:"tan"`
+}
+
+type OutputMessage struct {
+	MsgType string      `json:"type"`
+	Data    interface{} `json:"data"`
+}
+
+// AckMessage confirms that the request with ID succeeded
+type AckMessage struct {
+	ID int `json:"id"`
+}
+
+// NackMessage reports that the request with ID was refused
+// - Reason: Why the request was refused
+// - Player: The player holding the tan, tangram.NoPlayer if nobody does
+type NackMessage struct {
+	ID     int              `json:"id"`
+	Tan    tangram.TanID    `json:"tan"`
+	Reason string           `json:"reason"`
+	Player tangram.PlayerID `json:"player"`
+}
+
+type Handler struct {
+	game *tangram.Game
+}
+
+// session is the state of a single websocket connection, only its Handle loop writes to conn
+// Obtaining a tan may wait on the host for long, so it runs aside and its outcome comes back
+// through obtained. The later messages on the tan wait for it, so they apply in the order sent,
+// while the messages on other tans keep flowing.
+type session struct {
+	conn     *websocket.Conn
+	obtained chan obtainReply
+	done     chan struct{}
+	waiting  map[tangram.TanID][][]byte
+}
+
+func NewHandler(game *tangram.Game) *Handler {
+	return &Handler{game}
+}
+
+func (handler *Handler) Handle(conn *websocket.Conn) (err error) {
+	changeChan := handler.game.Subscribe()
+	defer handler.game.Unsubscribe(changeChan)
+
+	msgChan := make(chan []byte, 10)
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				close(msgChan)
+				return
+			}
+			log.Printf("[Handle] Inbound Message %s", msg)
+			msgChan <- msg
+		}
+	}()
+
+	s := &session{conn, make(chan obtainReply), make(chan struct{}), make(map[tangram.TanID][][]byte)}
+	defer close(s.done)
+
+	conn.WriteJSON(OutputMessage{"player", handler.game.GetPlayer()})
+	conn.WriteJSON(OutputMessage{"config", handler.game.GetConfig()})
+
+	for {
+		select {
+		// Handle change
+		case _, ok := <-changeChan:
+			if !ok {
+				log.Println("[Handle] Change Channel closed")
+				return
+			}
+			handler.handleChange(conn)
+		// Handle msg
+		case msg, ok := <-msgChan:
+			if !ok {
+				log.Println("[Handle] Message Channel closed")
+				return
+			}
+			err = handler.handleMessage(s, msg)
+			if err != nil {
+				log.Printf("[Handle] Error: %s", err.Error())
+			}
+		// Handle an obtained or refused tan
+		case r := <-s.obtained:
+			err = handler.handleObtained(s, r)
+			if err != nil {
+				log.Printf("[Handle] Error: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (handler *Handler) handleChange(conn *websocket.Conn) {
+	state := handler.getState()
+	conn.WriteJSON(OutputMessage{"state", state})
+}
+
+// getState returns the game state with the timer expressed in local wall time
+// The browser measures the timer with its own clock
+func (handler *Handler) getState() *tangram.GameState {
+	state := handler.game.GetState()
+	state.Timer = time.Now().Add(-handler.game.GetTime())
+	return state
+}
+
+func (handler *Handler) handleMessage(s *session, data []byte) (err error) {
+	var msg Message
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		return
+	}
+
+	if queue, obtaining := s.waiting[msg.Tan]; obtaining && (msg.MsgType == "ObtainTan" || msg.MsgType == "MoveTan") {
+		s.waiting[msg.Tan] = append(queue, data)
+		return
+	}
+
+	switch msg.MsgType {
+	case "GetState":
+		err = handler.handleGetState(s.conn, data)
+	case "ObtainTan":
+		err = handler.handleObtainTan(s, data)
+	case "MoveTan":
+		err = handler.handleMoveTan(s.conn, data)
+	case "GetTopology":
+		err = handler.handleGetTopology(s.conn, data)
+	default:
+		err = fmt.Errorf("Unsupported Message %s", msg.MsgType)
+	}
+	return
+}
+
+func (handler *Handler) handleGetState(conn *websocket.Conn, data []byte) (err error) {
+	state := handler.getState()
+	err = conn.WriteJSON(OutputMessage{"state", state})
+	return
+}
+
+// handleGetTopology replies with the latency matrix between the players, see tangram.Game.Topology
+func (handler *Handler) handleGetTopology(conn *websocket.Conn, data []byte) (err error) {
+	err = conn.WriteJSON(OutputMessage{"topology", handler.game.Topology()})
+	return
+}
+
+// ObtainTanMessage requests or releases a tan
+// - ID: Identifies the request in the ack or nack replying to it
+type ObtainTanMessage struct {
+	ID      int           `json:"id"`
+	Tan     tangram.TanID `json:"tan"`
+	Release bool          `json:"release"`
+}
+
+// obtainReply is the outcome of an ObtainTanMessage
+type obtainReply struct {
+	msg ObtainTanMessage
+	ok  bool
+	err error
+}
+
+// handleObtainTan obtains or releases the tan in its own goroutine, see session
+func (handler *Handler) handleObtainTan(s *session, data []byte) (err error) {
+	var msg ObtainTanMessage
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		return
+	}
+	s.waiting[msg.Tan] = nil
+	go func() {
+		ok, err := handler.game.ObtainTan(msg.Tan, msg.Release)
+		select {
+		case s.obtained <- obtainReply{msg, ok, err}:
+		case <-s.done:
+		}
+	}()
+	return
+}
+
+// handleObtained replies to the ObtainTanMessage, then handles the messages that waited for it
+// They wait again as soon as one of them obtains the tan anew
+func (handler *Handler) handleObtained(s *session, r obtainReply) (err error) {
+	err = handler.reply(s.conn, r.msg.ID, r.msg.Tan, r.ok, r.err)
+	queue := s.waiting[r.msg.Tan]
+	delete(s.waiting, r.msg.Tan)
+	for i, data := range queue {
+		if _, obtaining := s.waiting[r.msg.Tan]; obtaining {
+			s.waiting[r.msg.Tan] = append(s.waiting[r.msg.Tan], queue[i:]...)
+			break
+		}
+		handleError(handler.handleMessage(s, data))
+	}
+	return
+}
+
+// MoveTanMessage moves a held tan
+// - ID: Identifies the request in the ack or nack replying to it
+type MoveTanMessage struct {
+	ID       int              `json:"id"`
+	Tan      tangram.TanID    `json:"tan"`
+	Location tangram.Point    `json:"location"`
+	Rotation tangram.Rotation `json:"rotation"`
+}
+
+func (handler *Handler) handleMoveTan(conn *websocket.Conn, data []byte) (err error) {
+	var msg MoveTanMessage
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		return
+	}
+	ok, err := handler.game.MoveTan(msg.Tan, msg.Location, msg.Rotation)
+	err = handler.reply(conn, msg.ID, msg.Tan, ok, err)
+	return
+}
+
+// reply acks the request if it succeeded, or nacks it with the reason it was refused
+// Errors other than a *tangram.TanError are returned after nacking the request
+func (handler *Handler) reply(conn *websocket.Conn, id int, tan tangram.TanID, ok bool, err error) error {
+	if ok && err == nil {
+		return conn.WriteJSON(OutputMessage{"ack", AckMessage{id}})
+	}
+
+	nack := NackMessage{id, tan, "", tangram.NoPlayer}
+	if tanErr, refused := err.(*tangram.TanError); refused {
+		nack.Reason = string(tanErr.Reason)
+		nack.Player = tanErr.Player
+		err = nil
+	} else if err != nil {
+		nack.Reason = err.Error()
+	}
+
+	writeErr := conn.WriteJSON(OutputMessage{"nack", nack})
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
+
+func handleError(err error) {
+	if err != nil {
+		log.Println(err)
+	}
+}